@@ -0,0 +1,170 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+import "slices"
+
+// Accumulator rebuilds a complete ChatResponse from streaming chunks, so a
+// streamed call yields the same value the unary call would have returned.
+// Choices are keyed by their Index and merged with Message.AppendDelta, with
+// "\n" between the text (and the thinking) of different content blocks
+// marked by a BlockDelta; the last non-nil finish reason and usage win;
+// chunk-level and per-choice Extensions merge through the ExtensionMerger
+// contract. The zero value is ready to use. An Accumulator is not safe for
+// concurrent use.
+type Accumulator struct {
+	resp    ChatResponse
+	choices map[int]*choiceState
+}
+
+// BlockDelta is implemented by a per-choice stream extension value that a
+// provider whose messages are made of content blocks attaches to its text
+// and thinking deltas. BlockIndex names the block the delta belongs to. The
+// Accumulator joins the text, and the thinking, of different blocks with
+// "\n" as the unary responses do; the value is a stream-only marker and is
+// not merged into the response.
+type BlockDelta interface {
+	BlockIndex() int
+}
+
+// choiceState is one choice being accumulated, with the content blocks its
+// latest text and thinking deltas belonged to.
+type choiceState struct {
+	Choice
+
+	textBlock, thinkingBlock int
+	hasText, hasThinking     bool
+}
+
+// Add folds one chunk into the accumulated response. A nil chunk is ignored.
+func (a *Accumulator) Add(chunk *StreamChunk) {
+	if chunk == nil {
+		return
+	}
+
+	if chunk.ID != "" {
+		a.resp.ID = chunk.ID
+	}
+
+	if chunk.Created != 0 {
+		a.resp.Created = chunk.Created
+	}
+
+	if chunk.Model != "" {
+		a.resp.Model = chunk.Model
+	}
+
+	if chunk.Usage != nil {
+		a.resp.Usage = *chunk.Usage
+	}
+
+	a.resp.Extensions.mergeDelta(chunk.Extensions)
+
+	for i := range chunk.Choices {
+		a.addChoice(&chunk.Choices[i])
+	}
+}
+
+// addChoice merges one chunk choice into the choice with the same Index.
+func (a *Accumulator) addChoice(cc *StreamChunkChoice) {
+	if a.choices == nil {
+		a.choices = map[int]*choiceState{}
+	}
+
+	c, ok := a.choices[cc.Index]
+	if !ok {
+		c = &choiceState{Choice: Choice{Index: cc.Index}}
+		a.choices[cc.Index] = c
+	}
+
+	block, exts := splitBlockDelta(cc.Extensions)
+
+	if cc.Delta.Content.text != "" {
+		if c.hasText && block != c.textBlock {
+			c.Message.Content.text += "\n"
+		}
+
+		c.textBlock, c.hasText = block, true
+	}
+
+	if cc.Delta.Thinking != "" {
+		if c.hasThinking && block != c.thinkingBlock {
+			c.Message.Thinking += "\n"
+		}
+
+		c.thinkingBlock, c.hasThinking = block, true
+	}
+
+	if cc.Delta.Role != "" {
+		c.Message.Role = cc.Delta.Role
+	}
+
+	if cc.Delta.ToolCallID != "" {
+		c.Message.ToolCallID = cc.Delta.ToolCallID
+	}
+
+	c.Message.AppendDelta(&cc.Delta)
+
+	if cc.FinishReason != nil && *cc.FinishReason != "" {
+		c.FinishReason = FinishReason(*cc.FinishReason)
+	}
+
+	c.Extensions.mergeDelta(exts)
+}
+
+// splitBlockDelta returns the block index a BlockDelta value in exts names
+// (zero without one) and exts without the marker.
+func splitBlockDelta(exts Extensions) (int, Extensions) {
+	for provider, v := range exts {
+		if bd, ok := v.(BlockDelta); ok {
+			rest := exts.Clone()
+			delete(rest, provider)
+
+			return bd.BlockIndex(), rest
+		}
+	}
+
+	return 0, exts
+}
+
+// Response returns the response accumulated so far, with choices ordered by
+// Index, Object set to "chat.completion", and an empty message role defaulted
+// to assistant (not every provider repeats the role on its deltas). Take it
+// once the stream has ended: later Add calls may modify the returned
+// messages.
+func (a *Accumulator) Response() *ChatResponse {
+	resp := a.resp
+	resp.Object = "chat.completion"
+	resp.Extensions = a.resp.Extensions.Clone()
+
+	if len(a.choices) > 0 {
+		resp.Choices = make([]Choice, 0, len(a.choices))
+		for _, c := range a.choices {
+			choice := c.Choice
+			if choice.Message.Role == "" {
+				choice.Message.Role = RoleAssistant
+			}
+
+			resp.Choices = append(resp.Choices, choice)
+		}
+
+		slices.SortFunc(resp.Choices, func(x, y Choice) int { return x.Index - y.Index })
+	}
+
+	return &resp
+}
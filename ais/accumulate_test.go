@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+import (
+	"reflect"
+	"testing"
+)
+
+// appendExt is a test extension value that accumulates by appending.
+type appendExt []string
+
+func (e appendExt) MergeExtension(delta any) any {
+	d, _ := delta.(appendExt)
+
+	return append(append(appendExt{}, e...), d...)
+}
+
+func TestAccumulator_MultipleChoicesAndToolCalls(t *testing.T) {
+	stop, tools := "stop", "tool_calls"
+
+	chunks := []*StreamChunk{
+		{ID: "c1", Object: "chat.completion.chunk", Created: 7, Model: "m", Choices: []StreamChunkChoice{
+			{Index: 1, Delta: Message{Role: RoleAssistant, Content: NewTextContent("B")}},
+			{Index: 0, Delta: Message{Role: RoleAssistant, Thinking: "th"}},
+		}},
+		{ID: "c1", Choices: []StreamChunkChoice{
+			{Index: 0, Delta: Message{Thinking: "ink", ToolCalls: []ToolCall{{Index: 0, ID: "t1", Type: "function", Function: FunctionCall{Name: "f"}}}}},
+			{Index: 1, Delta: Message{Content: NewTextContent("ye")}, FinishReason: &stop},
+		}},
+		{ID: "c1", Choices: []StreamChunkChoice{
+			{Index: 0, Delta: Message{ToolCalls: []ToolCall{{Index: 0, Function: FunctionCall{Arguments: `{"a":`}}}}},
+		}},
+		{ID: "c1", Choices: []StreamChunkChoice{
+			{Index: 0, Delta: Message{ToolCalls: []ToolCall{{Index: 0, Function: FunctionCall{Arguments: `1}`}}}}, FinishReason: &tools},
+		}},
+		{ID: "c1", Usage: &Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}},
+	}
+
+	var acc Accumulator
+	for _, c := range chunks {
+		acc.Add(c)
+	}
+
+	acc.Add(nil)
+
+	want := &ChatResponse{
+		ID: "c1", Object: "chat.completion", Created: 7, Model: "m",
+		Choices: []Choice{
+			{Index: 0, Message: Message{Role: RoleAssistant, Thinking: "think", ToolCalls: []ToolCall{
+				{Index: 0, ID: "t1", Type: "function", Function: FunctionCall{Name: "f", Arguments: `{"a":1}`}},
+			}}, FinishReason: FinishReasonToolCalls},
+			{Index: 1, Message: Message{Role: RoleAssistant, Content: NewTextContent("Bye")}, FinishReason: FinishReasonStop},
+		},
+		Usage: Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+	}
+
+	if got := acc.Response(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Response() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestAccumulator_ExtensionsAndDefaultRole(t *testing.T) {
+	chunks := []*StreamChunk{
+		{Extensions: Extensions{"p": "container"}},
+		{Choices: []StreamChunkChoice{{Delta: Message{Content: NewTextContent("hi"), Extensions: Extensions{"p": appendExt{"a"}}}}}},
+		{Choices: []StreamChunkChoice{{Delta: Message{Extensions: Extensions{"p": appendExt{"b"}}}, Extensions: Extensions{"p": "stop-details"}}}},
+	}
+
+	var acc Accumulator
+	for _, c := range chunks {
+		acc.Add(c)
+	}
+
+	resp := acc.Response()
+
+	if resp.Extensions.Value("p") != "container" {
+		t.Errorf("response extension = %v", resp.Extensions.Value("p"))
+	}
+
+	choice := resp.Choices[0]
+	if choice.Message.Role != RoleAssistant {
+		t.Errorf("role = %q, want assistant by default", choice.Message.Role)
+	}
+
+	if got := choice.Message.Extensions.Value("p"); !reflect.DeepEqual(got, appendExt{"a", "b"}) {
+		t.Errorf("message extension = %v, want merged [a b]", got)
+	}
+
+	if choice.Extensions.Value("p") != "stop-details" {
+		t.Errorf("choice extension = %v", choice.Extensions.Value("p"))
+	}
+}
+
+// blockExt is a test BlockDelta marker.
+type blockExt int
+
+func (b blockExt) BlockIndex() int { return int(b) }
+
+func TestAccumulator_BlockDelta(t *testing.T) {
+	delta := func(block int, text, thinking string) *StreamChunk {
+		return &StreamChunk{Choices: []StreamChunkChoice{{
+			Delta:      Message{Content: NewTextContent(text), Thinking: thinking},
+			Extensions: Extensions{"p": blockExt(block)},
+		}}}
+	}
+
+	var acc Accumulator
+	for _, c := range []*StreamChunk{
+		delta(0, "", "Let me "), delta(0, "", "think."),
+		delta(1, "Checking", ""), delta(1, " now.", ""),
+		delta(3, "", "Again."),
+		delta(4, "Done.", ""),
+	} {
+		acc.Add(c)
+	}
+
+	choice := acc.Response().Choices[0]
+	if choice.Message.Content.Text() != "Checking now.\nDone." || choice.Message.Thinking != "Let me think.\nAgain." {
+		t.Errorf("text = %q, thinking = %q", choice.Message.Content.Text(), choice.Message.Thinking)
+	}
+
+	if choice.Extensions != nil {
+		t.Errorf("choice extensions = %v, want the markers dropped", choice.Extensions)
+	}
+}
+
+func TestAccumulator_Empty(t *testing.T) {
+	var acc Accumulator
+
+	resp := acc.Response()
+	if resp.Object != "chat.completion" || resp.Choices != nil {
+		t.Errorf("empty Response() = %+v", resp)
+	}
+}
@@ -71,8 +71,6 @@ func TestCanonicalSchemaExcludesSingleProtocolSurface(t *testing.T) {
 	}{
 		{reflect.TypeFor[Choice](), "LogProbs"},
 		{reflect.TypeFor[Message](), "Audio"},
-		{reflect.TypeFor[ContentPart](), "InputAudio"},
-		{reflect.TypeFor[ContentPart](), "File"},
 	}
 	for _, check := range checks {
 		if _, ok := check.typ.FieldByName(check.field); ok {
@@ -86,8 +84,7 @@ func TestCanonicalSchemaExcludesSingleProtocolSurface(t *testing.T) {
 	}
 	forbiddenNames := map[string]bool{
 		"StreamOptions": true, "AudioConfig": true, "LogProbs": true,
-		"TokenLogprob": true, "TopLogprob": true, "InputAudio": true,
-		"FilePart": true, "MessageAudio": true, "VerbosityLow": true,
+		"TokenLogprob": true, "TopLogprob": true, "FilePart": true, "MessageAudio": true, "VerbosityLow": true,
 		"VerbosityMedium": true, "VerbosityHigh": true,
 	}
 	for _, decl := range file.Decls {
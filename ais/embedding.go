@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+// EncodingFormat constants select how the provider transfers embedding
+// vectors on the wire. The canonical response always carries decoded float
+// vectors; EncodingFormatBase64 only trades JSON size for a decode step.
+const (
+	EncodingFormatFloat  = "float"
+	EncodingFormatBase64 = "base64"
+)
+
+// EmbeddingRequest is the canonical embeddings request. Input is always a
+// batch: a single text is a one-element slice, and the response carries one
+// Embedding per input, matched by Index.
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+	// Dimensions asks models that support it to truncate their output
+	// vectors; zero keeps the model's native size.
+	Dimensions int `json:"dimensions,omitempty"`
+	// EncodingFormat is EncodingFormatFloat or EncodingFormatBase64; empty
+	// leaves the choice to the provider.
+	EncodingFormat string `json:"encoding_format,omitempty"`
+}
+
+// Clone returns a copy of the request whose Input slice does not share
+// backing storage with the original.
+func (r *EmbeddingRequest) Clone() EmbeddingRequest {
+	c := *r
+
+	if len(r.Input) > 0 {
+		c.Input = make([]string, len(r.Input))
+		copy(c.Input, r.Input)
+	}
+
+	return c
+}
+
+// EmbeddingResponse is the canonical embeddings response. Usage reports the
+// input tokens in PromptTokens and TotalTokens; embeddings produce no
+// completion tokens.
+type EmbeddingResponse struct {
+	Model string      `json:"model"`
+	Data  []Embedding `json:"data"`
+	Usage Usage       `json:"usage"`
+
+	// Meta carries the HTTP metadata of the response, as on ChatResponse.
+	Meta *ResponseMeta `json:"-"`
+}
+
+// Embedding is the vector for one input of an EmbeddingRequest.
+type Embedding struct {
+	// Index is the position of the corresponding input in
+	// EmbeddingRequest.Input.
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
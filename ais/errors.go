@@ -20,6 +20,7 @@ package ais
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
@@ -30,6 +31,14 @@ var (
 	ErrStreamClosed   = errors.New("aimodel: stream is closed")
 	ErrEmptyResponse  = errors.New("aimodel: empty response from API")
 	ErrNoActiveModels = errors.New("aimodel: no active models available")
+	// ErrEmbeddingUnsupported reports that the selected provider does not
+	// implement EmbeddingProvider.
+	ErrEmbeddingUnsupported = errors.New("aimodel: provider does not support embeddings")
+	// ErrModelListUnsupported reports that the selected provider does not
+	// implement ModelLister. It wraps errors.ErrUnsupported.
+	ErrModelListUnsupported = fmt.Errorf("aimodel: provider does not list models: %w", errors.ErrUnsupported)
+	// ErrNilRequest reports a call made with a nil request.
+	ErrNilRequest = errors.New("aimodel: request is nil")
 )
 
 // APIError represents an error returned by an AI API.
@@ -38,7 +47,14 @@ type APIError struct {
 	Code       string
 	Message    string
 	Type       string
-	Err        error
+	// Header holds the HTTP response headers (Retry-After, rate-limit
+	// counters, request IDs). It is nil for errors that did not come from an
+	// HTTP response, such as an SSE error event.
+	Header http.Header
+	// Meta is the parsed response metadata (request ID, rate-limit state);
+	// nil under the same conditions as Header.
+	Meta *ResponseMeta
+	Err  error
 }
 
 func (e *APIError) Error() string {
@@ -49,6 +65,50 @@ func (e *APIError) Unwrap() error {
 	return e.Err
 }
 
+// contextLengthMarkers are the lower-cased fragments providers use to report a
+// prompt that does not fit the model's context window.
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"context length",
+	"context window",
+	"prompt is too long",
+	"maximum number of tokens",
+}
+
+// ContextLengthExceeded reports whether e signals an exceeded context window:
+// OpenAI's context_length_exceeded code, Anthropic's "prompt is too long" and
+// Gemini's token-limit message. Providers report it under various statuses,
+// so it is matched on the code and message only.
+func (e *APIError) ContextLengthExceeded() bool {
+	for _, s := range []string{e.Code, e.Message} {
+		s = strings.ToLower(s)
+		for _, m := range contextLengthMarkers {
+			if strings.Contains(s, m) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ContentPartError reports that a provider cannot represent a content part
+// of the canonical request. Providers return it from request translation —
+// before any network I/O — so an unsupported part fails the call instead of
+// being silently dropped.
+type ContentPartError struct {
+	// Provider is the registered name of the provider that rejected the part.
+	Provider string
+	// Type is the content part type, e.g. "input_audio".
+	Type string
+	// Reason explains why the part cannot be represented.
+	Reason string
+}
+
+func (e *ContentPartError) Error() string {
+	return fmt.Sprintf("aimodel: %s cannot represent %s content part: %s", e.Provider, e.Type, e.Reason)
+}
+
 // ModelError associates an error with a specific model name.
 type ModelError struct {
 	Model string
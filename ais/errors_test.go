@@ -138,3 +138,24 @@ func TestAPIError_Unwrap(t *testing.T) {
 		t.Fatal("APIError.Unwrap should allow errors.Is to match inner error")
 	}
 }
+
+func TestAPIError_ContextLengthExceeded(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want bool
+	}{
+		{"openai code", &APIError{StatusCode: 400, Code: "context_length_exceeded"}, true},
+		{"anthropic message", &APIError{StatusCode: 400, Message: "prompt is too long: 210000 tokens > 200000 maximum"}, true},
+		{"gemini message", &APIError{StatusCode: 400, Message: "The input token count exceeds the maximum number of tokens allowed"}, true},
+		{"other bad request", &APIError{StatusCode: 400, Code: "invalid_request_error", Message: "tools.0: invalid schema"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.ContextLengthExceeded(); got != tt.want {
+				t.Errorf("ContextLengthExceeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+import "encoding/json"
+
+// EstimateTokens roughly estimates a request's size without a tokenizer:
+// four bytes of text per prompt token over the messages (the text of every
+// content part, thinking and tool calls) and the tool definitions with their
+// parameter schemas, and the output cap as completion tokens. completion is
+// zero when the request sets no output cap.
+func EstimateTokens(req *ChatRequest) (prompt, completion int) {
+	n := 0
+
+	for _, m := range req.Messages {
+		n += len(m.Content.Text()) + len(m.Thinking)
+
+		for _, tc := range m.ToolCalls {
+			n += len(tc.Function.Name) + len(tc.Function.Arguments)
+		}
+	}
+
+	for _, t := range req.Tools {
+		n += len(t.Function.Name) + len(t.Function.Description)
+
+		if t.Function.Parameters != nil {
+			if b, err := json.Marshal(t.Function.Parameters); err == nil {
+				n += len(b)
+			}
+		}
+	}
+
+	switch {
+	case req.MaxCompletionTokens != nil:
+		completion = *req.MaxCompletionTokens
+	case req.MaxTokens != nil:
+		completion = *req.MaxTokens
+	}
+
+	return n / 4, completion
+}
@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	req := &ChatRequest{
+		Messages: []Message{
+			{Role: RoleUser, Content: NewPartsContent(
+				ContentPart{Type: "text", Text: "12345678"},
+				ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: "https://example.com/a.png"}},
+				ContentPart{Type: "text", Text: "1234"},
+			)},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{Function: FunctionCall{Name: "f", Arguments: "{}"}}}},
+		},
+		Tools: []Tool{{Type: "function", Function: FunctionDefinition{
+			Name:       "f",
+			Parameters: map[string]any{"type": "object"},
+		}}},
+		MaxTokens: new(100),
+	}
+
+	// 12 text bytes, 3 of the tool call, 1 + 17 of the tool definition.
+	prompt, completion := EstimateTokens(req)
+	if prompt != 33/4 || completion != 100 {
+		t.Errorf("EstimateTokens = %d, %d; want %d, 100", prompt, completion, 33/4)
+	}
+
+	req.MaxCompletionTokens = new(50)
+	if _, completion := EstimateTokens(req); completion != 50 {
+		t.Errorf("completion = %d, want MaxCompletionTokens", completion)
+	}
+}
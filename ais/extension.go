@@ -97,6 +97,29 @@ type ExtensionMerger interface {
 	MergeExtension(delta any) any
 }
 
+// The billing interfaces below are implemented by Usage extension values that
+// report what a provider bills beyond the canonical Usage counts, so cost
+// accounting can read them through the namespace-neutral Extensions map
+// without importing the provider.
+
+// CacheWriteUsage reports prompt tokens written to a prompt cache. Like
+// CacheReadTokens, they are a subset of PromptTokens.
+type CacheWriteUsage interface {
+	// CacheWrites returns the tokens written with a 5-minute and with a
+	// 1-hour time to live.
+	CacheWrites() (ttl5m, ttl1h int)
+}
+
+// ExtraPromptUsage reports billed prompt tokens that PromptTokens excludes.
+type ExtraPromptUsage interface {
+	ExtraPromptTokens() int
+}
+
+// WebSearchUsage reports the server-side web searches billed with a call.
+type WebSearchUsage interface {
+	WebSearches() int
+}
+
 // ExtensionTypeError reports that an Extensions namespace holds a value of a
 // type the owning provider does not recognize. Providers return it from
 // request translation — before any network I/O — so a mis-typed extension
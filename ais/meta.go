@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseMeta is the canonical view of the HTTP metadata of a provider
+// response: the request ID vendors ask for in support tickets, the status, the
+// rate-limit state and the server processing time. The raw headers are kept
+// for anything not modelled here.
+type ResponseMeta struct {
+	// RequestID is the vendor request identifier (x-request-id or request-id).
+	RequestID string
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// RateLimit is the parsed rate-limit state; nil when the response carried
+	// no rate-limit headers.
+	RateLimit *RateLimit
+	// ProcessingTime is the server-reported processing time
+	// (openai-processing-ms); zero when not reported.
+	ProcessingTime time.Duration
+	// Header holds the raw response headers.
+	Header http.Header
+}
+
+// RateLimit is the rate-limit state reported with a response, one window per
+// limited quantity. A window is nil when the server did not report it.
+type RateLimit struct {
+	Requests *RateLimitWindow
+	Tokens   *RateLimitWindow
+	// InputTokens and OutputTokens are reported by Anthropic alongside the
+	// combined Tokens window.
+	InputTokens  *RateLimitWindow
+	OutputTokens *RateLimitWindow
+}
+
+// RateLimitWindow is one rate-limited quantity. Fields the server omitted are
+// zero.
+type RateLimitWindow struct {
+	Limit     int64
+	Remaining int64
+	// Reset is when the window replenishes, resolved to an absolute time from
+	// either a relative duration (OpenAI: "6m0s", "20ms", or seconds) or a
+	// timestamp (Anthropic: RFC 3339).
+	Reset time.Time
+}
+
+// NewResponseMeta parses the response metadata from an HTTP status and its
+// headers. It recognizes the OpenAI family (x-request-id,
+// x-ratelimit-{limit,remaining,reset}-{requests,tokens}, openai-processing-ms),
+// which OpenAI-compatible backends also use, and the Anthropic family
+// (request-id, anthropic-ratelimit-{requests,tokens,input-tokens,output-tokens}-
+// {limit,remaining,reset}). now resolves relative reset values.
+func NewResponseMeta(statusCode int, header http.Header, now time.Time) *ResponseMeta {
+	m := &ResponseMeta{
+		RequestID:  firstHeader(header, "x-request-id", "request-id"),
+		StatusCode: statusCode,
+		Header:     header,
+	}
+
+	if v := header.Get("openai-processing-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			m.ProcessingTime = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+
+	rl := RateLimit{
+		Requests:     rateLimitWindow(header, now, "requests"),
+		Tokens:       rateLimitWindow(header, now, "tokens"),
+		InputTokens:  rateLimitWindow(header, now, "input-tokens"),
+		OutputTokens: rateLimitWindow(header, now, "output-tokens"),
+	}
+	if rl != (RateLimit{}) {
+		m.RateLimit = &rl
+	}
+
+	return m
+}
+
+// rateLimitWindow reads one quantity from whichever header family is present,
+// returning nil when neither reports it.
+func rateLimitWindow(h http.Header, now time.Time, quantity string) *RateLimitWindow {
+	limit := firstHeader(h, "x-ratelimit-limit-"+quantity, "anthropic-ratelimit-"+quantity+"-limit")
+	remaining := firstHeader(h, "x-ratelimit-remaining-"+quantity, "anthropic-ratelimit-"+quantity+"-remaining")
+	reset := firstHeader(h, "x-ratelimit-reset-"+quantity, "anthropic-ratelimit-"+quantity+"-reset")
+
+	if limit == "" && remaining == "" && reset == "" {
+		return nil
+	}
+
+	w := &RateLimitWindow{Reset: parseReset(reset, now)}
+	w.Limit, _ = strconv.ParseInt(limit, 10, 64)
+	w.Remaining, _ = strconv.ParseInt(remaining, 10, 64)
+
+	return w
+}
+
+// parseReset resolves a reset header to an absolute time: an RFC 3339
+// timestamp, a Go-style duration ("1m30s", "20ms"), or plain seconds. An
+// unparsable value yields the zero time.
+func parseReset(v string, now time.Time) time.Time {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d)
+	}
+
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return now.Add(time.Duration(secs * float64(time.Second)))
+	}
+
+	return time.Time{}
+}
+
+// firstHeader returns the first non-empty value among keys.
+func firstHeader(h http.Header, keys ...string) string {
+	for _, k := range keys {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
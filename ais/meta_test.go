@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ais
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewResponseMeta_OpenAIHeaders(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("x-request-id", "req_123")
+	h.Set("openai-processing-ms", "250")
+	h.Set("x-ratelimit-limit-requests", "500")
+	h.Set("x-ratelimit-remaining-requests", "499")
+	h.Set("x-ratelimit-reset-requests", "120ms")
+	h.Set("x-ratelimit-limit-tokens", "30000")
+	h.Set("x-ratelimit-remaining-tokens", "29000")
+	h.Set("x-ratelimit-reset-tokens", "6m0s")
+
+	m := NewResponseMeta(http.StatusOK, h, now)
+
+	if m.RequestID != "req_123" || m.StatusCode != http.StatusOK || m.ProcessingTime != 250*time.Millisecond {
+		t.Fatalf("meta = %+v", m)
+	}
+
+	if m.RateLimit == nil || m.RateLimit.InputTokens != nil || m.RateLimit.OutputTokens != nil {
+		t.Fatalf("rate limit = %+v, want requests and tokens windows only", m.RateLimit)
+	}
+
+	if r := m.RateLimit.Requests; r.Limit != 500 || r.Remaining != 499 || !r.Reset.Equal(now.Add(120*time.Millisecond)) {
+		t.Errorf("requests = %+v", r)
+	}
+
+	if tk := m.RateLimit.Tokens; tk.Limit != 30000 || tk.Remaining != 29000 || !tk.Reset.Equal(now.Add(6*time.Minute)) {
+		t.Errorf("tokens = %+v", tk)
+	}
+}
+
+func TestNewResponseMeta_AnthropicHeaders(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	reset := "2026-10-16T12:01:00Z"
+	h := http.Header{}
+	h.Set("request-id", "req_abc")
+	h.Set("anthropic-ratelimit-requests-limit", "50")
+	h.Set("anthropic-ratelimit-requests-remaining", "0")
+	h.Set("anthropic-ratelimit-requests-reset", reset)
+	h.Set("anthropic-ratelimit-input-tokens-remaining", "39000")
+	h.Set("anthropic-ratelimit-output-tokens-remaining", "8000")
+
+	m := NewResponseMeta(http.StatusTooManyRequests, h, now)
+
+	if m.RequestID != "req_abc" || m.RateLimit == nil || m.RateLimit.Tokens != nil {
+		t.Fatalf("meta = %+v, rate limit = %+v", m, m.RateLimit)
+	}
+
+	want := time.Date(2026, 10, 16, 12, 1, 0, 0, time.UTC)
+	if r := m.RateLimit.Requests; r.Limit != 50 || r.Remaining != 0 || !r.Reset.Equal(want) {
+		t.Errorf("requests = %+v", r)
+	}
+
+	if m.RateLimit.InputTokens.Remaining != 39000 || m.RateLimit.OutputTokens.Remaining != 8000 {
+		t.Errorf("input/output = %+v / %+v", m.RateLimit.InputTokens, m.RateLimit.OutputTokens)
+	}
+}
+
+func TestNewResponseMeta_NoRateLimit(t *testing.T) {
+	h := http.Header{}
+	h.Set("x-ratelimit-reset-requests", "60")
+
+	if m := NewResponseMeta(http.StatusOK, http.Header{}, time.Now()); m.RateLimit != nil || m.RequestID != "" {
+		t.Errorf("meta = %+v, want no rate limit and no request ID", m)
+	}
+
+	now := time.Unix(0, 0)
+	if m := NewResponseMeta(http.StatusOK, h, now); !m.RateLimit.Requests.Reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("plain-seconds reset = %v, want now+60s", m.RateLimit.Requests.Reset)
+	}
+}
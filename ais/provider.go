@@ -62,6 +62,23 @@ type ChatProvider interface {
 	NewStreamDecoder(body io.Reader) StreamDecoder
 }
 
+// EmbeddingProvider is the optional provider-side contract for the
+// embeddings capability. A registered provider implements it alongside
+// ChatProvider when its vendor exposes an embeddings endpoint; the root client
+// discovers it with a type assertion and otherwise fails with
+// ErrEmbeddingUnsupported. Non-2xx responses go through the provider's
+// ParseErrorResponse, exactly as for chat.
+type EmbeddingProvider interface {
+	// NewEmbeddingRequest builds the complete HTTP request for the given
+	// canonical request, a per-call working copy.
+	NewEmbeddingRequest(ctx context.Context, req *EmbeddingRequest) (*http.Request, error)
+
+	// ParseEmbeddingResponse normalizes the body of a successful response
+	// into a canonical EmbeddingResponse, decoding every vector to floats
+	// whatever the wire encoding. The caller closes body.
+	ParseEmbeddingResponse(body io.Reader) (*EmbeddingResponse, error)
+}
+
 // StreamDecoder decodes one canonical chunk per call from a streaming
 // response body. It returns io.EOF when the stream is complete. The root
 // Stream owns the close state and the underlying reader; a decoder only
@@ -84,7 +101,9 @@ type Config struct {
 	BaseURL string
 
 	// Options is the provider-specific configuration value (defined by the
-	// provider package), or nil. A factory must reject values of a type it
+	// provider package), or nil. Configuration loaders pass the value's JSON
+	// encoding as a json.RawMessage instead, which a factory with options
+	// decodes into its own type. A factory must reject values of a type it
 	// does not recognize.
 	Options any
 }
@@ -93,3 +112,17 @@ type Config struct {
 // configuration. It validates required fields and vendor options up front so
 // every failure surfaces at client construction, not at call time.
 type Factory func(cfg Config) (ChatProvider, error)
+
+// ModelLister is the optional provider-side contract for listing the models
+// a backend serves. The root client discovers it with a type assertion, like
+// EmbeddingProvider, for Client.ListModels and Client.CheckHealth — a model
+// list costs no tokens, so it is the cheapest authenticated round trip.
+type ModelLister interface {
+	// NewListModelsRequest builds the complete HTTP request for the model
+	// list.
+	NewListModelsRequest(ctx context.Context) (*http.Request, error)
+
+	// ParseListModelsResponse decodes the body of a successful response into
+	// model IDs, as accepted by ChatRequest.Model. The caller closes body.
+	ParseListModelsResponse(body io.Reader) ([]string, error)
+}
@@ -19,6 +19,7 @@ package ais
 
 import (
 	"encoding/json"
+	"slices"
 	"strings"
 )
 
@@ -155,6 +156,11 @@ type ChatResponse struct {
 	// cross-provider consensus (e.g. anthropic.ResponseExtension); read it
 	// through the provider package's typed accessors.
 	Extensions Extensions `json:"-"`
+
+	// Meta carries the HTTP metadata of the response (request ID, rate-limit
+	// state, headers). The client sets it; it is nil on responses that did not
+	// come from an HTTP call.
+	Meta *ResponseMeta `json:"-"`
 }
 
 // Choice represents a single completion choice.
@@ -176,11 +182,15 @@ type Content struct {
 
 // ContentPart represents a single part in a multimodal content array.
 // Exactly one of the payload fields is set, selected by Type:
-// "text" → Text, "image_url" → ImageURL.
+// "text" → Text, "image_url" → ImageURL, "input_audio" → InputAudio,
+// "file" → File. A provider that cannot represent a part fails request
+// translation with a *ContentPartError instead of dropping it.
 type ContentPart struct {
-	Type     string    `json:"type"`
-	Text     string    `json:"text,omitempty"`
-	ImageURL *ImageURL `json:"image_url,omitempty"`
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+	File       *File       `json:"file,omitempty"`
 }
 
 // ImageURL represents an image URL in a content part.
@@ -189,6 +199,28 @@ type ImageURL struct {
 	Detail string `json:"detail,omitempty"`
 }
 
+// InputAudio represents base64-encoded audio input in a content part.
+type InputAudio struct {
+	// Data is the base64-encoded audio, without a data: URI prefix.
+	Data string `json:"data"`
+	// Format is the audio encoding, e.g. "wav" or "mp3".
+	Format string `json:"format"`
+}
+
+// File represents a document input (e.g. a PDF or plain text) in a content
+// part. Exactly one source is set: FileData, FileID, or FileURL.
+type File struct {
+	// FileData is the file content as a data URI
+	// ("data:application/pdf;base64,…").
+	FileData string `json:"file_data,omitempty"`
+	// FileID references a file previously uploaded to the provider.
+	FileID string `json:"file_id,omitempty"`
+	// FileURL is a publicly reachable URL of the file.
+	FileURL string `json:"file_url,omitempty"`
+	// Filename is the display name of the file; optional.
+	Filename string `json:"filename,omitempty"`
+}
+
 // NewTextContent creates a Content from a plain string.
 func NewTextContent(text string) Content {
 	return Content{text: text}
@@ -379,6 +411,43 @@ func (r *ChatRequest) Clone() ChatRequest {
 	return c
 }
 
+// Clone returns a deep copy of the ChatResponse, duplicating the choices,
+// their content parts and tool calls, and the extension maps at every node,
+// so that a copy handed to another goroutine does not race with the caller.
+// Extension values and Meta are shared: both are read-only once set.
+func (r *ChatResponse) Clone() ChatResponse {
+	c := *r
+
+	if len(r.Choices) > 0 {
+		c.Choices = make([]Choice, len(r.Choices))
+		copy(c.Choices, r.Choices)
+
+		for i := range c.Choices {
+			ch := &c.Choices[i]
+			ch.Extensions = ch.Extensions.Clone()
+			ch.Message.Extensions = ch.Message.Extensions.Clone()
+
+			if ch.Message.Content.parts != nil {
+				ch.Message.Content.parts = slices.Clone(ch.Message.Content.parts)
+			}
+
+			if ch.Message.ToolCalls != nil {
+				ch.Message.ToolCalls = slices.Clone(ch.Message.ToolCalls)
+			}
+		}
+	}
+
+	if r.Error != nil {
+		e := *r.Error
+		c.Error = &e
+	}
+
+	c.Usage.Extensions = r.Usage.Extensions.Clone()
+	c.Extensions = r.Extensions.Clone()
+
+	return c
+}
+
 // StreamChunk represents a single chunk in a streaming response.
 type StreamChunk struct {
 	ID      string              `json:"id"`
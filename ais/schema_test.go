@@ -723,6 +723,39 @@ func TestChatRequestClone(t *testing.T) {
 	}
 }
 
+func TestChatResponseClone(t *testing.T) {
+	orig := &ChatResponse{
+		Model: "gpt-4o",
+		Choices: []Choice{{
+			Message: Message{
+				Role:      RoleAssistant,
+				Content:   NewPartsContent(ContentPart{Type: "text", Text: "Hi"}),
+				ToolCalls: []ToolCall{{ID: "t1", Function: FunctionCall{Name: "f"}}},
+			},
+		}},
+		Error: &Error{Code: "c"},
+	}
+	orig.Extensions.Set("p", "v")
+	orig.Choices[0].Message.Extensions.Set("p", "v")
+
+	cloned := orig.Clone()
+
+	cloned.Choices[0].Message.Content.Parts()[0].Text = "Bye"
+	cloned.Choices[0].Message.ToolCalls[0].ID = "t2"
+	cloned.Choices[0].Message.Extensions.Set("p", "changed")
+	cloned.Extensions.Set("q", "added")
+	cloned.Error.Code = "changed"
+
+	msg := orig.Choices[0].Message
+	if msg.Content.Text() != "Hi" || msg.ToolCalls[0].ID != "t1" || msg.Extensions.Value("p") != "v" {
+		t.Errorf("original message changed: %+v", msg)
+	}
+
+	if orig.Extensions.Value("q") != nil || orig.Error.Code != "c" {
+		t.Errorf("original response changed: %+v", orig)
+	}
+}
+
 func TestContentPartOmitsUnsetPayloads(t *testing.T) {
 	// A plain text part must not serialize image_url.
 	data, err := json.Marshal(ContentPart{Type: "text", Text: "hi"})
@@ -733,3 +766,29 @@ func TestContentPartOmitsUnsetPayloads(t *testing.T) {
 		t.Errorf("got %s, want %s", got, `{"type":"text","text":"hi"}`)
 	}
 }
+
+func TestContentPartAudioAndFileJSON(t *testing.T) {
+	parts := []ContentPart{
+		{Type: "input_audio", InputAudio: &InputAudio{Data: "UklGRg==", Format: "wav"}},
+		{Type: "file", File: &File{FileID: "file-abc", Filename: "a.pdf"}},
+	}
+	want := `[{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}},` +
+		`{"type":"file","file":{"file_id":"file-abc","filename":"a.pdf"}}]`
+
+	data, err := json.Marshal(NewPartsContent(parts...))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var back Content
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	got := back.Parts()
+	if len(got) != 2 || got[0].InputAudio == nil || got[0].InputAudio.Format != "wav" || got[1].File == nil || got[1].File.FileID != "file-abc" {
+		t.Errorf("round trip parts = %+v", got)
+	}
+}
@@ -101,8 +101,8 @@ func TestCanonicalSchemaHasNoVendorIdentifiers(t *testing.T) {
 
 // TestCanonicalSchemaJSONDashFieldsAreExtensionsOnly enforces that the only
 // struct fields excluded from canonical JSON are the unified Extensions
-// channel. A vendor-specific json:"-" switch smuggled into a canonical type
-// fails this test.
+// channel and the transport-level *ResponseMeta. A vendor-specific json:"-"
+// switch smuggled into a canonical type fails this test.
 func TestCanonicalSchemaJSONDashFieldsAreExtensionsOnly(t *testing.T) {
 	for name, file := range parseAisPackage(t) {
 		ast.Inspect(file, func(n ast.Node) bool {
@@ -116,6 +116,12 @@ func TestCanonicalSchemaJSONDashFieldsAreExtensionsOnly(t *testing.T) {
 					continue
 				}
 
+				if star, ok := f.Type.(*ast.StarExpr); ok {
+					if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "ResponseMeta" {
+						continue
+					}
+				}
+
 				ident, ok := f.Type.(*ast.Ident)
 				if !ok || ident.Name != "Extensions" {
 					t.Errorf("%s: field %v is tagged json:\"-\" but is not the unified Extensions channel", name, f.Names)
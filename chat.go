@@ -19,9 +19,11 @@ package aimodel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/vogo/aimodel/ais"
 )
@@ -52,54 +54,103 @@ func (c *Client) applyDefaultModel(r *ais.ChatRequest) {
 // ChatCompletion sends a non-streaming chat completion request, delegating the
 // protocol-specific work to the client's resolved provider.
 func (c *Client) ChatCompletion(ctx context.Context, req *ais.ChatRequest) (*ais.ChatResponse, error) {
-	r := req.Clone()
-	r.Stream = false
-
-	c.applyDefaultModel(&r)
-
-	resp, err := c.send(ctx, &r)
+	call, err := c.newCall(ctx, req, false)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if !isSuccess(resp.StatusCode) {
-		return nil, c.parseError(resp)
+	if err := c.unary(ctx, call); err != nil {
+		return nil, err
 	}
 
-	return c.provider.ParseChatResponse(resp.Body)
+	return call.Response, nil
 }
 
 // ChatCompletionStream sends a streaming chat completion request and returns a
 // Stream backed by the provider's SSE decoder.
 func (c *Client) ChatCompletionStream(ctx context.Context, req *ais.ChatRequest) (*Stream, error) {
+	call, err := c.newCall(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.stream(ctx, call); err != nil {
+		return nil, err
+	}
+
+	return call.Stream, nil
+}
+
+// newCall clones the request, applies the stream flag and default model, and
+// builds the provider request the middleware chain will see. A nil request or
+// a build failure is returned before the chain runs.
+func (c *Client) newCall(ctx context.Context, req *ais.ChatRequest, stream bool) (*Call, error) {
+	if req == nil {
+		return nil, ais.ErrNilRequest
+	}
+
 	r := req.Clone()
-	r.Stream = true
+	r.Stream = stream
 
 	c.applyDefaultModel(&r)
 
-	resp, err := c.send(ctx, &r)
+	httpReq, err := c.provider.NewChatRequest(ctx, &r)
 	if err != nil {
 		return nil, err
 	}
 
+	return &Call{Request: &r, HTTPRequest: httpReq}, nil
+}
+
+// doUnary is the terminal unary handler: it issues the HTTP call and parses
+// the response.
+func (c *Client) doUnary(_ context.Context, call *Call) error {
+	resp, err := c.do(call.HTTPRequest)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	call.HTTPResponse = resp
+
 	if !isSuccess(resp.StatusCode) {
-		defer func() { _ = resp.Body.Close() }()
-		return nil, c.parseError(resp)
+		return c.parseError(resp)
 	}
 
-	return newStream(resp.Body, c.provider.NewStreamDecoder(resp.Body)), nil
+	call.Response, err = c.provider.ParseChatResponse(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	call.Response.Meta = responseMeta(resp)
+
+	return nil
 }
 
-// send builds the provider request and issues the single HTTP call. On any
-// build or transport failure the caller receives an error and no response body
-// to close.
-func (c *Client) send(ctx context.Context, r *ais.ChatRequest) (*http.Response, error) {
-	httpReq, err := c.provider.NewChatRequest(ctx, r)
+// doStream is the terminal streaming handler: it issues the HTTP call and
+// wraps a successful body in a Stream, which then owns it.
+func (c *Client) doStream(_ context.Context, call *Call) error {
+	resp, err := c.do(call.HTTPRequest)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	call.HTTPResponse = resp
+
+	if !isSuccess(resp.StatusCode) {
+		defer func() { _ = resp.Body.Close() }()
+		return c.parseError(resp)
 	}
 
+	call.Stream = newStream(resp.Body, c.provider.NewStreamDecoder(resp.Body))
+	call.Stream.SetMeta(responseMeta(resp))
+
+	return nil
+}
+
+// do issues the single HTTP call for a provider-built request, shared by every
+// capability.
+func (c *Client) do(httpReq *http.Request) (*http.Response, error) {
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("aimodel: send request: %w", err)
@@ -109,18 +160,34 @@ func (c *Client) send(ctx context.Context, r *ais.ChatRequest) (*http.Response,
 }
 
 // parseError reads the error body under the shared size limit and hands it to
-// the provider for conversion into the canonical error model.
+// the provider for conversion into the canonical error model. The response
+// headers and parsed metadata are attached to the resulting APIError.
 func (c *Client) parseError(resp *http.Response) error {
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
 	if err != nil {
 		return &ais.APIError{
 			StatusCode: resp.StatusCode,
 			Message:    "failed to read error response",
+			Header:     resp.Header,
+			Meta:       responseMeta(resp),
 			Err:        err,
 		}
 	}
 
-	return c.provider.ParseErrorResponse(resp.StatusCode, body)
+	err = c.provider.ParseErrorResponse(resp.StatusCode, body)
+
+	var apiErr *ais.APIError
+	if errors.As(err, &apiErr) && apiErr.Header == nil {
+		apiErr.Header = resp.Header
+		apiErr.Meta = responseMeta(resp)
+	}
+
+	return err
+}
+
+// responseMeta parses the canonical metadata of an HTTP response.
+func responseMeta(resp *http.Response) *ais.ResponseMeta {
+	return ais.NewResponseMeta(resp.StatusCode, resp.Header, time.Now())
 }
 
 // isSuccess reports whether an HTTP status is 2xx.
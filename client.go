@@ -40,9 +40,15 @@ const defaultTimeout = 60 * time.Second
 // OpenAI-compatible one; select another with WithProvider (e.g.
 // WithProvider(anthropic.Name)).
 type Client struct {
-	model      string
-	httpClient *http.Client
-	provider   ais.ChatProvider
+	model        string
+	httpClient   *http.Client
+	provider     ais.ChatProvider
+	providerName string
+
+	// unary and stream are the terminal chat handlers wrapped in the
+	// configured middleware.
+	unary  Handler
+	stream Handler
 }
 
 // clientConfig holds the construction-time configuration mutated by Options.
@@ -57,6 +63,7 @@ type clientConfig struct {
 	providerOptions any
 	timeout         time.Duration
 	httpClient      *http.Client
+	middleware      []Middleware
 }
 
 // Option configures a Client.
@@ -183,9 +190,20 @@ func NewClient(opts ...Option) (*Client, error) {
 
 	httpClient.Timeout = cfg.timeout
 
-	return &Client{
-		model:      cfg.model,
-		httpClient: httpClient,
-		provider:   prov,
-	}, nil
+	c := &Client{
+		model:        cfg.model,
+		httpClient:   httpClient,
+		provider:     prov,
+		providerName: cfg.providerName,
+	}
+	c.unary = ApplyMiddleware(c.doUnary, cfg.middleware...)
+	c.stream = ApplyMiddleware(c.doStream, cfg.middleware...)
+
+	return c, nil
+}
+
+// Provider returns the registered name of the client's provider, openai.Name
+// when WithProvider was not used.
+func (c *Client) Provider() string {
+	return c.providerName
 }
@@ -19,6 +19,7 @@ package aimodel
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -259,10 +260,14 @@ func TestNewClientNoBaseURLAllowedForAnthropic(t *testing.T) {
 
 	// The Anthropic provider has a default base URL, so construction succeeds
 	// without one.
-	_, err := NewClient(WithAPIKey("sk-test"), WithProvider(anthropic.Name))
+	c, err := NewClient(WithAPIKey("sk-test"), WithProvider(anthropic.Name))
 	if err != nil {
 		t.Fatalf("NewClient: %v", err)
 	}
+
+	if c.Provider() != anthropic.Name {
+		t.Errorf("Provider() = %q, want %q", c.Provider(), anthropic.Name)
+	}
 }
 
 func TestNewClientNoBaseURLErrorForOpenAI(t *testing.T) {
@@ -283,3 +288,66 @@ func TestNewClientUnknownProvider(t *testing.T) {
 		t.Fatal("expected error for unknown provider")
 	}
 }
+
+// TestResponseMetaAttached verifies the response metadata reaches the unary
+// response, the stream and the API error.
+func TestResponseMetaAttached(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_1")
+		w.Header().Set("x-ratelimit-remaining-requests", "9")
+
+		var req struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch {
+		case req.Model == "bad":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"bad model"}}`))
+		case req.Stream:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(completionResponse))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(WithAPIKey("k"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	check := func(what string, m *ais.ResponseMeta, status int) {
+		t.Helper()
+
+		if m == nil || m.StatusCode != status || m.RequestID != "req_1" ||
+			m.RateLimit == nil || m.RateLimit.Requests.Remaining != 9 {
+			t.Errorf("%s meta = %+v", what, m)
+		}
+	}
+
+	resp, err := c.ChatCompletion(context.Background(), &ais.ChatRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	check("response", resp.Meta, http.StatusOK)
+
+	s, err := c.ChatCompletionStream(context.Background(), &ais.ChatRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	check("stream", s.Meta(), http.StatusOK)
+	_ = s.Close()
+
+	_, err = c.ChatCompletion(context.Background(), &ais.ChatRequest{Model: "bad"})
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *ais.APIError", err)
+	}
+	check("error", apiErr.Meta, http.StatusBadRequest)
+}
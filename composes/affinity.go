@@ -0,0 +1,170 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// ringReplicas is the number of points per unit of weight an entry gets on
+// the session ring; more points spread sessions more evenly.
+const ringReplicas = 64
+
+// SessionKeyFunc derives the session key of a request. Requests with the same
+// non-empty key are routed to the same entry while it is healthy; an empty
+// key leaves the request to the selector.
+type SessionKeyFunc func(ctx context.Context, req *ais.ChatRequest) string
+
+type sessionKeyCtx struct{}
+
+// WithSessionKey returns a context carrying a session key for
+// ContextSessionKey.
+func WithSessionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKeyCtx{}, key)
+}
+
+// ContextSessionKey returns the session key set with WithSessionKey, or "".
+func ContextSessionKey(ctx context.Context, _ *ais.ChatRequest) string {
+	key, _ := ctx.Value(sessionKeyCtx{}).(string)
+
+	return key
+}
+
+// WithSessionAffinity routes requests with a session key to a fixed entry, so
+// a conversation keeps hitting the backend that holds its prompt cache. Keys
+// are consistently hashed onto a ring of the entries, with points in
+// proportion to their weights: when the key's entry is unhealthy, incapable
+// or busy with a trial, the request goes to the next entry on the ring, and
+// adding or removing an entry moves only the sessions of that entry. A nil
+// key means ContextSessionKey. Requests without a key use the selector.
+func WithSessionAffinity(key SessionKeyFunc) ComposeOption {
+	return func(c *ComposeClient) {
+		if key == nil {
+			key = ContextSessionKey
+		}
+
+		c.sessionKey = key
+	}
+}
+
+// ringPoint is an entry's position on the session ring.
+type ringPoint struct {
+	hash uint64
+	idx  int
+}
+
+// buildRing places every entry on the session ring. Points derive from the
+// entry's name and its occurrence among equally named entries, so a session
+// keeps its entry across processes and configuration reorderings.
+func buildRing(entries []ModelEntry) []ringPoint {
+	var ring []ringPoint
+
+	seen := make(map[string]int, len(entries))
+
+	for i, e := range entries {
+		occurrence := seen[e.Name]
+		seen[e.Name]++
+
+		base := e.Name + "/" + strconv.Itoa(occurrence) + "/"
+
+		for v := range max(e.Weight, 1) * ringReplicas {
+			ring = append(ring, ringPoint{hash: ringHash(base + strconv.Itoa(v)), idx: i})
+		}
+	}
+
+	slices.SortFunc(ring, func(a, b ringPoint) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		default:
+			return a.idx - b.idx
+		}
+	})
+
+	return ring
+}
+
+// ringHash is FNV-1a finished with the splitmix64 mixer, whose avalanche
+// spreads the similar keys of one entry around the ring.
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// affinityOrder reorders candidates for the session key of the request: the
+// entries in ring order from the key's position. It returns candidates
+// unchanged when the request has no key.
+func (c *ComposeClient) affinityOrder(ctx context.Context, req *ais.ChatRequest, candidates []int) []int {
+	if c.sessionKey == nil || len(candidates) < 2 {
+		return candidates
+	}
+
+	key := c.sessionKey(ctx, req)
+	if key == "" {
+		return candidates
+	}
+
+	listed := make(map[int]bool, len(candidates))
+	for _, idx := range candidates {
+		listed[idx] = true
+	}
+
+	h := ringHash(key)
+	start, _ := slices.BinarySearchFunc(c.ring, h, func(p ringPoint, h uint64) int {
+		switch {
+		case p.hash < h:
+			return -1
+		case p.hash > h:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	order := make([]int, 0, len(candidates))
+
+	for i := range c.ring {
+		idx := c.ring[(start+i)%len(c.ring)].idx
+		if listed[idx] {
+			listed[idx] = false
+			order = append(order, idx)
+
+			if len(order) == len(candidates) {
+				break
+			}
+		}
+	}
+
+	return order
+}
@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// cacheCompleter simulates a backend prompt cache: a conversation it has
+// seen before, keyed by its first message, reads its prompt from cache.
+type cacheCompleter struct {
+	okCompleter
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (c *cacheCompleter) ChatCompletion(_ context.Context, r *ais.ChatRequest) (*ais.ChatResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := r.Messages[0].Content.Text()
+	usage := ais.Usage{PromptTokens: 100}
+
+	if c.seen[key] {
+		usage.CacheReadTokens = 90
+	}
+
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+
+	c.seen[key] = true
+
+	return &ais.ChatResponse{Model: r.Model, Usage: usage}, nil
+}
+
+func sessionRequest(session string) *ais.ChatRequest {
+	return &ais.ChatRequest{Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent(session)}}}
+}
+
+// TestSessionAffinity_Sticky verifies that a session keeps its entry under a
+// random selector, and that sessions spread over the entries.
+func TestSessionAffinity_Sticky(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyRandom, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+		{Name: "m2", Client: &okCompleter{}},
+		{Name: "m3", Client: &okCompleter{}},
+	}, WithSessionAffinity(nil))
+
+	used := map[string]int{}
+
+	for s := range 30 {
+		ctx := WithSessionKey(context.Background(), fmt.Sprintf("session-%d", s))
+		home := ""
+
+		for range 5 {
+			resp, err := cc.ChatCompletion(ctx, testRequest())
+			if err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+
+			if home != "" && resp.Model != home {
+				t.Fatalf("session %d moved from %s to %s", s, home, resp.Model)
+			}
+
+			home = resp.Model
+		}
+
+		used[home]++
+	}
+
+	if len(used) != 3 {
+		t.Errorf("sessions per entry = %v, want all entries used", used)
+	}
+}
+
+// TestSessionAffinity_Fallback verifies that a session moves to the next
+// entry on the ring while its entry is unhealthy, and returns afterwards.
+func TestSessionAffinity_Fallback(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+		{Name: "m2", Client: &okCompleter{}},
+		{Name: "m3", Client: &okCompleter{}},
+	}, WithSessionAffinity(func(_ context.Context, r *ais.ChatRequest) string {
+		return r.Messages[0].Content.Text()
+	}))
+
+	req := sessionRequest("conversation")
+	ctx := context.Background()
+
+	home, _ := cc.ChatCompletion(ctx, req)
+	homeIdx := map[string]int{"m1": 0, "m2": 1, "m3": 2}[home.Model]
+
+	cc.health[homeIdx].markError(errors.New("down"), cc.nowFunc())
+
+	fallback, _ := cc.ChatCompletion(ctx, req)
+	if fallback.Model == home.Model {
+		t.Fatalf("request still went to unhealthy %s", home.Model)
+	}
+
+	again, _ := cc.ChatCompletion(ctx, req)
+	if again.Model != fallback.Model {
+		t.Fatalf("fallback moved from %s to %s", fallback.Model, again.Model)
+	}
+
+	cc.health[homeIdx].probeSucceeded(cc.nowFunc())
+
+	back, _ := cc.ChatCompletion(ctx, req)
+	if back.Model != home.Model {
+		t.Fatalf("session went to %s after recovery, want %s", back.Model, home.Model)
+	}
+}
+
+// TestSessionAffinity_CacheReads verifies the point of affinity: every turn
+// after the first reads its prompt from the cache, as the usage shows.
+func TestSessionAffinity_CacheReads(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyWeight, []ModelEntry{
+		{Name: "m1", Client: &cacheCompleter{}},
+		{Name: "m2", Client: &cacheCompleter{}},
+		{Name: "m3", Client: &cacheCompleter{}},
+	}, WithSessionAffinity(nil))
+
+	const sessions, turns = 10, 4
+
+	for s := range sessions {
+		key := fmt.Sprintf("session-%d", s)
+		ctx := WithSessionKey(context.Background(), key)
+
+		for turn := range turns {
+			resp, err := cc.ChatCompletion(ctx, sessionRequest(key))
+			if err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+
+			if turn > 0 && resp.Usage.CacheReadTokens == 0 {
+				t.Fatalf("%s turn %d missed the cache on %s", key, turn, resp.Model)
+			}
+		}
+	}
+
+	var prompt, cached uint64
+
+	for _, st := range cc.Status() {
+		prompt += st.PromptTokens
+		cached += st.CacheReadTokens
+	}
+
+	if want := uint64(sessions * (turns - 1) * 90); prompt != sessions*turns*100 || cached != want {
+		t.Fatalf("prompt = %d, cached = %d; want %d and %d", prompt, cached, sessions*turns*100, want)
+	}
+}
+
+// TestBuildRing_Consistent verifies that removing an entry only moves the
+// sessions it held.
+func TestBuildRing_Consistent(t *testing.T) {
+	entries := []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+		{Name: "m2", Client: &okCompleter{}},
+		{Name: "m3", Client: &okCompleter{}},
+		{Name: "m4", Client: &okCompleter{}},
+	}
+
+	home := func(entries []ModelEntry, key string) string {
+		cc, _ := NewComposeClient(StrategyFailover, entries, WithSessionAffinity(nil))
+		order := cc.affinityOrder(WithSessionKey(context.Background(), key), testRequest(), cc.selectModels(testRequest(), nil))
+
+		return entries[order[0]].Name
+	}
+
+	moved := 0
+
+	for k := range 200 {
+		key := fmt.Sprintf("k%d", k)
+		before := home(entries, key)
+		after := home(entries[1:], key)
+
+		if before != "m1" && after != before {
+			t.Fatalf("%s moved from %s to %s", key, before, after)
+		}
+
+		if before != after {
+			moved++
+		}
+	}
+
+	if moved == 0 {
+		t.Fatal("no session lived on the removed entry")
+	}
+}
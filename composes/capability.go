@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Capabilities declares what an entry's model can serve. Dispatch skips an
+// entry whose declared capabilities do not cover the request, instead of
+// spending a round trip on a guaranteed rejection.
+type Capabilities struct {
+	// Vision accepts image content parts.
+	Vision bool `json:"vision,omitempty"`
+	// Tools accepts tool definitions.
+	Tools bool `json:"tools,omitempty"`
+	// Reasoning accepts Thinking and a ReasoningEffort other than "none".
+	Reasoning bool `json:"reasoning,omitempty"`
+	// StructuredOutput accepts a JSON-schema ResponseFormat.
+	StructuredOutput bool `json:"structured_output,omitempty"`
+	// MaxContextTokens is the context window; requests whose estimated
+	// input plus output cap exceed it are not sent. Zero means unknown.
+	MaxContextTokens int `json:"max_context_tokens,omitempty"`
+	// Provider is the extension namespace the entry's client reads, e.g.
+	// anthropic.Name. A request carrying provider extensions only goes to
+	// entries whose namespace is among them. Empty means unknown.
+	Provider string `json:"provider,omitempty"`
+}
+
+// requirements is what a request needs from an entry.
+type requirements struct {
+	vision, tools, reasoning, structured bool
+	tokens                               int
+	namespaces                           map[string]bool
+}
+
+// list names the requirements for an error message.
+func (r requirements) list() []string {
+	var names []string
+
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{r.vision, "vision"},
+		{r.tools, "tools"},
+		{r.reasoning, "reasoning"},
+		{r.structured, "structured output"},
+	} {
+		if f.set {
+			names = append(names, f.name)
+		}
+	}
+
+	names = append(names, fmt.Sprintf("~%d context tokens", r.tokens))
+
+	if len(r.namespaces) > 0 {
+		ns := make([]string, 0, len(r.namespaces))
+		for n := range r.namespaces {
+			ns = append(ns, n)
+		}
+
+		slices.Sort(ns)
+		names = append(names, "provider "+strings.Join(ns, " or "))
+	}
+
+	return names
+}
+
+// serves reports whether an entry with these capabilities can take a
+// request with requirements r. Nil capabilities serve every request.
+func (c *Capabilities) serves(r requirements) bool {
+	if c == nil {
+		return true
+	}
+
+	switch {
+	case r.vision && !c.Vision,
+		r.tools && !c.Tools,
+		r.reasoning && !c.Reasoning,
+		r.structured && !c.StructuredOutput,
+		c.MaxContextTokens > 0 && r.tokens > c.MaxContextTokens:
+		return false
+	}
+
+	return c.Provider == "" || len(r.namespaces) == 0 || r.namespaces[c.Provider]
+}
+
+// CapabilityError reports that no entry declares the capabilities a request
+// needs. Dispatch returns it before any attempt.
+type CapabilityError struct {
+	// Required lists the request's requirements, e.g. "vision", "tools".
+	Required []string
+}
+
+func (e *CapabilityError) Error() string {
+	return "aimodel/composes: no entry can serve the request (requires " + strings.Join(e.Required, ", ") + ")"
+}
+
+// WithTokenEstimator replaces EstimateTokens for checking requests against
+// Capabilities.MaxContextTokens.
+func WithTokenEstimator(fn func(*ais.ChatRequest) int) ComposeOption {
+	return func(c *ComposeClient) {
+		c.estimate = fn
+	}
+}
+
+// EstimateTokens roughly estimates the context a request takes: the prompt
+// and output cap from ais.EstimateTokens. Use WithTokenEstimator to plug in a
+// tokenizer.
+func EstimateTokens(req *ais.ChatRequest) int {
+	prompt, completion := ais.EstimateTokens(req)
+
+	return prompt + completion
+}
+
+// requirementsOf inspects req for what it needs from an entry.
+func (c *ComposeClient) requirementsOf(req *ais.ChatRequest) requirements {
+	r := requirements{
+		tools:      len(req.Tools) > 0,
+		reasoning:  req.Thinking != nil && req.Thinking.Type != "disabled",
+		structured: isJSONSchema(req.ResponseFormat),
+		tokens:     c.estimate(req),
+	}
+
+	if req.ReasoningEffort != "" && req.ReasoningEffort != ais.ReasoningEffortNone {
+		r.reasoning = true
+	}
+
+	addNamespaces := func(e ais.Extensions) {
+		for ns, v := range e {
+			if v == nil {
+				continue
+			}
+
+			if r.namespaces == nil {
+				r.namespaces = make(map[string]bool)
+			}
+
+			r.namespaces[ns] = true
+		}
+	}
+
+	addNamespaces(req.Extensions)
+
+	for _, t := range req.Tools {
+		addNamespaces(t.Extensions)
+	}
+
+	for _, m := range req.Messages {
+		// Replayed assistant turns carry the extensions of the provider
+		// that produced them (thinking blocks, parts, output items); any
+		// entry accepts them back, so they constrain nothing.
+		if m.Role != ais.RoleAssistant {
+			addNamespaces(m.Extensions)
+		}
+
+		for _, p := range m.Content.Parts() {
+			if p.Type == "image_url" || p.ImageURL != nil {
+				r.vision = true
+			}
+		}
+	}
+
+	return r
+}
+
+// isJSONSchema reports whether a ResponseFormat asks for a JSON schema.
+func isJSONSchema(rf any) bool {
+	m, ok := rf.(map[string]any)
+	if !ok {
+		return false
+	}
+
+	t, _ := m["type"].(string)
+
+	return t == "json_schema"
+}
+
+// eligible returns which entries can serve req, or nil when none declares
+// capabilities. It returns a *CapabilityError when no entry qualifies.
+func (c *ComposeClient) eligible(req *ais.ChatRequest) ([]bool, error) {
+	declared := false
+
+	for _, e := range c.entries {
+		if e.Capabilities != nil {
+			declared = true
+
+			break
+		}
+	}
+
+	if !declared {
+		return nil, nil
+	}
+
+	r := c.requirementsOf(req)
+	eligible := make([]bool, len(c.entries))
+	found := false
+
+	for i, e := range c.entries {
+		eligible[i] = e.Capabilities.serves(r)
+		found = found || eligible[i]
+	}
+
+	if !found {
+		return nil, &CapabilityError{Required: r.list()}
+	}
+
+	return eligible, nil
+}
@@ -0,0 +1,204 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestRequirementsOf verifies what a request is found to need.
+func TestRequirementsOf(t *testing.T) {
+	cc, _ := NewComposeClient(nil, []ModelEntry{{Name: "m", Client: &okCompleter{}}})
+
+	image := ais.Message{Role: ais.RoleUser, Content: ais.NewPartsContent(
+		ais.ContentPart{Type: "text", Text: "what is this?"},
+		ais.ContentPart{Type: "image_url", ImageURL: &ais.ImageURL{URL: "https://example.com/a.png"}},
+	)}
+
+	tests := []struct {
+		name string
+		edit func(r *ais.ChatRequest)
+		want requirements
+	}{
+		{"plain", func(*ais.ChatRequest) {}, requirements{}},
+		{"image", func(r *ais.ChatRequest) { r.Messages = append(r.Messages, image) }, requirements{vision: true}},
+		{"tools", func(r *ais.ChatRequest) {
+			r.Tools = []ais.Tool{{Type: "function", Function: ais.FunctionDefinition{Name: "f"}}}
+		}, requirements{tools: true}},
+		{"thinking", func(r *ais.ChatRequest) { r.Thinking = &ais.Thinking{Type: "adaptive"} }, requirements{reasoning: true}},
+		{"thinking disabled", func(r *ais.ChatRequest) { r.Thinking = &ais.Thinking{Type: "disabled"} }, requirements{}},
+		{"effort", func(r *ais.ChatRequest) { r.ReasoningEffort = ais.ReasoningEffortHigh }, requirements{reasoning: true}},
+		{"effort none", func(r *ais.ChatRequest) { r.ReasoningEffort = ais.ReasoningEffortNone }, requirements{}},
+		{"json schema", func(r *ais.ChatRequest) {
+			r.ResponseFormat = map[string]any{"type": "json_schema", "json_schema": map[string]any{"schema": map[string]any{}}}
+		}, requirements{structured: true}},
+		{"json object", func(r *ais.ChatRequest) { r.ResponseFormat = map[string]any{"type": "json_object"} }, requirements{}},
+		{"extensions", func(r *ais.ChatRequest) {
+			r.Extensions.Set("anthropic", struct{}{})
+			r.Messages[0].Extensions.Set("gemini", struct{}{})
+		}, requirements{namespaces: map[string]bool{"anthropic": true, "gemini": true}}},
+		{"assistant extensions", func(r *ais.ChatRequest) {
+			reply := ais.Message{Role: ais.RoleAssistant, Content: ais.NewTextContent("hi")}
+			reply.Extensions.Set("anthropic", struct{}{})
+			r.Messages = append(r.Messages, reply)
+		}, requirements{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testRequest()
+			tt.edit(req)
+
+			got := cc.requirementsOf(req)
+			got.tokens = 0
+
+			if got.vision != tt.want.vision || got.tools != tt.want.tools || got.reasoning != tt.want.reasoning ||
+				got.structured != tt.want.structured || len(got.namespaces) != len(tt.want.namespaces) {
+				t.Fatalf("requirements = %+v, want %+v", got, tt.want)
+			}
+
+			for ns := range tt.want.namespaces {
+				if !got.namespaces[ns] {
+					t.Errorf("namespace %q not found", ns)
+				}
+			}
+		})
+	}
+}
+
+// TestCapabilities_SkipIncapable verifies that dispatch never sends a
+// request to an entry that declares it cannot serve it.
+func TestCapabilities_SkipIncapable(t *testing.T) {
+	textOnly := &errCompleter{err: &ais.APIError{StatusCode: 400}}
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "text", Client: textOnly, Capabilities: &Capabilities{Tools: true}},
+		{Name: "vision", Client: &okCompleter{}, Capabilities: &Capabilities{Vision: true}},
+	})
+
+	req := testRequest()
+	req.Messages[0].Content = ais.NewPartsContent(
+		ais.ContentPart{Type: "image_url", ImageURL: &ais.ImageURL{URL: "https://example.com/a.png"}},
+	)
+
+	resp, err := cc.ChatCompletion(context.Background(), req)
+	if err != nil || resp.Model != "vision" {
+		t.Fatalf("got %v, %v; want the vision entry", resp, err)
+	}
+
+	if textOnly.calls != 0 {
+		t.Errorf("text-only entry called %d times", textOnly.calls)
+	}
+}
+
+// TestCapabilities_ContextAndProvider verifies the context window and the
+// provider namespace checks, with nil capabilities serving everything.
+func TestCapabilities_ContextAndProvider(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "small", Client: &okCompleter{}, Capabilities: &Capabilities{MaxContextTokens: 1000, Provider: "openai"}},
+		{Name: "large", Client: &okCompleter{}, Capabilities: &Capabilities{MaxContextTokens: 100000, Provider: "openai"}},
+		{Name: "claude", Client: &okCompleter{}, Capabilities: &Capabilities{Provider: "anthropic"}},
+		{Name: "any", Client: &okCompleter{}},
+	}, WithTokenEstimator(func(r *ais.ChatRequest) int { return len(r.Messages) * 800 }))
+
+	pick := func(req *ais.ChatRequest) []int {
+		eligible, err := cc.eligible(req)
+		if err != nil {
+			t.Fatalf("eligible: %v", err)
+		}
+
+		return cc.selectModels(req, eligible)
+	}
+
+	req := testRequest()
+	if got := pick(req); !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Errorf("small request → %v, want every entry", got)
+	}
+
+	req.Messages = append(req.Messages, req.Messages[0])
+	if got := pick(req); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("large request → %v, want the small window skipped", got)
+	}
+
+	req.Extensions.Set("anthropic", struct{}{})
+	if got := pick(req); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("anthropic extensions → %v, want the anthropic and undeclared entries", got)
+	}
+}
+
+// TestCapabilities_MultiTurnFailover verifies that a conversation replaying
+// one provider's reply still fails over to another provider's entry.
+func TestCapabilities_MultiTurnFailover(t *testing.T) {
+	down := &errCompleter{err: &ais.APIError{StatusCode: 500}}
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "claude", Client: down, Capabilities: &Capabilities{Provider: "anthropic"}},
+		{Name: "gpt", Client: &okCompleter{}, Capabilities: &Capabilities{Provider: "openai"}},
+	})
+
+	reply := ais.Message{Role: ais.RoleAssistant, Content: ais.NewTextContent("first answer")}
+	reply.Extensions.Set("anthropic", struct{}{})
+
+	req := testRequest()
+	req.Messages = append(req.Messages, reply, ais.Message{Role: ais.RoleUser, Content: ais.NewTextContent("and then?")})
+
+	resp, err := cc.ChatCompletion(context.Background(), req)
+	if err != nil || resp.Model != "gpt" {
+		t.Fatalf("got %v, %v; want failover to gpt", resp, err)
+	}
+
+	if down.calls != 1 {
+		t.Errorf("claude called %d times, want 1", down.calls)
+	}
+}
+
+// TestCapabilities_NoneQualifies verifies the error when no entry can serve
+// a request, on both paths and regardless of health.
+func TestCapabilities_NoneQualifies(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}, Capabilities: &Capabilities{}},
+		{Name: "m2", Client: &okCompleter{}, Capabilities: &Capabilities{Vision: true}},
+	})
+
+	req := testRequest()
+	req.Tools = []ais.Tool{{Type: "function", Function: ais.FunctionDefinition{Name: "f"}}}
+
+	_, err := cc.ChatCompletion(context.Background(), req)
+
+	var capErr *CapabilityError
+	if !errors.As(err, &capErr) || capErr.Required[0] != "tools" {
+		t.Fatalf("ChatCompletion error = %v, want a CapabilityError requiring tools", err)
+	}
+
+	if _, err := cc.ChatCompletionStream(context.Background(), req); !errors.As(err, &capErr) {
+		t.Fatalf("ChatCompletionStream error = %v, want a CapabilityError", err)
+	}
+
+	// A capable entry that is merely unhealthy yields ErrNoActiveModels.
+	cc.entries[1].Capabilities.Tools = true
+	cc.health[1].markError(errors.New("down"), cc.nowFunc())
+
+	if _, err := cc.ChatCompletion(context.Background(), req); !errors.Is(err, ais.ErrNoActiveModels) {
+		t.Fatalf("error = %v, want ErrNoActiveModels", err)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// ErrorAction is what the dispatch loop does after an entry attempt fails.
+type ErrorAction int
+
+const (
+	// ActionFailover records the error and tries the next candidate.
+	ActionFailover ErrorAction = iota
+	// ActionAbort stops the dispatch and returns the error as is, without
+	// trying the remaining candidates.
+	ActionAbort
+)
+
+// ErrorClass is an ErrorClassifier's verdict on an entry attempt's error.
+type ErrorClass struct {
+	Action ErrorAction
+	// MarkUnhealthy records the error against the entry's health, which
+	// takes it out of selection until a recovery probe succeeds.
+	MarkUnhealthy bool
+}
+
+// ErrorClassifier decides how the dispatch loop handles an entry attempt's
+// error, including errors returned by Recv on a compose stream.
+type ErrorClassifier func(err error) ErrorClass
+
+// WithErrorClassifier replaces ClassifyError as the rule deciding whether an
+// error fails over, aborts the dispatch and marks the entry unhealthy.
+func WithErrorClassifier(fn ErrorClassifier) ComposeOption {
+	return func(c *ComposeClient) {
+		c.classify = fn
+	}
+}
+
+// ClassifyError is the default ErrorClassifier:
+//
+//   - context cancellation and deadline expiry abort without touching health;
+//   - an *ais.ExtensionTypeError is a caller bug that no entry accepts, so it
+//     aborts without touching health;
+//   - an *ais.ContentPartError is a capability gap of one provider: fail over
+//     without marking it unhealthy;
+//   - an *ais.APIError reporting an exceeded context window fails over
+//     without marking, since another model may have a larger window;
+//   - an *ais.APIError with status 401, 403 or 404 (bad key, no access, model
+//     unknown to that backend) fails over and marks the entry unhealthy;
+//   - an *ais.APIError with status 408, 409, 429 or 5xx fails over and marks
+//     the entry unhealthy;
+//   - an *ais.APIError with status 413 fails over without marking;
+//   - any other 4xx reports a malformed request every entry would reject: it
+//     aborts without touching health;
+//   - an *ais.APIError without a status (an SSE error event), a transport
+//     error and anything else fail over and mark the entry unhealthy.
+func ClassifyError(err error) ErrorClass {
+	failover := ErrorClass{Action: ActionFailover, MarkUnhealthy: true}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClass{Action: ActionAbort}
+	}
+
+	var extErr *ais.ExtensionTypeError
+	if errors.As(err, &extErr) {
+		return ErrorClass{Action: ActionAbort}
+	}
+
+	var partErr *ais.ContentPartError
+	if errors.As(err, &partErr) {
+		return ErrorClass{Action: ActionFailover}
+	}
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) {
+		// Transport, decoding and any other errors.
+		return failover
+	}
+
+	if apiErr.ContextLengthExceeded() {
+		return ErrorClass{Action: ActionFailover}
+	}
+
+	switch s := apiErr.StatusCode; {
+	case s == 0,
+		s == http.StatusUnauthorized,
+		s == http.StatusForbidden,
+		s == http.StatusNotFound,
+		s == http.StatusRequestTimeout,
+		s == http.StatusConflict,
+		s == http.StatusTooManyRequests,
+		s >= http.StatusInternalServerError:
+		return failover
+	case s == http.StatusRequestEntityTooLarge:
+		return ErrorClass{Action: ActionFailover}
+	case s >= http.StatusBadRequest:
+		return ErrorClass{Action: ActionAbort}
+	default:
+		return failover
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestClassifyError covers each branch of the default classifier.
+func TestClassifyError(t *testing.T) {
+	failover := ErrorClass{Action: ActionFailover, MarkUnhealthy: true}
+	skip := ErrorClass{Action: ActionFailover}
+	abort := ErrorClass{Action: ActionAbort}
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"canceled", context.Canceled, abort},
+		{"deadline", fmt.Errorf("send: %w", context.DeadlineExceeded), abort},
+		{"extension type", &ais.ExtensionTypeError{Provider: "anthropic", Node: "ChatRequest"}, abort},
+		{"content part", &ais.ContentPartError{Provider: "openai", Type: "document"}, skip},
+		{"context length", &ais.APIError{StatusCode: 400, Code: "context_length_exceeded"}, skip},
+		{"bad request", &ais.APIError{StatusCode: 400, Message: "tools.0: invalid schema"}, abort},
+		{"unprocessable", &ais.APIError{StatusCode: 422}, abort},
+		{"too large", &ais.APIError{StatusCode: 413}, skip},
+		{"unauthorized", &ais.APIError{StatusCode: 401}, failover},
+		{"forbidden", &ais.APIError{StatusCode: 403}, failover},
+		{"not found", &ais.APIError{StatusCode: 404}, failover},
+		{"rate limited", &ais.APIError{StatusCode: 429}, failover},
+		{"overloaded", &ais.APIError{StatusCode: 529}, failover},
+		{"stream error event", &ais.APIError{Type: "overloaded_error"}, failover},
+		{"transport", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, failover},
+		{"other", errors.New("decode: unexpected EOF"), failover},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %+v, want %+v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// errCompleter fails every call with err and counts the calls.
+type errCompleter struct {
+	err   error
+	calls int
+}
+
+func (e *errCompleter) ChatCompletion(context.Context, *ais.ChatRequest) (*ais.ChatResponse, error) {
+	e.calls++
+
+	return nil, e.err
+}
+
+func (e *errCompleter) ChatCompletionStream(context.Context, *ais.ChatRequest) (*aimodel.Stream, error) {
+	e.calls++
+
+	return nil, e.err
+}
+
+// TestDispatch_ClassifiedErrors verifies that the verdict drives failover and
+// health marking in the dispatch loop.
+func TestDispatch_ClassifiedErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantBackup    int
+		wantUnhealthy bool
+	}{
+		{"bad request aborts", &ais.APIError{StatusCode: 400, Message: "invalid schema"}, 0, false},
+		{"unauthorized fails over", &ais.APIError{StatusCode: 401}, 1, true},
+		{"context length fails over unmarked", &ais.APIError{StatusCode: 400, Code: "context_length_exceeded"}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &errCompleter{err: tt.err}
+			backup := &errCompleter{err: &ais.APIError{StatusCode: 503}}
+
+			cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+				{Name: "m1", Client: primary},
+				{Name: "m2", Client: backup},
+			})
+
+			_, err := cc.ChatCompletion(context.Background(), testRequest())
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want it to wrap %v", err, tt.err)
+			}
+
+			if backup.calls != tt.wantBackup {
+				t.Errorf("backup calls = %d, want %d", backup.calls, tt.wantBackup)
+			}
+
+			if got := !cc.health[0].isActive(); got != tt.wantUnhealthy {
+				t.Errorf("m1 unhealthy = %v, want %v", got, tt.wantUnhealthy)
+			}
+		})
+	}
+}
+
+// TestWithErrorClassifier verifies that a custom classifier replaces the
+// default, for dispatch and for stream Recv errors alike.
+func TestWithErrorClassifier(t *testing.T) {
+	abortAll := func(error) ErrorClass { return ErrorClass{Action: ActionAbort} }
+
+	backup := &errCompleter{err: errors.New("unused")}
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &errCompleter{err: &ais.APIError{StatusCode: 503}}},
+		{Name: "m2", Client: backup},
+	}, WithErrorClassifier(abortAll))
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if backup.calls != 0 || !cc.health[0].isActive() {
+		t.Errorf("backup calls = %d, m1 active = %v; want 0, true", backup.calls, cc.health[0].isActive())
+	}
+
+	cc, _ = NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &scriptedCompleter{err: errMidStream}},
+		{Name: "m2", Client: &scriptedCompleter{deltas: []string{"hello"}}},
+	}, WithErrorClassifier(abortAll), WithStreamFailover(StreamFailoverReplay))
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	if _, err := drain(s); !errors.Is(err, errMidStream) {
+		t.Fatalf("err = %v, want %v", err, errMidStream)
+	}
+
+	if !cc.health[0].isActive() {
+		t.Error("m1 should stay active under the custom classifier")
+	}
+}
@@ -38,8 +38,25 @@ type ModelEntry struct {
 	// Client is the underlying API client for this model.
 	// Protocol routing is handled internally by each Client.
 	Client aimodel.ChatCompleter
-	// Weight is used by StrategyWeight. Zero is treated as 1.
+	// Weight is used by StrategyWeight and reported to selectors. Zero is
+	// treated as 1.
 	Weight int
+	// Capabilities declares what the model can serve; requests needing
+	// more skip the entry. Nil means the entry serves every request.
+	Capabilities *Capabilities
+	// Timeout bounds an attempt on the entry — a stream until its first
+	// chunk — so a slow backend fails over before the caller's deadline.
+	// It fails with ErrAttemptTimeout. Zero means no limit.
+	Timeout time.Duration
+	// MaxConcurrency caps the entry's calls and open streams; at the cap
+	// the entry is skipped and ErrConcurrencyLimit recorded. Zero means no
+	// limit.
+	MaxConcurrency int
+	// Rewrite edits the request sent to this entry, after the model name
+	// is set: e.g. capping MaxCompletionTokens or adding a provider
+	// extension. It gets a deep copy, so the caller's request and other
+	// entries are unaffected.
+	Rewrite func(r *ais.ChatRequest)
 }
 
 // ComposeClient dispatches chat requests across multiple model backends.
@@ -47,26 +64,52 @@ type ModelEntry struct {
 type ComposeClient struct {
 	entries          []ModelEntry
 	health           []*modelHealth
-	strategy         Strategy
+	selector         Selector
 	recoveryInterval time.Duration
 	nowFunc          func() time.Time
+	afterFunc        func(time.Duration) <-chan time.Time
 	rng              *rand.Rand
 	mu               sync.Mutex // protects rng
+	middleware       []aimodel.Middleware
+	streamFailover   StreamFailover
+	replayDedup      ReplayDedup
+	classify         ErrorClassifier
+	breaker          BreakerConfig
+	breakerListener  func(BreakerEvent)
+	healthListener   func(Event)
+	activeCheck      *activeCheck
+	estimate         func(*ais.ChatRequest) int
+	sessionKey       SessionKeyFunc
+	ring             []ringPoint
+	shadow           *shadow
 }
 
 // ComposeOption configures a ComposeClient.
 type ComposeOption func(*ComposeClient)
 
 // WithRecoveryInterval sets the duration after which an errored model
-// becomes eligible for a recovery probe.
+// becomes eligible for a recovery probe: the default open timeout of the
+// circuit breakers (BreakerConfig.OpenTimeout).
 func WithRecoveryInterval(d time.Duration) ComposeOption {
 	return func(c *ComposeClient) {
 		c.recoveryInterval = d
 	}
 }
 
-// NewComposeClient creates a ComposeClient with the given strategy and model entries.
-func NewComposeClient(strategy Strategy, entries []ModelEntry, opts ...ComposeOption) (*ComposeClient, error) {
+// WithMiddleware appends middleware to the chain run around every entry
+// attempt, so a failover produces one pass through the chain per entry tried.
+// Each call carries the per-entry request (with the entry's model); there is
+// no HTTP request or response at this level, so HTTPRequest and HTTPResponse
+// stay nil. The first middleware is the outermost.
+func WithMiddleware(mw ...aimodel.Middleware) ComposeOption {
+	return func(c *ComposeClient) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// NewComposeClient creates a ComposeClient with the given selector and model
+// entries. A nil selector means StrategyFailover.
+func NewComposeClient(selector Selector, entries []ModelEntry, opts ...ComposeOption) (*ComposeClient, error) {
 	if len(entries) == 0 {
 		return nil, fmt.Errorf("aimodel/composes: at least one model entry is required")
 	}
@@ -77,127 +120,309 @@ func NewComposeClient(strategy Strategy, entries []ModelEntry, opts ...ComposeOp
 		}
 	}
 
-	health := make([]*modelHealth, len(entries))
-	for i := range health {
-		health[i] = newModelHealth()
-	}
-
 	c := &ComposeClient{
 		entries:          entries,
-		health:           health,
-		strategy:         strategy,
+		selector:         selector,
 		recoveryInterval: defaultRecoveryInterval,
 		nowFunc:          time.Now,
+		afterFunc:        time.After,
 		rng:              newRand(time.Now().UnixNano()),
+		classify:         ClassifyError,
+		estimate:         EstimateTokens,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	if c.shadow != nil && c.shadow.entry.Client == nil {
+		return nil, fmt.Errorf("aimodel/composes: shadow entry %q: client is nil", c.shadow.entry.Name)
+	}
+
+	cfg := c.breaker.normalize(c.recoveryInterval)
+
+	c.health = make([]*modelHealth, len(entries))
+	for i := range c.health {
+		c.health[i] = newModelHealth()
+		c.health[i].cfg = cfg
+		c.health[i].notify = c.notifier(i)
+		c.health[i].clock = func() time.Time { return c.nowFunc() }
+		c.health[i].maxInFlight = entries[i].MaxConcurrency
+	}
+
+	if c.sessionKey != nil {
+		c.ring = buildRing(entries)
+	}
+
+	c.startHealthCheck()
+
 	return c, nil
 }
 
-// ChatCompletion sends a non-streaming request, routing via the configured strategy.
+// ChatCompletion sends a non-streaming request, routing via the configured selector.
 // Protocol routing is handled internally by each entry's Client.
 func (c *ComposeClient) ChatCompletion(ctx context.Context, req *ais.ChatRequest) (*ais.ChatResponse, error) {
-	return dispatchUnary(ctx, c, req, func(ctx context.Context, client aimodel.ChatCompleter, r *ais.ChatRequest) (*ais.ChatResponse, error) {
-		return client.ChatCompletion(ctx, r)
-	})
+	candidates, err := c.candidates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mirror := c.mirror(ctx, req)
+
+	resp, err := dispatchUnary(ctx, c, req, candidates, func(ctx context.Context, idx int, r *ais.ChatRequest) (*ais.ChatResponse, error) {
+		actx, _, cancel := c.attemptContext(ctx, idx)
+		defer cancel()
+
+		call, err := c.invoke(actx, r, func(ctx context.Context, call *aimodel.Call) (err error) {
+			call.Response, err = c.entries[idx].Client.ChatCompletion(ctx, call.Request)
+
+			return err
+		})
+		if err == nil && call.Response != nil {
+			c.health[idx].observeUsage(&call.Response.Usage)
+		}
+
+		return call.Response, c.attemptErr(actx, idx, err)
+	}, settleResponse)
+
+	mirror.complete(resp, err)
+
+	return resp, err
 }
 
-// ChatCompletionStream sends a streaming request, routing via the configured strategy.
-// Protocol routing is handled internally by each entry's Client.
+// ChatCompletionStream sends a streaming request, routing via the configured selector.
+// Protocol routing is handled internally by each entry's Client. Errors from
+// Recv update the serving entry's health; WithStreamFailover additionally
+// switches to the next candidate mid-stream.
 func (c *ComposeClient) ChatCompletionStream(ctx context.Context, req *ais.ChatRequest) (*aimodel.Stream, error) {
-	return dispatchUnary(ctx, c, req, func(ctx context.Context, client aimodel.ChatCompleter, r *ais.ChatRequest) (*aimodel.Stream, error) {
-		return client.ChatCompletionStream(ctx, r)
+	candidates, err := c.candidates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mirror := c.mirror(ctx, req)
+
+	s, err := c.newFailoverStream(ctx, req, candidates)
+	if err != nil {
+		mirror.complete(nil, err)
+
+		return nil, err
+	}
+
+	return mirror.observe(s), nil
+}
+
+// openStream opens a stream on entry idx through the middleware chain. The
+// stream records the entry's time to first chunk; under StrategyHedged the
+// open waits for that chunk, so an attempt only wins once it produces output.
+func (c *ComposeClient) openStream(ctx context.Context, idx int, r *ais.ChatRequest) (*aimodel.Stream, error) {
+	start := c.nowFunc()
+	actx, stop, cancel := c.attemptContext(ctx, idx)
+
+	call, err := c.invoke(actx, r, func(ctx context.Context, call *aimodel.Call) (err error) {
+		call.Stream, err = c.entries[idx].Client.ChatCompletionStream(ctx, call.Request)
+
+		return err
 	})
+	if err != nil {
+		cancel()
+
+		return nil, c.attemptErr(actx, idx, err)
+	}
+
+	s := call.Stream
+	first := true
+
+	timed := aimodel.NewStream(func() (*ais.StreamChunk, error) {
+		chunk, err := s.Recv()
+		if err == nil && first {
+			first = false
+			stop()
+			c.health[idx].observeTTFT(c.nowFunc().Sub(start))
+		}
+
+		if err == nil && chunk.Usage != nil {
+			c.health[idx].observeUsage(chunk.Usage)
+		}
+
+		return chunk, c.attemptErr(actx, idx, err)
+	}, func() error {
+		defer cancel()
+
+		return s.Close()
+	})
+	timed.SetMeta(s.Meta())
+
+	if _, ok := c.hedging(); ok {
+		return timed.Peek()
+	}
+
+	return timed, nil
+}
+
+// invoke runs one entry attempt through the middleware chain, with terminal
+// making the actual call.
+func (c *ComposeClient) invoke(ctx context.Context, r *ais.ChatRequest, terminal aimodel.Handler) (*aimodel.Call, error) {
+	call := &aimodel.Call{Request: r}
+	err := aimodel.ApplyMiddleware(terminal, c.middleware...)(ctx, call)
+
+	return call, err
 }
 
-// dispatchUnary is the generic dispatch loop shared by all public methods.
+// attemptFunc makes one attempt of a request on entry idx.
+type attemptFunc[T any] func(ctx context.Context, idx int, r *ais.ChatRequest) (T, error)
+
+// dispatchUnary dispatches a call over the candidates selected for req that
+// completes within the attempt.
 func dispatchUnary[T any](
 	ctx context.Context,
 	c *ComposeClient,
 	req *ais.ChatRequest,
-	call func(context.Context, aimodel.ChatCompleter, *ais.ChatRequest) (T, error),
+	candidates []int,
+	call attemptFunc[T],
+	settle settleFunc[T],
 ) (T, error) {
-	var zero T
+	result, pos, _, err := dispatch(ctx, c, req, candidates, nil, call, settle, false)
+	if err == nil {
+		c.health[candidates[pos]].end()
+	}
+
+	return result, err
+}
 
-	candidates := c.selectModels()
+// candidates returns the selector's candidate order for req with the entries
+// due for a circuit breaker trial in front, restricted to the entries capable
+// of serving req and put in session order under WithSessionAffinity. It
+// returns a *CapabilityError when no entry is capable, and ErrNoActiveModels
+// when the list is empty.
+func (c *ComposeClient) candidates(ctx context.Context, req *ais.ChatRequest) ([]int, error) {
+	eligible, err := c.eligible(req)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := c.selectModels(req, eligible)
 
-	// Recovery probe: prepend errored models that are eligible for probing.
-	candidates = c.prependRecoveryProbes(candidates)
+	// Circuits ready for a trial go first, so a recovered entry is seen.
+	candidates = c.prependTrials(candidates, eligible)
+	candidates = c.affinityOrder(ctx, req, candidates)
 
 	if len(candidates) == 0 {
-		return zero, ais.ErrNoActiveModels
+		return nil, ais.ErrNoActiveModels
 	}
 
-	var errs []ais.ModelError
+	return candidates, nil
+}
 
-	for _, idx := range candidates {
+// dispatchCandidates tries candidates in order until one succeeds, and
+// returns its result and position in candidates. The error classifier
+// decides per failure whether to mark the entry and whether to go on. The
+// successful entry keeps its in-flight slot; the caller releases it with
+// end. errs carries the failures of earlier attempts (a mid-stream failover
+// resumes the list); the returned slice extends it.
+func dispatchCandidates[T any](
+	ctx context.Context,
+	c *ComposeClient,
+	req *ais.ChatRequest,
+	candidates []int,
+	errs []ais.ModelError,
+	call attemptFunc[T],
+) (T, int, []ais.ModelError, error) {
+	var zero T
+
+	for pos, idx := range candidates {
 		// Return immediately if the context is cancelled to avoid
 		// marking healthy models as errored due to client-side cancellation.
 		if ctx.Err() != nil {
-			return zero, ctx.Err()
+			return zero, 0, errs, ctx.Err()
 		}
 
 		entry := c.entries[idx]
+		start := c.nowFunc()
+
+		// A candidate whose breaker is open, or half-open with every trial
+		// slot taken, is skipped; one at its concurrency limit too.
+		trial, ok := c.health[idx].begin(start)
+		if !ok {
+			errs = c.rejected(idx, errs)
 
-		// Clone the request and override the model name if specified.
-		r := *req
-		if entry.Name != "" {
-			r.Model = entry.Name
+			continue
 		}
 
-		result, err := call(ctx, entry.Client, &r)
+		result, err := call(ctx, idx, c.entryRequest(idx, req))
 		if err != nil {
+			c.health[idx].end()
+
 			// Do not poison model health on context cancellation.
 			if ctx.Err() != nil {
-				return zero, ctx.Err()
+				c.health[idx].finish(trial)
+
+				return zero, 0, errs, ctx.Err()
+			}
+
+			class := c.classify(err)
+			if class.MarkUnhealthy {
+				c.health[idx].markError(err, c.nowFunc())
+			}
+
+			c.health[idx].finish(trial)
+
+			if class.Action == ActionAbort {
+				return zero, 0, errs, err
 			}
 
-			c.health[idx].markError(err, c.nowFunc())
 			errs = append(errs, ais.ModelError{Model: entry.Name, Err: err})
 
 			continue
 		}
 
-		c.health[idx].markActive()
+		now := c.nowFunc()
+		c.health[idx].markActive(now)
+		c.health[idx].finish(trial)
+		c.health[idx].observeLatency(now.Sub(start))
+
+		return result, pos, errs, nil
+	}
+
+	return zero, 0, errs, &ais.MultiError{Errors: errs}
+}
 
-		return result, nil
+// rejected records entry idx in errs when begin turned it away for its
+// concurrency limit; a breaker or operator rejection is not an error.
+func (c *ComposeClient) rejected(idx int, errs []ais.ModelError) []ais.ModelError {
+	if !c.health[idx].atCapacity() {
+		return errs
 	}
 
-	return zero, &ais.MultiError{Errors: errs}
+	return append(errs, ais.ModelError{Model: c.entries[idx].Name, Err: ErrConcurrencyLimit})
 }
 
-// prependRecoveryProbes prepends errored models that are eligible for recovery probing
-// to the candidate list.
-func (c *ComposeClient) prependRecoveryProbes(candidates []int) []int {
+// prependTrials prepends the eligible unhealthy entries whose circuit breaker
+// would admit a trial to the candidate list; nil eligible admits every
+// entry. Admission is decided again when the entry is attempted, so
+// concurrent requests cannot exceed the trial limit.
+func (c *ComposeClient) prependTrials(candidates []int, eligible []bool) []int {
 	now := c.nowFunc()
 
-	// Collect the set of already-active candidates for quick lookup.
-	active := make(map[int]bool, len(candidates))
+	// Collect the set of already-listed candidates for quick lookup.
+	listed := make(map[int]bool, len(candidates))
 	for _, idx := range candidates {
-		active[idx] = true
+		listed[idx] = true
 	}
 
-	var probes []int
+	var trials []int
 
 	for i := range c.entries {
-		if active[i] {
-			continue
-		}
-
-		if c.health[i].shouldProbe(now, c.recoveryInterval) {
-			probes = append(probes, i)
+		if !listed[i] && (eligible == nil || eligible[i]) && c.health[i].probeDue(now) {
+			trials = append(trials, i)
 		}
 	}
 
-	if len(probes) == 0 {
+	if len(trials) == 0 {
 		return candidates
 	}
 
-	return append(probes, candidates...)
+	return append(trials, candidates...)
 }
 
 // Compile-time check: ComposeClient implements aimodel.ChatCompleter.
@@ -711,3 +711,152 @@ func TestNoActiveModels_Error(t *testing.T) {
 		t.Fatalf("expected ErrNoActiveModels, got %v", err)
 	}
 }
+
+func TestMiddleware_RunsPerEntryAttempt(t *testing.T) {
+	failServer := newFailServer(t)
+	defer failServer.Close()
+
+	okServer := newTestServer(t)
+	defer okServer.Close()
+
+	var models []string
+
+	record := func(next aimodel.Handler) aimodel.Handler {
+		return func(ctx context.Context, call *aimodel.Call) error {
+			models = append(models, call.Request.Model)
+			if call.HTTPRequest != nil {
+				t.Error("HTTPRequest should be nil at the compose level")
+			}
+
+			err := next(ctx, call)
+			if err == nil && call.Response == nil {
+				t.Error("Response not set after a successful call")
+			}
+
+			return err
+		}
+	}
+
+	cc, err := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "model-a", Client: newClientForServer(t, failServer)},
+		{Name: "model-b", Client: newClientForServer(t, okServer)},
+	}, WithMiddleware(record))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Model != "model-b" {
+		t.Errorf("model = %q, want model-b", resp.Model)
+	}
+
+	if strings.Join(models, ",") != "model-a,model-b" {
+		t.Errorf("attempts = %v, want [model-a model-b]", models)
+	}
+}
+
+func TestMiddleware_RewritesEntryRequest(t *testing.T) {
+	server := newStreamServer(t)
+	defer server.Close()
+
+	rewrite := func(next aimodel.Handler) aimodel.Handler {
+		return func(ctx context.Context, call *aimodel.Call) error {
+			call.Request.Model = "rewritten"
+
+			return next(ctx, call)
+		}
+	}
+
+	cc, err := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "model-a", Client: newClientForServer(t, server)},
+	}, WithMiddleware(rewrite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	chunk, err := s.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if chunk.Model != "rewritten" {
+		t.Errorf("model = %q, want rewritten", chunk.Model)
+	}
+}
+
+// TestCircuitBreaker_HalfOpenLimitsProbes verifies that a burst of concurrent
+// requests after the open timeout sends a single trial to the broken entry,
+// and that transitions reach the listener.
+func TestCircuitBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	broken := newStallCompleter()
+
+	var (
+		mu     sync.Mutex
+		events []BreakerEvent
+	)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "broken", Client: broken},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithCircuitBreaker(BreakerConfig{OpenTimeout: time.Second}), WithBreakerListener(func(e BreakerEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc.nowFunc = func() time.Time { return now }
+	cc.health[0].markError(errors.New("down"), now)
+
+	now = now.Add(time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	trialDone := make(chan struct{})
+
+	// The trial stalls on the broken entry until cancelled.
+	go func() {
+		defer close(trialDone)
+
+		_, _ = cc.ChatCompletion(ctx, testRequest())
+	}()
+
+	for cc.health[0].snapshot().InFlight == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for range 10 {
+		resp, err := cc.ChatCompletion(context.Background(), testRequest())
+		if err != nil || resp.Model != "backup" {
+			t.Fatalf("got %v, %v; want the backup while the trial runs", resp, err)
+		}
+	}
+
+	cancel()
+	<-trialDone
+
+	if n := len(broken.cancelled); n != 1 {
+		t.Fatalf("broken entry got %d calls, want the single trial", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want transitions to %v", events, want)
+	}
+
+	for i, e := range events {
+		if e.To != want[i] || e.Model != "broken" || e.Index != 0 {
+			t.Errorf("event %d = %+v, want broken to %s", i, e, want[i])
+		}
+	}
+}
@@ -0,0 +1,299 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// Config is the JSON description of a ComposeClient read by LoadConfig. An
+// entry is either a provider client or a nested Config, so pools can be
+// arranged in trees.
+type Config struct {
+	// Strategy names the selector: "failover" (the default), "random",
+	// "weighted" ("weight" is accepted as an alias), "p2c" or
+	// "least_in_flight".
+	Strategy string `json:"strategy,omitempty"`
+	// Hedge, when set, dispatches the strategy with StrategyHedged.
+	Hedge *HedgeConfig `json:"hedge,omitempty"`
+	// RecoveryInterval is WithRecoveryInterval, e.g. "30s".
+	RecoveryInterval Duration      `json:"recovery_interval,omitempty"`
+	Entries          []EntryConfig `json:"entries"`
+}
+
+// HedgeConfig is the JSON form of HedgePolicy.
+type HedgeConfig struct {
+	Delay       Duration `json:"delay,omitempty"`
+	Percentile  float64  `json:"percentile,omitempty"`
+	MaxAttempts int      `json:"max_attempts,omitempty"`
+}
+
+// EntryConfig is the JSON description of a ModelEntry. Compose excludes the
+// provider fields.
+type EntryConfig struct {
+	// Name is the model name sent to the entry (ModelEntry.Name).
+	Name   string `json:"name,omitempty"`
+	Weight int    `json:"weight,omitempty"`
+
+	// Provider is the registered provider name (see ais.Register); empty
+	// means the default OpenAI-compatible provider.
+	Provider string `json:"provider,omitempty"`
+	BaseURL  string `json:"base_url,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key, so no
+	// secret is stored in the configuration. Empty falls back to the
+	// environment defaults of aimodel.NewClient.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// Options are the provider options in their JSON form (e.g. the fields
+	// of anthropic.Options), decoded by the provider's factory.
+	Options json.RawMessage `json:"options,omitempty"`
+
+	Timeout        Duration `json:"timeout,omitempty"`
+	MaxConcurrency int      `json:"max_concurrency,omitempty"`
+	// Capabilities default their Provider to the entry's provider, the
+	// OpenAI-compatible one when Provider is empty.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+
+	// Compose makes the entry a nested ComposeClient.
+	Compose *Config `json:"compose,omitempty"`
+}
+
+// Duration is a time.Duration written as a Go duration string in JSON, e.g.
+// "1m30s".
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+// ConfigError reports an invalid configuration value. Path locates it, e.g.
+// "entries[1].compose.entries[0].provider".
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return "aimodel/composes: config: " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// strategies maps the configuration names to the built-in selectors: each
+// Strategy under its own value, plus the empty default and the "weight" alias.
+var strategies = map[string]Selector{
+	"":                            StrategyFailover,
+	string(StrategyFailover):      StrategyFailover,
+	string(StrategyRandom):        StrategyRandom,
+	string(StrategyWeight):        StrategyWeight,
+	"weight":                      StrategyWeight,
+	string(StrategyP2C):           StrategyP2C,
+	string(StrategyLeastInFlight): StrategyLeastInFlight,
+}
+
+// LoadConfig reads a JSON Config from r and builds its ComposeClient tree.
+// Unknown fields are rejected; invalid values fail with a *ConfigError naming
+// their path. opts apply to the top-level client after the configured ones.
+//
+// An entry's provider must be registered in the program: "openai" and
+// "anthropic" come with the root package, any other provider (e.g. "gemini")
+// only once its package is imported, as in
+//
+//	import _ "github.com/vogo/aimodel/provider/gemini"
+func LoadConfig(r io.Reader, opts ...ComposeOption) (*ComposeClient, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("aimodel/composes: config: %w", err)
+	}
+
+	return cfg.Build(opts...)
+}
+
+// Build constructs the ComposeClient tree described by cfg. opts apply to
+// the top-level client after the configured ones.
+func (cfg *Config) Build(opts ...ComposeOption) (*ComposeClient, error) {
+	return cfg.build("", opts)
+}
+
+func (cfg *Config) build(path string, extra []ComposeOption) (*ComposeClient, error) {
+	selector, ok := strategies[cfg.Strategy]
+	if !ok {
+		return nil, &ConfigError{Path: path + "strategy", Err: fmt.Errorf("unknown strategy %q", cfg.Strategy)}
+	}
+
+	if h := cfg.Hedge; h != nil {
+		if h.Delay < 0 || h.Percentile < 0 || h.Percentile >= 100 || h.MaxAttempts < 0 {
+			return nil, &ConfigError{Path: path + "hedge", Err: errors.New("delay, percentile and max_attempts must be non-negative, percentile below 100")}
+		}
+
+		selector = StrategyHedged(selector, HedgePolicy{
+			Delay:       time.Duration(h.Delay),
+			Percentile:  h.Percentile,
+			MaxAttempts: h.MaxAttempts,
+		})
+	}
+
+	var opts []ComposeOption
+
+	switch {
+	case cfg.RecoveryInterval < 0:
+		return nil, &ConfigError{Path: path + "recovery_interval", Err: errors.New("must not be negative")}
+	case cfg.RecoveryInterval > 0:
+		opts = append(opts, WithRecoveryInterval(time.Duration(cfg.RecoveryInterval)))
+	}
+
+	if len(cfg.Entries) == 0 {
+		return nil, &ConfigError{Path: path + "entries", Err: errors.New("at least one entry is required")}
+	}
+
+	entries := make([]ModelEntry, len(cfg.Entries))
+
+	for i := range cfg.Entries {
+		entry, err := cfg.Entries[i].build(path + "entries[" + strconv.Itoa(i) + "]")
+		if err != nil {
+			return nil, err
+		}
+
+		entries[i] = entry
+	}
+
+	return NewComposeClient(selector, entries, append(opts, extra...)...)
+}
+
+func (e *EntryConfig) build(path string) (ModelEntry, error) {
+	switch {
+	case e.Weight < 0:
+		return ModelEntry{}, &ConfigError{Path: path + ".weight", Err: errors.New("must not be negative")}
+	case e.Timeout < 0:
+		return ModelEntry{}, &ConfigError{Path: path + ".timeout", Err: errors.New("must not be negative")}
+	case e.MaxConcurrency < 0:
+		return ModelEntry{}, &ConfigError{Path: path + ".max_concurrency", Err: errors.New("must not be negative")}
+	}
+
+	entry := ModelEntry{
+		Name:           e.Name,
+		Weight:         e.Weight,
+		Timeout:        time.Duration(e.Timeout),
+		MaxConcurrency: e.MaxConcurrency,
+	}
+
+	if e.Compose != nil {
+		if e.Provider != "" || e.BaseURL != "" || e.APIKeyEnv != "" || len(e.Options) > 0 {
+			return ModelEntry{}, &ConfigError{Path: path + ".compose", Err: errors.New("a nested compose takes no provider, base_url, api_key_env or options")}
+		}
+
+		nested, err := e.Compose.build(path+".compose.", nil)
+		if err != nil {
+			return ModelEntry{}, err
+		}
+
+		entry.Client = nested
+		entry.Capabilities = e.Capabilities
+
+		return entry, nil
+	}
+
+	client, err := e.newClient(path)
+	if err != nil {
+		return ModelEntry{}, err
+	}
+
+	entry.Client = client
+
+	if e.Capabilities != nil {
+		caps := *e.Capabilities
+		if caps.Provider == "" {
+			caps.Provider = client.Provider()
+		}
+
+		entry.Capabilities = &caps
+	}
+
+	return entry, nil
+}
+
+// newClient builds the provider client of an entry.
+func (e *EntryConfig) newClient(path string) (*aimodel.Client, error) {
+	var opts []aimodel.Option
+
+	if e.Provider != "" {
+		// Resolve the name here so an unknown provider is reported at its
+		// path rather than by NewClient.
+		if _, ok := ais.Lookup(e.Provider); !ok {
+			return nil, &ConfigError{Path: path + ".provider", Err: fmt.Errorf("unknown provider %q (import its package to register it, e.g. github.com/vogo/aimodel/provider/%s)", e.Provider, e.Provider)}
+		}
+
+		opts = append(opts, aimodel.WithProvider(e.Provider))
+	}
+
+	if e.BaseURL != "" {
+		opts = append(opts, aimodel.WithBaseURL(e.BaseURL))
+	}
+
+	if e.APIKeyEnv != "" {
+		key := os.Getenv(e.APIKeyEnv)
+		if key == "" {
+			return nil, &ConfigError{Path: path + ".api_key_env", Err: fmt.Errorf("environment variable %s is not set", e.APIKeyEnv)}
+		}
+
+		opts = append(opts, aimodel.WithAPIKey(key))
+	}
+
+	if len(e.Options) > 0 && !bytes.Equal(bytes.TrimSpace(e.Options), []byte("null")) {
+		opts = append(opts, aimodel.WithProviderOptions(e.Options))
+	}
+
+	client, err := aimodel.NewClient(opts...)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	return client, nil
+}
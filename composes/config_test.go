@@ -0,0 +1,218 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestLoadConfig_Tree verifies that a configuration builds its client tree
+// and that requests reach the configured backends.
+func TestLoadConfig_Tree(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	t.Setenv("TEST_POOL_KEY", "sk-test")
+
+	cfg := fmt.Sprintf(`{
+		"strategy": "weighted",
+		"recovery_interval": "30s",
+		"entries": [
+			{"name": "gpt-4o", "weight": 3, "base_url": %q, "api_key_env": "TEST_POOL_KEY",
+			 "timeout": "20s", "max_concurrency": 4, "capabilities": {"tools": true}},
+			{"weight": 1, "compose": {
+				"strategy": "failover",
+				"hedge": {"delay": "2s", "max_attempts": 2},
+				"entries": [
+					{"name": "claude-sonnet", "provider": "anthropic", "api_key_env": "TEST_POOL_KEY",
+					 "options": {"beta": ["fast-mode"]}, "capabilities": {"vision": true}}
+				]
+			}}
+		]
+	}`, server.URL)
+
+	cc, err := LoadConfig(strings.NewReader(cfg))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if cc.recoveryInterval != 30*time.Second || len(cc.entries) != 2 || cc.selector != StrategyWeight {
+		t.Fatalf("client = %+v", cc)
+	}
+
+	e := cc.entries[0]
+	if e.Name != "gpt-4o" || e.Weight != 3 || e.Timeout != 20*time.Second || e.MaxConcurrency != 4 ||
+		e.Capabilities == nil || !e.Capabilities.Tools || e.Capabilities.Provider != "openai" {
+		t.Errorf("entry 0 = %+v", e)
+	}
+
+	nested, ok := cc.entries[1].Client.(*ComposeClient)
+	if !ok {
+		t.Fatalf("entry 1 client = %T, want a nested *ComposeClient", cc.entries[1].Client)
+	}
+
+	if _, hedged := nested.hedging(); !hedged {
+		t.Error("nested client is not hedged")
+	}
+
+	if c := nested.entries[0].Capabilities; c == nil || c.Provider != "anthropic" || !c.Vision {
+		t.Errorf("nested capabilities = %+v, want vision with the entry's provider", c)
+	}
+
+	if _, ok := nested.entries[0].Client.(*aimodel.Client); !ok {
+		t.Errorf("nested entry client = %T", nested.entries[0].Client)
+	}
+
+	// Only the first entry declares tools.
+	req := testRequest()
+	req.Tools = []ais.Tool{{Type: "function", Function: ais.FunctionDefinition{Name: "f"}}}
+
+	resp, err := cc.ChatCompletion(context.Background(), req)
+	if err != nil || resp.Model != "gpt-4o" {
+		t.Fatalf("got %v, %v; want gpt-4o", resp, err)
+	}
+}
+
+// TestLoadConfig_Strategies verifies that every built-in strategy is named by
+// its own value, and that "weight" remains an alias.
+func TestLoadConfig_Strategies(t *testing.T) {
+	t.Setenv("TEST_POOL_KEY", "sk-test")
+
+	for _, s := range []Strategy{StrategyFailover, StrategyRandom, StrategyWeight, StrategyP2C, StrategyLeastInFlight} {
+		cfg := fmt.Sprintf(`{"strategy": %q, "entries": [{"name": "m", "base_url": "http://127.0.0.1:1", "api_key_env": "TEST_POOL_KEY"}]}`, s)
+
+		cc, err := LoadConfig(strings.NewReader(cfg))
+		if err != nil || cc.selector != s {
+			t.Errorf("strategy %q: selector = %v, %v", s, cc, err)
+		}
+	}
+
+	cc, err := LoadConfig(strings.NewReader(`{"strategy": "weight", "entries": [{"name": "m", "base_url": "http://127.0.0.1:1", "api_key_env": "TEST_POOL_KEY"}]}`))
+	if err != nil || cc.selector != StrategyWeight {
+		t.Errorf("alias weight: %v, %v", cc, err)
+	}
+}
+
+// TestLoadConfig_Errors verifies that invalid configurations are rejected
+// with the offending path.
+func TestLoadConfig_Errors(t *testing.T) {
+	t.Setenv("TEST_POOL_KEY", "sk-test")
+	t.Setenv("TEST_POOL_EMPTY", "")
+
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{"no entries", `{"entries": []}`, "config: entries: "},
+		{"strategy", `{"strategy": "fastest", "entries": [{}]}`, "config: strategy: unknown strategy"},
+		{"unknown field", `{"entries": [{"modle": "x"}]}`, `unknown field "modle"`},
+		{"duration", `{"recovery_interval": 30, "entries": [{}]}`, "duration must be a string"},
+		{"weight", `{"entries": [{"weight": -1}]}`, "config: entries[0].weight: "},
+		{"provider", `{"entries": [{"provider": "anthropic", "api_key_env": "TEST_POOL_KEY"}, {"provider": "nope"}]}`,
+			`config: entries[1].provider: unknown provider "nope"`},
+		{"unregistered provider", `{"entries": [{"provider": "gemini"}]}`,
+			"import its package to register it, e.g. github.com/vogo/aimodel/provider/gemini"},
+		{"api key", `{"entries": [{"api_key_env": "TEST_POOL_EMPTY"}]}`,
+			"config: entries[0].api_key_env: environment variable TEST_POOL_EMPTY is not set"},
+		{"nested", `{"entries": [{"compose": {"entries": [{"api_key_env": "TEST_POOL_KEY", "timeout": "-1s"}]}}]}`,
+			"config: entries[0].compose.entries[0].timeout: "},
+		{"nested strategy", `{"entries": [{"compose": {"strategy": "x", "entries": [{}]}}]}`,
+			"config: entries[0].compose.strategy: "},
+		{"compose with provider", `{"entries": [{"provider": "anthropic", "compose": {"entries": [{}]}}]}`,
+			"config: entries[0].compose: "},
+		{"options", `{"entries": [{"provider": "anthropic", "api_key_env": "TEST_POOL_KEY", "options": {"beta": 1}}]}`,
+			"config: entries[0]: aimodel/anthropic: decode provider options"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(strings.NewReader(tt.cfg))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	_, err := LoadConfig(strings.NewReader(`{"entries": [{"weight": -1}]}`))
+
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) || cfgErr.Path != "entries[0].weight" {
+		t.Fatalf("error = %#v, want a ConfigError at entries[0].weight", err)
+	}
+}
+
+// TestReloader_Swap verifies that a reload serves new calls from the new
+// client while a stream opened before it runs to its end, and that a failed
+// reload keeps the current client.
+func TestReloader_Swap(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	t.Setenv("TEST_POOL_KEY", "sk-test")
+
+	config := func(model string) *strings.Reader {
+		return strings.NewReader(fmt.Sprintf(`{"entries": [{"name": %q, "base_url": %q, "api_key_env": "TEST_POOL_KEY"}]}`,
+			model, server.URL))
+	}
+
+	l, err := NewReloader(config("old"))
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	defer l.Close()
+
+	// A stream in flight on the old pool.
+	oldStream := &scriptedCompleter{deltas: []string{"a", "b"}}
+	old, _ := NewComposeClient(nil, []ModelEntry{{Name: "old", Client: oldStream}})
+	l.Swap(old)
+
+	s, err := l.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	if err := l.Reload(config("new")); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	resp, err := l.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "new" {
+		t.Fatalf("got %v, %v; want the new pool", resp, err)
+	}
+
+	if text, err := drain(s); err != nil || text != "ab" {
+		t.Fatalf("old stream = %q, %v; want it served to its end", text, err)
+	}
+
+	if err := l.Reload(strings.NewReader(`{"entries": []}`)); err == nil {
+		t.Fatal("expected an error for an invalid configuration")
+	}
+
+	if resp, _ := l.ChatCompletion(context.Background(), testRequest()); resp == nil || resp.Model != "new" {
+		t.Fatalf("got %v after a failed reload, want the new pool kept", resp)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+var (
+	// ErrAttemptTimeout reports an attempt that exceeded its entry's Timeout.
+	// The default classifier fails over and marks the entry unhealthy.
+	ErrAttemptTimeout = errors.New("aimodel/composes: attempt timed out")
+	// ErrConcurrencyLimit is recorded for an entry skipped because it had
+	// MaxConcurrency requests in flight.
+	ErrConcurrencyLimit = errors.New("aimodel/composes: entry at its concurrency limit")
+)
+
+// entryRequest returns the request sent to entry idx: a copy of req with the
+// entry's model name, rewritten by the entry's Rewrite on a deep copy.
+func (c *ComposeClient) entryRequest(idx int, req *ais.ChatRequest) *ais.ChatRequest {
+	entry := c.entries[idx]
+
+	r := *req
+	if entry.Rewrite != nil {
+		r = req.Clone()
+	}
+
+	if entry.Name != "" {
+		r.Model = entry.Name
+	}
+
+	if entry.Rewrite != nil {
+		entry.Rewrite(&r)
+	}
+
+	return &r
+}
+
+// attemptContext derives the context of an attempt on entry idx. With an
+// entry Timeout the context is cancelled when it expires, unless stop is
+// called first; cancel releases the context.
+func (c *ComposeClient) attemptContext(ctx context.Context, idx int) (actx context.Context, stop func(), cancel func()) {
+	d := c.entries[idx].Timeout
+	if d <= 0 {
+		return ctx, func() {}, func() {}
+	}
+
+	actx, cancelCause := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(d, func() { cancelCause(ErrAttemptTimeout) })
+
+	return actx, func() { timer.Stop() }, func() {
+		timer.Stop()
+		cancelCause(context.Canceled)
+	}
+}
+
+// attemptErr replaces the error of an attempt whose entry Timeout expired
+// with ErrAttemptTimeout, so it is not taken for a caller cancellation.
+func (c *ComposeClient) attemptErr(actx context.Context, idx int, err error) error {
+	if err == nil || !errors.Is(context.Cause(actx), ErrAttemptTimeout) {
+		return err
+	}
+
+	return fmt.Errorf("%w after %s", ErrAttemptTimeout, c.entries[idx].Timeout)
+}
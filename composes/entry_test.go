@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// recordCompleter records the requests it receives.
+type recordCompleter struct {
+	okCompleter
+	got []*ais.ChatRequest
+}
+
+func (r *recordCompleter) ChatCompletion(ctx context.Context, req *ais.ChatRequest) (*ais.ChatResponse, error) {
+	r.got = append(r.got, req)
+
+	return r.okCompleter.ChatCompletion(ctx, req)
+}
+
+// TestEntryTimeout_Unary verifies that a slow entry fails over at its
+// timeout and is marked with ErrAttemptTimeout.
+func TestEntryTimeout_Unary(t *testing.T) {
+	slow := newStallCompleter()
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "slow", Client: slow, Timeout: 20 * time.Millisecond},
+		{Name: "fast", Client: &okCompleter{}},
+	})
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "fast" {
+		t.Fatalf("got %v, %v; want the fast entry", resp, err)
+	}
+
+	if st := cc.Status()[0]; st.State != EntryError || !errors.Is(st.LastError, ErrAttemptTimeout) {
+		t.Fatalf("slow entry = %+v, want errored by ErrAttemptTimeout", st)
+	}
+}
+
+// TestEntryTimeout_Stream verifies that the timeout covers a stream until its
+// first chunk only.
+func TestEntryTimeout_Stream(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "slow", Client: newStallCompleter(), Timeout: 20 * time.Millisecond},
+		{Name: "steady", Client: &scriptedCompleter{deltas: []string{"a", "b"}}, Timeout: 20 * time.Millisecond},
+	}, WithStreamFailover(StreamFailoverBeforeContent))
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	// Chunks after the first may take longer than the timeout.
+	first, err := s.Recv()
+	if err != nil || first.Choices[0].Delta.Content.Text() != "a" {
+		t.Fatalf("first chunk = %v, %v", first, err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	text, err := drain(s)
+	if err != nil || text != "b" {
+		t.Fatalf("rest = %q, %v; want b", text, err)
+	}
+
+	if st := cc.Status()[0]; !errors.Is(st.LastError, ErrAttemptTimeout) {
+		t.Fatalf("slow entry last error = %v, want ErrAttemptTimeout", st.LastError)
+	}
+}
+
+// TestEntryMaxConcurrency verifies that a full entry is skipped, and that the
+// limit is reported when no entry is left.
+func TestEntryMaxConcurrency(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{scriptedCompleter{deltas: []string{"hi"}}}, MaxConcurrency: 1},
+		{Name: "m2", Client: &okCompleter{}},
+	})
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "m2" {
+		t.Fatalf("got %v, %v; want m2 while m1 is full", resp, err)
+	}
+
+	_ = cc.Disable("m2")
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("error = %v, want ErrConcurrencyLimit", err)
+	}
+
+	_, _ = drain(s)
+
+	resp, err = cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "m1" {
+		t.Fatalf("got %v, %v; want m1 once the stream ended", resp, err)
+	}
+}
+
+// TestEntryRewrite verifies that a rewrite applies to its entry only and
+// leaves the caller's request untouched.
+func TestEntryRewrite(t *testing.T) {
+	rewritten := &recordCompleter{}
+	plain := &recordCompleter{}
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: rewritten, Rewrite: func(r *ais.ChatRequest) {
+			r.MaxCompletionTokens = new(256)
+			r.ReasoningEffort = ais.ReasoningEffortLow
+			r.Extensions.Set("anthropic", "marker")
+		}},
+		{Name: "m2", Client: plain},
+	})
+
+	req := testRequest()
+	req.Extensions.Set("openai", "shared")
+
+	_, _ = cc.ChatCompletion(context.Background(), req)
+
+	got := rewritten.got[0]
+	if got.Model != "m1" || *got.MaxCompletionTokens != 256 || got.ReasoningEffort != ais.ReasoningEffortLow ||
+		got.Extensions.Value("anthropic") != "marker" || got.Extensions.Value("openai") != "shared" {
+		t.Fatalf("rewritten request = %+v", got)
+	}
+
+	if req.Model != "placeholder" || req.MaxCompletionTokens != nil || req.Extensions.Value("anthropic") != nil {
+		t.Fatalf("caller's request changed: %+v", req)
+	}
+
+	_ = cc.Disable("m1")
+	_, _ = cc.ChatCompletion(context.Background(), req)
+
+	if p := plain.got[0]; p.ReasoningEffort != "" || p.Extensions.Value("anthropic") != nil {
+		t.Fatalf("rewrite leaked to m2: %+v", p)
+	}
+}
@@ -18,76 +18,611 @@
 package composes
 
 import (
+	"math"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/vogo/aimodel/ais"
 )
 
-// modelState represents the health state of a model.
-type modelState string
+// BreakerState is the state of an entry's circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed admits all traffic; the entry is healthy.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects traffic until the open timeout has elapsed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen admits a limited number of trial requests whose
+	// outcome closes or reopens the circuit.
+	BreakerHalfOpen BreakerState = "half-open"
+)
 
 const (
-	stateActive modelState = "active"
-	stateError  modelState = "error"
+	// ewmaAlpha is the weight of a new sample in the latency averages.
+	ewmaAlpha = 0.3
+	// outcomeWindow is the number of recent attempts the error rate covers.
+	outcomeWindow = 20
+	// sampleWindow is the number of recent latencies kept for percentiles.
+	sampleWindow = 64
 )
 
-// modelHealth tracks the health state of a single model entry.
+// BreakerConfig sets the thresholds of the per-entry circuit breakers.
+type BreakerConfig struct {
+	// ConsecutiveFailures opens the circuit after that many failures in a
+	// row. Zero means 1: any failure takes the entry out of rotation.
+	ConsecutiveFailures int
+	// ErrorRate, when in (0, 1], also opens the circuit once the failed
+	// share of the last 20 attempts reaches it.
+	ErrorRate float64
+	// MinRequests is the number of attempts the window must hold before
+	// ErrorRate applies. Zero means 10.
+	MinRequests int
+	// OpenTimeout is how long the circuit stays open before admitting
+	// trials. It doubles with each consecutive reopening, capped at 64x.
+	// Zero means the recovery interval (WithRecoveryInterval).
+	OpenTimeout time.Duration
+	// HalfOpenTrials is the number of trial requests admitted at once in the
+	// half-open state, and the number of successes that close the circuit.
+	// Zero means 1.
+	HalfOpenTrials int
+}
+
+// BreakerEvent reports a circuit breaker state transition.
+type BreakerEvent struct {
+	// Index and Model identify the entry.
+	Index int
+	Model string
+	From  BreakerState
+	To    BreakerState
+	// Err is the failure that opened the circuit; nil for other transitions.
+	Err  error
+	Time time.Time
+}
+
+// WithCircuitBreaker sets the circuit breaker thresholds of every entry.
+func WithCircuitBreaker(cfg BreakerConfig) ComposeOption {
+	return func(c *ComposeClient) {
+		c.breaker = cfg
+	}
+}
+
+// WithBreakerListener registers fn to be called on every circuit breaker
+// state transition. It is called synchronously on the request path, outside
+// internal locks, and must not block.
+func WithBreakerListener(fn func(BreakerEvent)) ComposeOption {
+	return func(c *ComposeClient) {
+		c.breakerListener = fn
+	}
+}
+
+// normalize fills in the defaults of cfg.
+func (cfg BreakerConfig) normalize(recoveryInterval time.Duration) BreakerConfig {
+	if cfg.ConsecutiveFailures <= 0 {
+		cfg.ConsecutiveFailures = 1
+	}
+
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 10
+	}
+
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = recoveryInterval
+	}
+
+	if cfg.HalfOpenTrials <= 0 {
+		cfg.HalfOpenTrials = 1
+	}
+
+	return cfg
+}
+
+// modelHealth is the circuit breaker and load tracker of a single model entry.
 type modelHealth struct {
 	mu         sync.RWMutex
-	state      modelState
+	cfg        BreakerConfig
+	state      BreakerState
 	lastError  error
 	errorTime  time.Time
-	errorCount int
+	errorCount int // consecutive failures
+
+	// openedAt is when the circuit last opened and trips the number of
+	// consecutive openings, which drives the open timeout's backoff.
+	openedAt time.Time
+	trips    int
+
+	// gen identifies the current half-open period; trials counts its
+	// admitted trials still awaiting an outcome and successes its successes.
+	gen       uint64
+	trials    int
+	successes int
+
+	// admin is the operator's override: Disable, Drain or none.
+	admin EntryState
+
+	// attempts counts the admitted attempts since construction, succeeded
+	// and failed their recorded outcomes.
+	attempts  uint64
+	succeeded uint64
+	failed    uint64
+
+	// promptTokens and cacheReadTokens sum the usage the entry reported.
+	promptTokens    uint64
+	cacheReadTokens uint64
+
+	// notify receives state transitions; nil when nobody listens. clock
+	// stamps the transitions that happen outside a dispatch.
+	notify func(before, after states, err error, at time.Time)
+	clock  func() time.Time
+
+	latency  time.Duration // EWMA of successful attempts
+	ttft     time.Duration // EWMA of stream time to first chunk
+	inFlight int
+	// maxInFlight is the entry's MaxConcurrency; zero means no limit.
+	maxInFlight int
+
+	// latencies and ttfts keep the recent samples behind the averages.
+	latencies sampleRing
+	ttfts     sampleRing
+
+	// outcomes is a ring of the last outcomeWindow attempts, true for a
+	// failure; outcomeLen of them are recorded, the next goes to outcomePos.
+	outcomes   [outcomeWindow]bool
+	outcomeLen int
+	outcomePos int
 }
 
 func newModelHealth() *modelHealth {
-	return &modelHealth{state: stateActive}
+	return &modelHealth{
+		cfg:   BreakerConfig{}.normalize(defaultRecoveryInterval),
+		state: BreakerClosed,
+		clock: time.Now,
+	}
+}
+
+// states is the pair of states a transition can change.
+type states struct {
+	breaker BreakerState
+	entry   EntryState
 }
 
-func (h *modelHealth) markActive() {
+// states returns the current states. h.mu must be held.
+func (h *modelHealth) states() states {
+	entry := h.admin
+
+	switch {
+	case entry != "":
+	case h.state == BreakerClosed:
+		entry = EntryActive
+	default:
+		entry = EntryError
+	}
+
+	return states{breaker: h.state, entry: entry}
+}
+
+// emit reports a transition to the listener. h.mu must not be held.
+func (h *modelHealth) emit(before, after states, err error, at time.Time) {
+	if before != after && h.notify != nil {
+		h.notify(before, after, err, at)
+	}
+}
+
+// markActive records a success. It closes a half-open circuit once
+// HalfOpenTrials successes were seen. An open circuit ignores it: the
+// success is a late one from an attempt admitted before the trip, and only
+// half-open trials may close the circuit.
+func (h *modelHealth) markActive(now time.Time) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
-	h.state = stateActive
-	h.lastError = nil
+	before := h.states()
+	h.recordOutcome(false)
+	h.succeeded++
+
+	if h.state == BreakerOpen {
+		h.mu.Unlock()
+
+		return
+	}
+
 	h.errorCount = 0
+
+	if h.state == BreakerHalfOpen {
+		h.successes++
+		if h.successes < h.cfg.HalfOpenTrials {
+			h.mu.Unlock()
+
+			return
+		}
+	}
+
+	h.state = BreakerClosed
+	h.lastError = nil
 	h.errorTime = time.Time{}
+	h.trips = 0
+	h.trials = 0
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, nil, now)
 }
 
+// markError records a failure. A closed circuit opens when a threshold is
+// reached; a half-open one reopens with a longer timeout. An open circuit
+// only records it: late failures from attempts admitted before the trip
+// neither count as trips nor push the timeout back.
 func (h *modelHealth) markError(err error, now time.Time) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
-	h.state = stateError
+	before := h.states()
+	h.recordOutcome(true)
+	h.failed++
 	h.lastError = err
 	h.errorTime = now
 	h.errorCount++
+
+	if h.state == BreakerHalfOpen || h.state == BreakerClosed && h.tripped() {
+		h.state = BreakerOpen
+		h.openedAt = now
+		h.trips++
+		h.trials = 0
+	}
+
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, err, now)
 }
 
-func (h *modelHealth) isActive() bool {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+// tripped reports whether a closed circuit's thresholds are reached. h.mu
+// must be held.
+func (h *modelHealth) tripped() bool {
+	if h.errorCount >= h.cfg.ConsecutiveFailures {
+		return true
+	}
 
-	return h.state == stateActive
+	return h.cfg.ErrorRate > 0 && h.outcomeLen >= min(h.cfg.MinRequests, outcomeWindow) &&
+		h.errorRate() >= h.cfg.ErrorRate
 }
 
 // maxBackoffShift caps exponential backoff at 2^6 = 64x the base interval.
 const maxBackoffShift = 6
 
-// shouldProbe returns true if enough time has passed since the last error
-// for a recovery probe attempt. The required wait time grows exponentially
-// with consecutive errors, capped at 64x the base interval.
-func (h *modelHealth) shouldProbe(now time.Time, interval time.Duration) bool {
+// openTimeout is how long the circuit stays open after its latest opening.
+// h.mu must be held.
+func (h *modelHealth) openTimeout() time.Duration {
+	shift := min(max(h.trips-1, 0), maxBackoffShift)
+
+	return h.cfg.OpenTimeout * time.Duration(1<<shift)
+}
+
+// probeDue reports whether an unhealthy entry would admit a trial at now:
+// it is open with its timeout elapsed, or half-open with a free trial slot,
+// and not taken out of rotation by an operator.
+func (h *modelHealth) probeDue(now time.Time) bool {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	if h.state != stateError {
+	if h.admin != "" {
+		return false
+	}
+
+	switch h.state {
+	case BreakerOpen:
+		return now.Sub(h.openedAt) >= h.openTimeout()
+	case BreakerHalfOpen:
+		return h.trials < h.cfg.HalfOpenTrials
+	default:
 		return false
 	}
+}
+
+// begin admits an attempt and counts it in flight, moving an open circuit
+// whose timeout elapsed to half-open. It returns false when the breaker, an
+// operator override or the concurrency limit rejects the attempt. A
+// half-open trial gets a non-zero token that the caller hands to finish once
+// the attempt's outcome is recorded; end releases the in-flight slot.
+func (h *modelHealth) begin(now time.Time) (trial uint64, ok bool) {
+	h.mu.Lock()
+
+	if h.admin != "" {
+		h.mu.Unlock()
+
+		return 0, false
+	}
+
+	if h.maxInFlight > 0 && h.inFlight >= h.maxInFlight {
+		h.mu.Unlock()
+
+		return 0, false
+	}
+
+	before := h.states()
+
+	if h.state == BreakerOpen && now.Sub(h.openedAt) >= h.openTimeout() {
+		h.state = BreakerHalfOpen
+		h.gen++
+		h.trials = 0
+		h.successes = 0
+	}
+
+	switch h.state {
+	case BreakerOpen:
+		h.mu.Unlock()
+
+		return 0, false
+	case BreakerHalfOpen:
+		if h.trials >= h.cfg.HalfOpenTrials {
+			h.mu.Unlock()
+
+			return 0, false
+		}
+
+		h.trials++
+		trial = h.gen
+	}
+
+	h.inFlight++
+	h.attempts++
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, nil, now)
+
+	return trial, true
+}
+
+// atCapacity reports whether the entry has its maximum of attempts in
+// flight.
+func (h *modelHealth) atCapacity() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.maxInFlight > 0 && h.inFlight >= h.maxInFlight
+}
+
+// finish frees the half-open trial slot of an attempt admitted with the
+// token trial; a token from an earlier half-open period is ignored.
+func (h *modelHealth) finish(trial uint64) {
+	if trial == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.state == BreakerHalfOpen && h.gen == trial && h.trials > 0 {
+		h.trials--
+	}
+}
+
+// end releases an in-flight slot; the last one completes a drain.
+func (h *modelHealth) end() {
+	h.mu.Lock()
+
+	before := h.states()
+	h.inFlight--
+
+	if h.admin == EntryDraining && h.inFlight == 0 {
+		h.admin = EntryDisabled
+	}
+
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, nil, h.clock())
+}
+
+// setAdmin applies an operator override: EntryDisabled, EntryDraining, or
+// "" to return the entry to its breaker's verdict. A drain with nothing in
+// flight completes at once.
+func (h *modelHealth) setAdmin(admin EntryState) {
+	h.mu.Lock()
+
+	before := h.states()
+	h.admin = admin
+
+	if admin == EntryDraining && h.inFlight == 0 {
+		h.admin = EntryDisabled
+	}
+
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, nil, h.clock())
+}
 
-	shift := max(h.errorCount-1, 0)
-	shift = min(shift, maxBackoffShift)
+// needsProbe reports whether the active health check should probe the
+// entry: its circuit is not closed and no operator override applies.
+func (h *modelHealth) needsProbe() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.admin == "" && h.state != BreakerClosed
+}
+
+// probeSucceeded closes the circuit after a successful active probe.
+func (h *modelHealth) probeSucceeded(now time.Time) {
+	h.mu.Lock()
+
+	before := h.states()
+	h.state = BreakerClosed
+	h.lastError = nil
+	h.errorTime = time.Time{}
+	h.errorCount = 0
+	h.trips = 0
+	h.trials = 0
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, nil, now)
+}
+
+// probeFailed records a failed active probe. The circuit reopens from now
+// without a longer timeout, so no user trial is due while probes keep
+// failing.
+func (h *modelHealth) probeFailed(err error, now time.Time) {
+	h.mu.Lock()
+
+	before := h.states()
+	h.lastError = err
+	h.errorTime = now
+	h.state = BreakerOpen
+	h.openedAt = now
+	h.trials = 0
+	after := h.states()
+	h.mu.Unlock()
+
+	h.emit(before, after, err, now)
+}
+
+// recordOutcome adds an attempt to the error-rate window. h.mu must be held.
+func (h *modelHealth) recordOutcome(failed bool) {
+	h.outcomes[h.outcomePos] = failed
+	h.outcomePos = (h.outcomePos + 1) % outcomeWindow
+	h.outcomeLen = min(h.outcomeLen+1, outcomeWindow)
+}
+
+// errorRate returns the failed share of the recorded window. h.mu must be
+// held.
+func (h *modelHealth) errorRate() float64 {
+	if h.outcomeLen == 0 {
+		return 0
+	}
+
+	failed := 0
+
+	for _, f := range h.outcomes[:h.outcomeLen] {
+		if f {
+			failed++
+		}
+	}
+
+	return float64(failed) / float64(h.outcomeLen)
+}
+
+func (h *modelHealth) observeLatency(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	backoff := interval * time.Duration(1<<shift)
+	h.latency = ewma(h.latency, d)
+	h.latencies.add(d)
+}
+
+func (h *modelHealth) observeTTFT(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ttft = ewma(h.ttft, d)
+	h.ttfts.add(d)
+}
+
+// percentile returns the p-th percentile of the recent latencies — of the
+// times to first chunk when stream is set — or false with fewer than
+// minHedgeSamples samples.
+func (h *modelHealth) percentile(p float64, stream bool) (time.Duration, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ring := &h.latencies
+	if stream {
+		ring = &h.ttfts
+	}
+
+	if ring.n < minHedgeSamples {
+		return 0, false
+	}
+
+	sorted := slices.Clone(ring.samples[:ring.n])
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(p/100*float64(ring.n))) - 1
+
+	return sorted[min(max(rank, 0), ring.n-1)], true
+}
+
+// sampleRing holds the last sampleWindow durations.
+type sampleRing struct {
+	samples [sampleWindow]time.Duration
+	n       int
+	pos     int
+}
+
+func (r *sampleRing) add(d time.Duration) {
+	r.samples[r.pos] = d
+	r.pos = (r.pos + 1) % sampleWindow
+	r.n = min(r.n+1, sampleWindow)
+}
+
+// ewma folds sample into avg; the first sample seeds the average.
+func ewma(avg, sample time.Duration) time.Duration {
+	if avg == 0 {
+		return sample
+	}
+
+	return time.Duration(ewmaAlpha*float64(sample) + (1-ewmaAlpha)*float64(avg))
+}
+
+// snapshot returns the health fields of an EntrySnapshot.
+func (h *modelHealth) snapshot() EntrySnapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return EntrySnapshot{
+		Healthy:    h.state == BreakerClosed && h.admin == "",
+		State:      h.state,
+		ErrorCount: h.errorCount,
+		LastError:  h.lastError,
+		Latency:    h.latency,
+		TTFT:       h.ttft,
+		InFlight:   h.inFlight,
+		ErrorRate:  h.errorRate(),
+	}
+}
+
+// status returns the health fields of an EntryStatus.
+func (h *modelHealth) status() EntryStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	st := EntryStatus{
+		State:      h.states().entry,
+		Breaker:    h.state,
+		LastError:  h.lastError,
+		ErrorTime:  h.errorTime,
+		ErrorCount: h.errorCount,
+		InFlight:   h.inFlight,
+		Latency:    h.latency,
+		TTFT:       h.ttft,
+		ErrorRate:  h.errorRate(),
+		Attempts:   h.attempts,
+		Successes:  h.succeeded,
+		Failures:   h.failed,
+
+		PromptTokens:    h.promptTokens,
+		CacheReadTokens: h.cacheReadTokens,
+	}
+
+	if h.state == BreakerOpen {
+		st.NextProbe = h.openedAt.Add(h.openTimeout())
+	}
+
+	return st
+}
+
+// observeUsage adds the token usage of a response served by the entry.
+func (h *modelHealth) observeUsage(u *ais.Usage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.promptTokens += uint64(max(u.PromptTokens, 0))
+	h.cacheReadTokens += uint64(max(u.CacheReadTokens, 0))
+}
+
+func (h *modelHealth) isActive() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
-	return now.Sub(h.errorTime) >= backoff
+	return h.state == BreakerClosed
 }
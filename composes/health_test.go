@@ -41,15 +41,42 @@ func TestModelHealth_StateTransitions(t *testing.T) {
 		t.Fatal("should be in error state after markError")
 	}
 
-	// error → active
-	h.markActive()
+	// A late success from before the trip leaves the circuit open.
+	h.markActive(now)
+	if h.isActive() {
+		t.Fatal("open circuit should ignore a late success")
+	}
+
+	// error → active through a half-open trial.
+	trial, ok := h.begin(now.Add(h.cfg.OpenTimeout))
+	if !ok {
+		t.Fatal("trial should be admitted once the open timeout elapsed")
+	}
+
+	h.markActive(now)
+	h.finish(trial)
+
 	if !h.isActive() {
-		t.Fatal("should be active after markActive")
+		t.Fatal("should be active after a successful trial")
+	}
+}
+
+// failTrial fails one half-open trial admitted at at.
+func failTrial(t *testing.T, h *modelHealth, at time.Time) {
+	t.Helper()
+
+	trial, ok := h.begin(at)
+	if !ok {
+		t.Fatalf("trial not admitted at %v", at)
 	}
+
+	h.markError(errors.New("trial failed"), at)
+	h.finish(trial)
 }
 
 func TestModelHealth_ErrorCountResets(t *testing.T) {
 	h := newModelHealth()
+	h.cfg = BreakerConfig{ConsecutiveFailures: 3}.normalize(time.Minute)
 	now := time.Now()
 
 	h.markError(errors.New("e1"), now)
@@ -63,7 +90,7 @@ func TestModelHealth_ErrorCountResets(t *testing.T) {
 		t.Fatalf("error count = %d, want 2", count)
 	}
 
-	h.markActive()
+	h.markActive(now)
 
 	h.mu.RLock()
 	count = h.errorCount
@@ -74,79 +101,112 @@ func TestModelHealth_ErrorCountResets(t *testing.T) {
 	}
 }
 
-func TestModelHealth_ShouldProbe(t *testing.T) {
+func TestModelHealth_ProbeDue(t *testing.T) {
 	h := newModelHealth()
 	interval := 60 * time.Second
+	h.cfg.OpenTimeout = interval
 	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
 
 	// Active model should not probe.
-	if h.shouldProbe(now, interval) {
+	if h.probeDue(now) {
 		t.Fatal("active model should not need probing")
 	}
 
 	// First error: backoff = 1x interval (60s).
 	h.markError(errors.New("fail"), now)
-	if h.shouldProbe(now.Add(30*time.Second), interval) {
+	if h.probeDue(now.Add(30 * time.Second)) {
 		t.Fatal("should not probe before interval elapses")
 	}
 
-	if !h.shouldProbe(now.Add(60*time.Second), interval) {
+	if !h.probeDue(now.Add(60 * time.Second)) {
 		t.Fatal("should probe at interval boundary")
 	}
 
-	if !h.shouldProbe(now.Add(90*time.Second), interval) {
+	if !h.probeDue(now.Add(90 * time.Second)) {
 		t.Fatal("should probe after interval")
 	}
 }
 
-func TestModelHealth_ShouldProbe_ExponentialBackoff(t *testing.T) {
+func TestModelHealth_ProbeDue_ExponentialBackoff(t *testing.T) {
 	h := newModelHealth()
 	interval := 10 * time.Second
+	h.cfg.OpenTimeout = interval
 	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
 
 	// 1st error: backoff = 1x = 10s.
 	h.markError(errors.New("fail1"), now)
-	if !h.shouldProbe(now.Add(10*time.Second), interval) {
+	if !h.probeDue(now.Add(10 * time.Second)) {
 		t.Fatal("1st error: should probe after 10s")
 	}
 
-	// 2nd error: backoff = 2x = 20s.
-	h.markError(errors.New("fail2"), now)
-	if h.shouldProbe(now.Add(15*time.Second), interval) {
-		t.Fatal("2nd error: should not probe after 15s (backoff=20s)")
+	// 2nd trip, a failed trial: backoff = 2x = 20s.
+	now = now.Add(10 * time.Second)
+	failTrial(t, h, now)
+
+	if h.probeDue(now.Add(15 * time.Second)) {
+		t.Fatal("2nd trip: should not probe after 15s (backoff=20s)")
 	}
 
-	if !h.shouldProbe(now.Add(20*time.Second), interval) {
-		t.Fatal("2nd error: should probe after 20s")
+	if !h.probeDue(now.Add(20 * time.Second)) {
+		t.Fatal("2nd trip: should probe after 20s")
 	}
 
-	// 3rd error: backoff = 4x = 40s.
-	h.markError(errors.New("fail3"), now)
-	if h.shouldProbe(now.Add(30*time.Second), interval) {
-		t.Fatal("3rd error: should not probe after 30s (backoff=40s)")
+	// 3rd trip: backoff = 4x = 40s.
+	now = now.Add(20 * time.Second)
+	failTrial(t, h, now)
+
+	if h.probeDue(now.Add(30 * time.Second)) {
+		t.Fatal("3rd trip: should not probe after 30s (backoff=40s)")
 	}
 
-	if !h.shouldProbe(now.Add(40*time.Second), interval) {
-		t.Fatal("3rd error: should probe after 40s")
+	if !h.probeDue(now.Add(40 * time.Second)) {
+		t.Fatal("3rd trip: should probe after 40s")
 	}
 }
 
-func TestModelHealth_ShouldProbe_BackoffCap(t *testing.T) {
+func TestModelHealth_LateOutcomesWhileOpen(t *testing.T) {
+	h := newModelHealth()
+	h.cfg.OpenTimeout = 10 * time.Second
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A burst of failures from attempts in flight before the trip is one
+	// trip: the timeout neither grows nor moves.
+	for i := range 10 {
+		h.markError(errors.New("fail"), now.Add(time.Duration(i)*time.Second))
+	}
+
+	if !h.probeDue(now.Add(10 * time.Second)) {
+		t.Fatal("late failures should not push the open timeout back")
+	}
+
+	h.markActive(now.Add(5 * time.Second))
+
+	snap := h.snapshot()
+	if snap.State != BreakerOpen || snap.ErrorRate != 10.0/11 {
+		t.Fatalf("snapshot = %+v; want open with all outcomes recorded", snap)
+	}
+}
+
+func TestModelHealth_ProbeDue_BackoffCap(t *testing.T) {
 	h := newModelHealth()
 	interval := 10 * time.Second
+	h.cfg.OpenTimeout = interval
 	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
 
-	// Mark 100 consecutive errors — backoff should cap at 64x = 640s.
+	// Fail 100 consecutive trials — backoff should cap at 64x = 640s.
+	h.markError(errors.New("fail"), now)
+
 	for range 100 {
-		h.markError(errors.New("fail"), now)
+		now = now.Add(h.openTimeout())
+		failTrial(t, h, now)
 	}
 
 	maxBackoff := interval * 64
-	if h.shouldProbe(now.Add(maxBackoff-time.Second), interval) {
+	if h.probeDue(now.Add(maxBackoff - time.Second)) {
 		t.Fatal("should not probe before capped backoff")
 	}
 
-	if !h.shouldProbe(now.Add(maxBackoff), interval) {
+	if !h.probeDue(now.Add(maxBackoff)) {
 		t.Fatal("should probe at capped backoff")
 	}
 }
@@ -166,13 +226,169 @@ func TestModelHealth_ConcurrentAccess(t *testing.T) {
 			if n%2 == 0 {
 				h.markError(errors.New("fail"), now)
 			} else {
-				h.markActive()
+				h.markActive(now)
+			}
+
+			if trial, ok := h.begin(now); ok {
+				h.end()
+				h.finish(trial)
 			}
 
 			h.isActive()
-			h.shouldProbe(now, time.Minute)
+			h.probeDue(now)
 		}(i)
 	}
 
 	wg.Wait()
 }
+
+func TestModelHealth_LatencyEWMA(t *testing.T) {
+	h := newModelHealth()
+
+	h.observeLatency(100 * time.Millisecond)
+	h.observeLatency(200 * time.Millisecond)
+	h.observeTTFT(50 * time.Millisecond)
+
+	snap := h.snapshot()
+	if snap.Latency != 130*time.Millisecond {
+		t.Errorf("latency = %v, want 130ms", snap.Latency)
+	}
+
+	if snap.TTFT != 50*time.Millisecond {
+		t.Errorf("ttft = %v, want 50ms (first sample seeds the average)", snap.TTFT)
+	}
+}
+
+func TestModelHealth_ErrorRateWindow(t *testing.T) {
+	h := newModelHealth()
+	now := time.Now()
+
+	h.markError(errors.New("fail"), now)
+	h.markActive(now)
+	h.markActive(now)
+	h.markActive(now)
+
+	if got := h.snapshot().ErrorRate; got != 0.25 {
+		t.Fatalf("error rate = %v, want 0.25", got)
+	}
+
+	// The failure rolls out of the window after outcomeWindow successes.
+	for range outcomeWindow {
+		h.markActive(now)
+	}
+
+	if got := h.snapshot().ErrorRate; got != 0 {
+		t.Fatalf("error rate = %v, want 0", got)
+	}
+}
+
+func TestBreaker_ConsecutiveFailures(t *testing.T) {
+	h := newModelHealth()
+	h.cfg = BreakerConfig{ConsecutiveFailures: 3}.normalize(time.Minute)
+	now := time.Now()
+
+	h.markError(errors.New("e1"), now)
+	h.markError(errors.New("e2"), now)
+
+	if !h.isActive() {
+		t.Fatal("circuit should stay closed below the threshold")
+	}
+
+	h.markError(errors.New("e3"), now)
+
+	if got := h.snapshot().State; got != BreakerOpen {
+		t.Fatalf("state = %s, want open", got)
+	}
+}
+
+func TestBreaker_ErrorRate(t *testing.T) {
+	h := newModelHealth()
+	h.cfg = BreakerConfig{ConsecutiveFailures: 100, ErrorRate: 0.5, MinRequests: 4}.normalize(time.Minute)
+	now := time.Now()
+
+	h.markActive(now)
+	h.markError(errors.New("e1"), now)
+	h.markActive(now)
+
+	if !h.isActive() {
+		t.Fatal("circuit should stay closed before MinRequests attempts")
+	}
+
+	h.markError(errors.New("e2"), now)
+
+	if h.isActive() {
+		t.Fatal("circuit should open at a 50% error rate over 4 attempts")
+	}
+}
+
+func TestBreaker_HalfOpenTrials(t *testing.T) {
+	h := newModelHealth()
+	h.cfg = BreakerConfig{HalfOpenTrials: 2}.normalize(10 * time.Second)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h.markError(errors.New("fail"), now)
+
+	if _, ok := h.begin(now.Add(5 * time.Second)); ok {
+		t.Fatal("open circuit should reject before its timeout")
+	}
+
+	later := now.Add(10 * time.Second)
+	t1, ok1 := h.begin(later)
+	t2, ok2 := h.begin(later)
+	_, ok3 := h.begin(later)
+
+	if !ok1 || !ok2 || ok3 || t1 == 0 || h.snapshot().State != BreakerHalfOpen {
+		t.Fatalf("admitted %v %v %v in state %s; want two trials in half-open", ok1, ok2, ok3, h.snapshot().State)
+	}
+
+	// One success is not enough, the second closes the circuit.
+	h.markActive(later)
+	h.finish(t1)
+
+	if h.isActive() {
+		t.Fatal("circuit should stay half-open after one of two successes")
+	}
+
+	h.markActive(later)
+	h.finish(t2)
+
+	if !h.isActive() {
+		t.Fatal("circuit should close after two successes")
+	}
+
+	// A failed trial reopens with a doubled timeout.
+	h.markError(errors.New("fail"), later)
+
+	again := later.Add(10 * time.Second)
+	trial, _ := h.begin(again)
+	h.markError(errors.New("still down"), again)
+	h.finish(trial)
+
+	if h.probeDue(again.Add(15*time.Second)) || !h.probeDue(again.Add(20*time.Second)) {
+		t.Fatal("reopened circuit should wait twice the open timeout")
+	}
+}
+
+func TestBreaker_StaleTrialIgnored(t *testing.T) {
+	h := newModelHealth()
+	h.cfg = BreakerConfig{}.normalize(time.Second)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h.markError(errors.New("fail"), now)
+	stale, _ := h.begin(now.Add(time.Second))
+
+	// The trial's failure reopens; a new half-open period begins later.
+	h.markError(errors.New("fail"), now.Add(time.Second))
+
+	fresh, ok := h.begin(now.Add(time.Hour))
+	if !ok || fresh == stale {
+		t.Fatalf("fresh trial = %d, %v; want a new admitted trial", fresh, ok)
+	}
+
+	// Releasing the stale token must not free the fresh trial's slot.
+	h.finish(stale)
+
+	if h.probeDue(now.Add(time.Hour)) {
+		t.Fatal("stale token freed the running trial's slot")
+	}
+}
@@ -0,0 +1,304 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// minHedgeSamples is the number of latency samples an entry needs before a
+// percentile delay is derived from them.
+const minHedgeSamples = 10
+
+// HedgePolicy configures StrategyHedged.
+type HedgePolicy struct {
+	// Delay is how long an attempt may run without a response — for a
+	// stream, without its first chunk — before the same request is also sent
+	// to the next candidate.
+	Delay time.Duration
+	// Percentile, when in (0, 100), derives the delay from the serving
+	// entry's recent latencies instead (time to first token for streams):
+	// the request is hedged once the attempt is slower than that percentile.
+	// Delay applies until the entry has enough samples.
+	Percentile float64
+	// MaxAttempts caps the attempts running at once; zero means 2.
+	MaxAttempts int
+}
+
+// hedgedSelector marks a selector whose candidates are dispatched with
+// hedging.
+type hedgedSelector struct {
+	Selector
+	policy HedgePolicy
+}
+
+// StrategyHedged orders candidates with selector (nil means StrategyFailover)
+// and dispatches them with hedging: when the running attempt is slower than
+// the policy's delay, the next candidate is tried concurrently. The first
+// success wins and the others are cancelled through their context; a
+// cancelled attempt never marks its entry unhealthy. A failed attempt hands
+// over to the next candidate at once, as in plain failover.
+func StrategyHedged(selector Selector, policy HedgePolicy) Selector {
+	if selector == nil {
+		selector = StrategyFailover
+	}
+
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 2
+	}
+
+	return &hedgedSelector{Selector: selector, policy: policy}
+}
+
+// hedging returns the hedge policy of the client's selector, if any.
+func (c *ComposeClient) hedging() (HedgePolicy, bool) {
+	h, ok := c.selector.(*hedgedSelector)
+	if !ok {
+		return HedgePolicy{}, false
+	}
+
+	return h.policy, true
+}
+
+// hedgeDelay returns how long the attempt on entry idx runs before the next
+// candidate is sent the request; false means no hedge.
+func (c *ComposeClient) hedgeDelay(policy HedgePolicy, idx int, stream bool) (time.Duration, bool) {
+	if policy.Percentile > 0 && policy.Percentile < 100 {
+		if d, ok := c.health[idx].percentile(policy.Percentile, stream); ok {
+			return d, true
+		}
+	}
+
+	return policy.Delay, policy.Delay > 0
+}
+
+// settleFunc finishes the result of a successful hedged attempt: the winner's
+// is returned with cancel bound to its lifetime, a loser's is released.
+type settleFunc[T any] func(v T, cancel context.CancelFunc, won bool) T
+
+// settleResponse finishes a unary attempt; a response needs no cleanup.
+func settleResponse(resp *ais.ChatResponse, cancel context.CancelFunc, _ bool) *ais.ChatResponse {
+	cancel()
+
+	return resp
+}
+
+// settleStream finishes a stream attempt: the winner's context lives until
+// the stream is closed, a loser's stream is closed at once.
+func settleStream(s *aimodel.Stream, cancel context.CancelFunc, won bool) *aimodel.Stream {
+	if !won {
+		_ = s.Close()
+
+		cancel()
+
+		return nil
+	}
+
+	wrapped := aimodel.NewStream(s.Recv, func() error {
+		defer cancel()
+
+		return s.Close()
+	})
+	wrapped.SetMeta(s.Meta())
+
+	return wrapped
+}
+
+// hedgeResult is the outcome of one hedged attempt.
+type hedgeResult[T any] struct {
+	pos int
+	val T
+	err error
+}
+
+// dispatch tries candidates with the client's dispatch mode: hedged when the
+// selector is StrategyHedged, in turn otherwise.
+func dispatch[T any](
+	ctx context.Context,
+	c *ComposeClient,
+	req *ais.ChatRequest,
+	candidates []int,
+	errs []ais.ModelError,
+	call attemptFunc[T],
+	settle settleFunc[T],
+	stream bool,
+) (T, int, []ais.ModelError, error) {
+	if policy, ok := c.hedging(); ok {
+		return dispatchHedged(ctx, c, req, candidates, errs, call, settle, policy, stream)
+	}
+
+	return dispatchCandidates(ctx, c, req, candidates, errs, call)
+}
+
+// dispatchHedged is dispatchCandidates with hedging. It returns the winner's
+// result and position; like dispatchCandidates it leaves the winner's
+// in-flight slot to the caller. Attempts still running when it returns are
+// cancelled and drained in the background without touching health.
+func dispatchHedged[T any](
+	ctx context.Context,
+	c *ComposeClient,
+	req *ais.ChatRequest,
+	candidates []int,
+	errs []ais.ModelError,
+	call attemptFunc[T],
+	settle settleFunc[T],
+	policy HedgePolicy,
+	stream bool,
+) (T, int, []ais.ModelError, error) {
+	var zero T
+
+	results := make(chan hedgeResult[T], len(candidates))
+	cancels := make(map[int]context.CancelFunc, len(candidates))
+	starts := make(map[int]time.Time, len(candidates))
+	trials := make(map[int]uint64, len(candidates))
+	next := 0
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	defer timer.Stop()
+
+	// launch starts the next candidate the circuit breakers admit and arms
+	// the hedge timer for it.
+	launch := func() {
+		for next < len(candidates) {
+			pos := next
+			next++
+
+			idx := candidates[pos]
+			start := c.nowFunc()
+
+			trial, ok := c.health[idx].begin(start)
+			if !ok {
+				errs = c.rejected(idx, errs)
+
+				continue
+			}
+
+			r := c.entryRequest(idx, req)
+
+			actx, cancel := context.WithCancel(ctx)
+			cancels[pos] = cancel
+			starts[pos] = start
+			trials[pos] = trial
+
+			go func() {
+				v, err := call(actx, idx, r)
+				results <- hedgeResult[T]{pos: pos, val: v, err: err}
+			}()
+
+			timer.Stop()
+
+			if d, ok := c.hedgeDelay(policy, idx, stream); ok && next < len(candidates) {
+				timer.Reset(d)
+			}
+
+			return
+		}
+	}
+
+	// abandon cancels the attempts still running and releases them as they
+	// finish: no health update, late successes are settled as losers.
+	abandon := func() {
+		pending := len(cancels)
+		for _, cancel := range cancels {
+			cancel()
+		}
+
+		go func() {
+			for range pending {
+				res := <-results
+				c.health[candidates[res.pos]].end()
+				c.health[candidates[res.pos]].finish(trials[res.pos])
+
+				if res.err == nil {
+					settle(res.val, func() {}, false)
+				}
+			}
+		}()
+	}
+
+	if ctx.Err() != nil {
+		return zero, 0, errs, ctx.Err()
+	}
+
+	launch()
+
+	for len(cancels) > 0 {
+		select {
+		case <-ctx.Done():
+			abandon()
+
+			return zero, 0, errs, ctx.Err()
+
+		case <-timer.C:
+			if len(cancels) < policy.MaxAttempts {
+				launch()
+			}
+
+		case res := <-results:
+			idx := candidates[res.pos]
+			cancel := cancels[res.pos]
+			delete(cancels, res.pos)
+
+			if res.err == nil {
+				abandon()
+
+				now := c.nowFunc()
+				c.health[idx].markActive(now)
+				c.health[idx].finish(trials[res.pos])
+				c.health[idx].observeLatency(now.Sub(starts[res.pos]))
+
+				return settle(res.val, cancel, true), res.pos, errs, nil
+			}
+
+			cancel()
+			c.health[idx].end()
+
+			if ctx.Err() != nil {
+				c.health[idx].finish(trials[res.pos])
+				abandon()
+
+				return zero, 0, errs, ctx.Err()
+			}
+
+			class := c.classify(res.err)
+			if class.MarkUnhealthy {
+				c.health[idx].markError(res.err, c.nowFunc())
+			}
+
+			c.health[idx].finish(trials[res.pos])
+
+			if class.Action == ActionAbort {
+				abandon()
+
+				return zero, 0, errs, res.err
+			}
+
+			errs = append(errs, ais.ModelError{Model: c.entries[idx].Name, Err: res.err})
+
+			launch()
+		}
+	}
+
+	return zero, 0, errs, &ais.MultiError{Errors: errs}
+}
@@ -0,0 +1,215 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// stallCompleter blocks every call until its context ends, then reports the
+// cancellation on cancelled.
+type stallCompleter struct {
+	cancelled chan error
+}
+
+func newStallCompleter() *stallCompleter {
+	return &stallCompleter{cancelled: make(chan error, 4)}
+}
+
+func (s *stallCompleter) stall(ctx context.Context) error {
+	<-ctx.Done()
+	s.cancelled <- ctx.Err()
+
+	return ctx.Err()
+}
+
+func (s *stallCompleter) ChatCompletion(ctx context.Context, _ *ais.ChatRequest) (*ais.ChatResponse, error) {
+	return nil, s.stall(ctx)
+}
+
+func (s *stallCompleter) ChatCompletionStream(ctx context.Context, _ *ais.ChatRequest) (*aimodel.Stream, error) {
+	// The stream opens at once but its first chunk never comes.
+	return aimodel.NewStream(func() (*ais.StreamChunk, error) {
+		return nil, s.stall(ctx)
+	}, nil), nil
+}
+
+// okCompleter answers unary calls with a response naming the model.
+type okCompleter struct {
+	scriptedCompleter
+}
+
+func (o *okCompleter) ChatCompletion(_ context.Context, r *ais.ChatRequest) (*ais.ChatResponse, error) {
+	return &ais.ChatResponse{Model: r.Model}, nil
+}
+
+// waitCancelled fails the test unless the stalled attempt is cancelled.
+func waitCancelled(t *testing.T, s *stallCompleter) {
+	t.Helper()
+
+	select {
+	case err := <-s.cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("loser ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("losing attempt was not cancelled")
+	}
+}
+
+// waitIdle waits for the background release of the losing attempts.
+func waitIdle(t *testing.T, cc *ComposeClient) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for i := range cc.health {
+		for cc.health[i].snapshot().InFlight != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("entry %d still in flight", i)
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// TestHedged_SlowPrimaryLoses verifies that a hedge is sent after the delay,
+// the faster answer wins and the loser is cancelled without poisoning health.
+func TestHedged_SlowPrimaryLoses(t *testing.T) {
+	slow := newStallCompleter()
+	cc, _ := NewComposeClient(StrategyHedged(nil, HedgePolicy{Delay: 10 * time.Millisecond}), []ModelEntry{
+		{Name: "slow", Client: slow},
+		{Name: "fast", Client: &okCompleter{}},
+	})
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if resp.Model != "fast" {
+		t.Fatalf("model = %s, want fast", resp.Model)
+	}
+
+	waitCancelled(t, slow)
+	waitIdle(t, cc)
+
+	if !cc.health[0].isActive() {
+		t.Error("cancelled loser should stay healthy")
+	}
+}
+
+// TestHedged_FastPrimaryNoHedge verifies that no hedge is sent when the first
+// attempt answers within the delay.
+func TestHedged_FastPrimaryNoHedge(t *testing.T) {
+	backup := &errCompleter{err: errors.New("unused")}
+	cc, _ := NewComposeClient(StrategyHedged(nil, HedgePolicy{Delay: time.Hour}), []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+		{Name: "m2", Client: backup},
+	})
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if backup.calls != 0 {
+		t.Fatalf("backup calls = %d, want 0", backup.calls)
+	}
+}
+
+// TestHedged_FailureHandsOver verifies that a failed attempt starts the next
+// candidate without waiting for the delay, and is marked unhealthy.
+func TestHedged_FailureHandsOver(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyHedged(nil, HedgePolicy{Delay: time.Hour}), []ModelEntry{
+		{Name: "down", Client: &errCompleter{err: &ais.APIError{StatusCode: 503}}},
+		{Name: "up", Client: &okCompleter{}},
+	})
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "up" {
+		t.Fatalf("got %v, %v; want the response of up", resp, err)
+	}
+
+	if cc.health[0].isActive() {
+		t.Error("failed entry should be marked unhealthy")
+	}
+}
+
+// TestHedged_StreamWaitsForFirstChunk verifies that a stream which opened but
+// produces nothing is hedged, and the winner's content is delivered whole.
+func TestHedged_StreamWaitsForFirstChunk(t *testing.T) {
+	slow := newStallCompleter()
+	cc, _ := NewComposeClient(StrategyHedged(nil, HedgePolicy{Delay: 10 * time.Millisecond}), []ModelEntry{
+		{Name: "slow", Client: slow},
+		{Name: "fast", Client: &scriptedCompleter{deltas: []string{"hel", "lo"}}},
+	})
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	text, err := drain(s)
+	if err != nil || text != "hello" {
+		t.Fatalf("got %q, %v; want %q", text, err, "hello")
+	}
+
+	waitCancelled(t, slow)
+	waitIdle(t, cc)
+
+	if !cc.health[0].isActive() {
+		t.Error("cancelled loser should stay healthy")
+	}
+}
+
+// TestHedged_PercentileDelay verifies that the delay follows the entry's
+// recorded latencies once there are enough samples.
+func TestHedged_PercentileDelay(t *testing.T) {
+	slow := newStallCompleter()
+	cc, _ := NewComposeClient(StrategyHedged(nil, HedgePolicy{Delay: time.Hour, Percentile: 90}), []ModelEntry{
+		{Name: "slow", Client: slow},
+		{Name: "fast", Client: &okCompleter{}},
+	})
+
+	policy, _ := cc.hedging()
+	if _, ok := cc.hedgeDelay(policy, 0, false); !ok || policy.MaxAttempts != 2 {
+		t.Fatalf("policy = %+v, want the fixed delay and 2 attempts before samples", policy)
+	}
+
+	for i := range minHedgeSamples {
+		cc.health[0].observeLatency(time.Duration(i+1) * time.Millisecond)
+	}
+
+	if d, _ := cc.hedgeDelay(policy, 0, false); d != 9*time.Millisecond {
+		t.Fatalf("p90 delay = %v, want 9ms", d)
+	}
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "fast" {
+		t.Fatalf("got %v, %v; want the response of fast", resp, err)
+	}
+
+	waitCancelled(t, slow)
+}
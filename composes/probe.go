@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// probeMaxTokens caps the output of the default probe request.
+const probeMaxTokens = 16
+
+// HealthChecker is implemented by entry clients that can check their backend
+// more cheaply than with a chat request, e.g. by listing its models. The
+// active health check calls CheckHealth instead of sending the probe request;
+// an error wrapping errors.ErrUnsupported falls back to the probe request.
+// *aimodel.Client implements it through the provider's model list
+// (ais.ModelLister), which the OpenAI-compatible and Gemini providers serve.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// activeCheck is the configuration and lifecycle of the background prober.
+type activeCheck struct {
+	interval time.Duration
+	request  *ais.ChatRequest
+
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WithActiveHealthCheck starts a background prober that, every interval,
+// checks each entry whose circuit is open or half-open: with CheckHealth when
+// the entry's client is a HealthChecker, otherwise by sending probe with the
+// entry's model. A successful probe closes the circuit, so the entry is
+// restored before user traffic reaches it; a failed one reopens it. Probes
+// bypass the middleware and do not count in the error rate or the attempt
+// counters. A nil probe is a one-word request capped at 16 output tokens.
+// Close stops the prober.
+func WithActiveHealthCheck(interval time.Duration, probe *ais.ChatRequest) ComposeOption {
+	return func(c *ComposeClient) {
+		if probe == nil {
+			probe = &ais.ChatRequest{
+				Messages:            []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("ping")}},
+				MaxCompletionTokens: new(probeMaxTokens),
+			}
+		}
+
+		c.activeCheck = &activeCheck{interval: interval, request: probe}
+	}
+}
+
+// Close stops the active health check, if any, and waits for running probes
+// to return. It is safe to call more than once.
+func (c *ComposeClient) Close() error {
+	a := c.activeCheck
+	if a == nil || a.done == nil {
+		return nil
+	}
+
+	a.closeOnce.Do(a.cancel)
+	<-a.done
+
+	return nil
+}
+
+// startHealthCheck runs the prober until Close, a round one interval after
+// the previous one ended, timed by the client's clock.
+func (c *ComposeClient) startHealthCheck() {
+	a := c.activeCheck
+	if a == nil || a.interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.done = make(chan struct{})
+
+	go func() {
+		defer close(a.done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.afterFunc(a.interval):
+				c.probeErrored(ctx)
+			}
+		}
+	}()
+}
+
+// probeErrored probes every entry that is out of rotation because of its
+// breaker, concurrently, each bounded by the check interval. Entries disabled
+// or draining by an operator are left alone.
+func (c *ComposeClient) probeErrored(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for idx, h := range c.health {
+		if !h.needsProbe() {
+			continue
+		}
+
+		wg.Go(func() {
+			pctx, cancel := context.WithTimeout(ctx, c.activeCheck.interval)
+			defer cancel()
+
+			err := c.probe(pctx, idx)
+
+			// A probe cut short by Close says nothing about the entry.
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				h.probeFailed(err, c.nowFunc())
+
+				return
+			}
+
+			h.probeSucceeded(c.nowFunc())
+		})
+	}
+
+	wg.Wait()
+}
+
+// probe checks entry idx once.
+func (c *ComposeClient) probe(ctx context.Context, idx int) error {
+	entry := c.entries[idx]
+
+	if hc, ok := entry.Client.(HealthChecker); ok {
+		if err := hc.CheckHealth(ctx); !errors.Is(err, errors.ErrUnsupported) {
+			return err
+		}
+	}
+
+	r := *c.activeCheck.request
+	if entry.Name != "" {
+		r.Model = entry.Name
+	}
+
+	_, err := entry.Client.ChatCompletion(ctx, &r)
+
+	return err
+}
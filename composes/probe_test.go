@@ -0,0 +1,254 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// checkerCompleter fails chat requests and reports its health through
+// CheckHealth.
+type checkerCompleter struct {
+	errCompleter
+	healthy atomic.Bool
+	checks  atomic.Int32
+}
+
+func (c *checkerCompleter) CheckHealth(context.Context) error {
+	c.checks.Add(1)
+
+	if c.healthy.Load() {
+		return nil
+	}
+
+	return errors.New("backend down")
+}
+
+// TestActiveHealthCheck_RestoresEntry verifies that a successful probe puts
+// an errored entry back in rotation before its open timeout elapsed, without
+// counting as an attempt.
+func TestActiveHealthCheck_RestoresEntry(t *testing.T) {
+	flaky := &errCompleter{err: &ais.APIError{StatusCode: 503}}
+
+	var events []Event
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "flaky", Client: flaky},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithActiveHealthCheck(time.Hour, nil), WithHealthListener(func(e Event) {
+		events = append(events, e)
+	}))
+	defer cc.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc.nowFunc = func() time.Time { return now }
+
+	_, _ = cc.ChatCompletion(context.Background(), testRequest())
+
+	flaky.err = nil
+	now = now.Add(time.Second)
+	cc.probeErrored(context.Background())
+
+	if flaky.calls != 2 {
+		t.Fatalf("flaky calls = %d, want the request and one probe", flaky.calls)
+	}
+
+	st := cc.Status()[0]
+	if st.State != EntryActive || st.Attempts != 1 || st.Failures != 1 || st.LastError != nil {
+		t.Fatalf("status = %+v, want active with only the user attempt counted", st)
+	}
+
+	if len(events) != 2 || events[1].To != EntryActive || !events[1].Time.Equal(now) {
+		t.Fatalf("events = %+v, want the restore at the probe", events)
+	}
+}
+
+// TestActiveHealthCheck_FailedProbe verifies that a failed probe keeps the
+// circuit open from the probe time on, so no user request becomes a trial.
+func TestActiveHealthCheck_FailedProbe(t *testing.T) {
+	down := &ais.APIError{StatusCode: 503}
+	flaky := &errCompleter{err: down}
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "flaky", Client: flaky},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithRecoveryInterval(time.Minute), WithActiveHealthCheck(time.Hour, nil))
+	defer cc.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc.nowFunc = func() time.Time { return now }
+
+	_, _ = cc.ChatCompletion(context.Background(), testRequest())
+
+	now = now.Add(50 * time.Second)
+	cc.probeErrored(context.Background())
+
+	st := cc.Status()[0]
+	if st.State != EntryError || !st.NextProbe.Equal(now.Add(time.Minute)) ||
+		!st.ErrorTime.Equal(now) || st.Failures != 1 {
+		t.Fatalf("status = %+v, want open until a minute after the probe", st)
+	}
+
+	// The original open timeout has elapsed, but the probe reopened the
+	// circuit, so the request goes to the backup only.
+	now = now.Add(20 * time.Second)
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "backup" || flaky.calls != 2 {
+		t.Fatalf("got %v, %v with %d flaky calls; want backup without a trial", resp, err, flaky.calls)
+	}
+}
+
+// TestActiveHealthCheck_Background verifies the prober loop on the client's
+// clock: each round waits one interval, uses the client's HealthChecker,
+// leaves healthy and disabled entries alone, and the loop stops on Close.
+func TestActiveHealthCheck_Background(t *testing.T) {
+	down := &ais.APIError{StatusCode: 503}
+	checked := &checkerCompleter{errCompleter: errCompleter{err: down}}
+	disabled := &checkerCompleter{errCompleter: errCompleter{err: down}}
+	ticks := make(chan time.Time)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "checked", Client: checked},
+		{Name: "disabled", Client: disabled},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithActiveHealthCheck(time.Minute, nil), withAfter(func(d time.Duration) <-chan time.Time {
+		if d != time.Minute {
+			t.Errorf("waited %v, want the interval", d)
+		}
+
+		return ticks
+	}))
+
+	_ = cc.Disable("disabled")
+	cc.health[1].markError(down, time.Now())
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	checked.healthy.Store(true)
+
+	// The second tick is received only once the first round has returned.
+	ticks <- time.Time{}
+	ticks <- time.Time{}
+
+	if err := cc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	_ = cc.Close()
+
+	if st := cc.Status()[0]; st.State != EntryActive {
+		t.Fatalf("status = %+v, want restored by the prober", st)
+	}
+
+	if n := checked.checks.Load(); n != 1 || checked.calls != 1 {
+		t.Errorf("checks = %d, chat calls = %d; want one check and only the user request", n, checked.calls)
+	}
+
+	if n := disabled.checks.Load(); n != 0 {
+		t.Errorf("disabled entry checked %d times", n)
+	}
+
+	select {
+	case ticks <- time.Time{}:
+		t.Error("prober still running after Close")
+	default:
+	}
+}
+
+// TestActiveHealthCheck_UnsupportedCheck verifies that a HealthChecker
+// reporting errors.ErrUnsupported is probed with the chat request instead.
+func TestActiveHealthCheck_UnsupportedCheck(t *testing.T) {
+	down := &ais.APIError{StatusCode: 503}
+	lister := &unsupportedChecker{errCompleter: errCompleter{err: down}}
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "flaky", Client: lister},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithActiveHealthCheck(time.Hour, nil))
+	defer cc.Close()
+
+	_, _ = cc.ChatCompletion(context.Background(), testRequest())
+
+	lister.err = nil
+	cc.probeErrored(context.Background())
+
+	if lister.calls != 2 || cc.Status()[0].State != EntryActive {
+		t.Fatalf("calls = %d, status = %+v; want a chat probe restoring the entry", lister.calls, cc.Status()[0])
+	}
+}
+
+// TestActiveHealthCheck_ModelList verifies that an *aimodel.Client entry is
+// checked through its provider's model list, without a chat request.
+func TestActiveHealthCheck_ModelList(t *testing.T) {
+	var chats, lists atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/models" {
+			lists.Add(1)
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"m1","object":"model"}]}`))
+
+			return
+		}
+
+		chats.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: newClientForServer(t, server)},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithActiveHealthCheck(time.Hour, nil))
+	defer cc.Close()
+
+	_, _ = cc.ChatCompletion(context.Background(), testRequest())
+	cc.probeErrored(context.Background())
+
+	if chats.Load() != 1 || lists.Load() != 1 || cc.Status()[0].State != EntryActive {
+		t.Fatalf("chats = %d, lists = %d, status = %+v; want one model list restoring the entry",
+			chats.Load(), lists.Load(), cc.Status()[0])
+	}
+}
+
+// unsupportedChecker is a HealthChecker whose backend cannot be checked.
+type unsupportedChecker struct {
+	errCompleter
+}
+
+func (*unsupportedChecker) CheckHealth(context.Context) error {
+	return ais.ErrModelListUnsupported
+}
+
+// withAfter replaces the timer side of the client's clock before the prober
+// starts.
+func withAfter(after func(time.Duration) <-chan time.Time) ComposeOption {
+	return func(c *ComposeClient) {
+		c.afterFunc = after
+	}
+}
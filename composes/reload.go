@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// Reloader is a ChatCompleter serving from a ComposeClient that can be
+// replaced at run time, e.g. when the configuration file changes. Each call
+// uses the client current when it starts: a stream opened before a swap
+// keeps running on the old client to its end, so nothing in flight is
+// dropped.
+type Reloader struct {
+	cur  atomic.Pointer[ComposeClient]
+	opts []ComposeOption
+}
+
+// NewReloader loads the initial client from r with LoadConfig. opts apply to
+// every client it loads.
+func NewReloader(r io.Reader, opts ...ComposeOption) (*Reloader, error) {
+	c, err := LoadConfig(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Reloader{opts: opts}
+	l.cur.Store(c)
+
+	return l, nil
+}
+
+// Reload loads a new client from r and swaps it in. On error the current
+// client stays in place.
+func (l *Reloader) Reload(r io.Reader) error {
+	c, err := LoadConfig(r, l.opts...)
+	if err != nil {
+		return err
+	}
+
+	l.Swap(c)
+
+	return nil
+}
+
+// Swap installs c and closes the client it replaces. Closing only stops the
+// old client's background health check; its calls and streams in flight
+// complete normally.
+func (l *Reloader) Swap(c *ComposeClient) {
+	if old := l.cur.Swap(c); old != nil && old != c {
+		_ = old.Close()
+	}
+}
+
+// Client returns the current client, e.g. for its Status.
+func (l *Reloader) Client() *ComposeClient {
+	return l.cur.Load()
+}
+
+// ChatCompletion sends the request through the current client.
+func (l *Reloader) ChatCompletion(ctx context.Context, req *ais.ChatRequest) (*ais.ChatResponse, error) {
+	return l.cur.Load().ChatCompletion(ctx, req)
+}
+
+// ChatCompletionStream opens the stream on the current client, which serves
+// it to its end even if another client is swapped in meanwhile.
+func (l *Reloader) ChatCompletionStream(ctx context.Context, req *ais.ChatRequest) (*aimodel.Stream, error) {
+	return l.cur.Load().ChatCompletionStream(ctx, req)
+}
+
+// Close closes the current client.
+func (l *Reloader) Close() error {
+	return l.cur.Load().Close()
+}
+
+// Compile-time check: Reloader implements aimodel.ChatCompleter.
+var _ aimodel.ChatCompleter = (*Reloader)(nil)
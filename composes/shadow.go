@@ -0,0 +1,194 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// ShadowResult pairs the outcome of a request with that of its mirror on the
+// shadow entry.
+type ShadowResult struct {
+	// Request is a copy of the caller's request.
+	Request *ais.ChatRequest
+	// Primary is a copy of the response the caller received — for a
+	// stream, the chunks it read, accumulated — and PrimaryErr the error it
+	// got instead or after them (ais.ErrStreamClosed when the stream was
+	// closed before its end).
+	Primary        *ais.ChatResponse
+	PrimaryErr     error
+	PrimaryLatency time.Duration
+	// Shadow is the shadow entry's response to the mirrored request, always
+	// made as a unary call.
+	Shadow        *ais.ChatResponse
+	ShadowErr     error
+	ShadowLatency time.Duration
+}
+
+// shadow mirrors sampled requests to an entry outside the rotation.
+type shadow struct {
+	entry    ModelEntry
+	rate     float64
+	compare  func(ShadowResult)
+	inFlight atomic.Int64
+}
+
+// WithShadow mirrors a sampled share of requests, rate in [0, 1], to entry
+// and hands both outcomes to compare, e.g. to evaluate a model before it
+// takes production traffic. The caller always gets the primary's result and
+// never waits for the shadow: the mirror runs in the background, detached
+// from the caller's cancellation, and compare is called from there once
+// both are done. The shadow entry takes no part in selection and its
+// failures never touch health state. Its Name, Rewrite and Timeout apply,
+// MaxConcurrency caps the mirrors in flight (extra samples are dropped), and
+// the middleware does not run for it.
+func WithShadow(entry ModelEntry, rate float64, compare func(ShadowResult)) ComposeOption {
+	return func(c *ComposeClient) {
+		c.shadow = &shadow{entry: entry, rate: min(max(rate, 0), 1), compare: compare}
+	}
+}
+
+// shadowCall is a mirror in flight for one request.
+type shadowCall struct {
+	result ShadowResult
+	start  time.Time
+	done   chan struct{}
+	once   sync.Once
+	c      *ComposeClient
+}
+
+// mirror samples req and, when chosen, sends its copy to the shadow entry.
+// It returns nil when the request is not mirrored.
+func (c *ComposeClient) mirror(ctx context.Context, req *ais.ChatRequest) *shadowCall {
+	sh := c.shadow
+	if sh == nil || sh.rate == 0 || (sh.rate < 1 && c.randFloat() >= sh.rate) {
+		return nil
+	}
+
+	if n := sh.inFlight.Add(1); sh.entry.MaxConcurrency > 0 && n > int64(sh.entry.MaxConcurrency) {
+		sh.inFlight.Add(-1)
+
+		return nil
+	}
+
+	// The caller may reuse its request once the call returns: copy it once,
+	// and again only for a Rewrite that may edit the copy in place.
+	orig := req.Clone()
+
+	r := orig
+	if sh.entry.Rewrite != nil {
+		r = orig.Clone()
+		sh.entry.Rewrite(&r)
+	}
+
+	r.Stream = false
+
+	if sh.entry.Name != "" {
+		r.Model = sh.entry.Name
+	}
+
+	call := &shadowCall{
+		result: ShadowResult{Request: &orig},
+		start:  c.nowFunc(),
+		done:   make(chan struct{}),
+		c:      c,
+	}
+
+	sctx := context.WithoutCancel(ctx)
+
+	go func() {
+		defer sh.inFlight.Add(-1)
+		defer close(call.done)
+
+		if d := sh.entry.Timeout; d > 0 {
+			var cancel context.CancelFunc
+
+			sctx, cancel = context.WithTimeout(sctx, d)
+			defer cancel()
+		}
+
+		resp, err := sh.entry.Client.ChatCompletion(sctx, &r)
+		call.result.Shadow, call.result.ShadowErr = resp, err
+		call.result.ShadowLatency = c.nowFunc().Sub(call.start)
+	}()
+
+	return call
+}
+
+// complete records the primary's outcome and, once the shadow is done,
+// calls the comparison in the background. Only the first call counts. The
+// comparison gets a copy of resp: the caller owns resp once the call returns.
+func (s *shadowCall) complete(resp *ais.ChatResponse, err error) {
+	if s == nil {
+		return
+	}
+
+	s.once.Do(func() {
+		if resp != nil {
+			primary := resp.Clone()
+			resp = &primary
+		}
+
+		s.result.Primary, s.result.PrimaryErr = resp, err
+		s.result.PrimaryLatency = s.c.nowFunc().Sub(s.start)
+
+		go func() {
+			<-s.done
+
+			if s.c.shadow.compare != nil {
+				s.c.shadow.compare(s.result)
+			}
+		}()
+	})
+}
+
+// observe accumulates what the caller reads from s for the comparison.
+func (s *shadowCall) observe(stream *aimodel.Stream) *aimodel.Stream {
+	if s == nil {
+		return stream
+	}
+
+	var acc ais.Accumulator
+
+	return aimodel.InterceptStream(stream, acc.Add, func(err error) {
+		switch {
+		case errors.Is(err, io.EOF):
+			err = nil
+		case err == nil:
+			err = ais.ErrStreamClosed
+		}
+
+		s.complete(acc.Response(), err)
+	})
+}
+
+// randFloat draws from the client's random source under its lock.
+func (c *ComposeClient) randFloat() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rng.Float64()
+}
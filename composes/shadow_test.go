@@ -0,0 +1,235 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// gateCompleter answers once release is closed, failing if its context
+// ended first.
+type gateCompleter struct {
+	release chan struct{}
+}
+
+func (g *gateCompleter) ChatCompletion(ctx context.Context, r *ais.ChatRequest) (*ais.ChatResponse, error) {
+	select {
+	case <-g.release:
+		return &ais.ChatResponse{Model: r.Model, Usage: ais.Usage{PromptTokens: 7}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *gateCompleter) ChatCompletionStream(context.Context, *ais.ChatRequest) (*aimodel.Stream, error) {
+	return nil, errors.New("stream not gated")
+}
+
+// waitShadow returns the next comparison.
+func waitShadow(t *testing.T, results <-chan ShadowResult) ShadowResult {
+	t.Helper()
+
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(2 * time.Second):
+		t.Fatal("comparison not called")
+
+		return ShadowResult{}
+	}
+}
+
+// TestShadow_Unary verifies that the caller gets the primary's response
+// without waiting for the shadow, which outlives the caller's context.
+func TestShadow_Unary(t *testing.T) {
+	gate := &gateCompleter{release: make(chan struct{})}
+	results := make(chan ShadowResult, 1)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+	}, WithShadow(ModelEntry{Name: "candidate", Client: gate}, 1, func(r ShadowResult) { results <- r }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	resp, err := cc.ChatCompletion(ctx, testRequest())
+	if err != nil || resp.Model != "m1" {
+		t.Fatalf("got %v, %v; want the primary's response", resp, err)
+	}
+
+	cancel()
+	close(gate.release)
+
+	r := waitShadow(t, results)
+	if r.Request.Model != "placeholder" || r.Primary == resp || r.Primary.Model != "m1" || r.PrimaryErr != nil {
+		t.Errorf("primary side = %+v", r)
+	}
+
+	if r.ShadowErr != nil || r.Shadow.Model != "candidate" || r.Shadow.Usage.PromptTokens != 7 {
+		t.Errorf("shadow side = %+v, %v", r.Shadow, r.ShadowErr)
+	}
+}
+
+// TestShadow_CallerOwnsResponse verifies, under -race, that the caller may
+// modify its response while the comparison reads the primary's copy.
+func TestShadow_CallerOwnsResponse(t *testing.T) {
+	gate := &gateCompleter{release: make(chan struct{})}
+	read := make(chan string, 1)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+	}, WithShadow(ModelEntry{Client: gate}, 1, func(r ShadowResult) {
+		read <- r.Primary.Model
+	}))
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	close(gate.release)
+
+	resp.Model = "reused"
+
+	if got := <-read; got != "m1" {
+		t.Errorf("comparison read %q, want the response as returned", got)
+	}
+}
+
+// TestShadow_NotMirroredWhenIncapable verifies that both paths sample only
+// requests that pass the capability filter.
+func TestShadow_NotMirroredWhenIncapable(t *testing.T) {
+	mirrored := make(chan ShadowResult, 2)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}, Capabilities: &Capabilities{}},
+	}, WithShadow(ModelEntry{Client: &okCompleter{}}, 1, func(r ShadowResult) { mirrored <- r }))
+
+	req := testRequest()
+	req.Tools = []ais.Tool{{Type: "function", Function: ais.FunctionDefinition{Name: "f"}}}
+
+	var capErr *CapabilityError
+
+	if _, err := cc.ChatCompletion(context.Background(), req); !errors.As(err, &capErr) {
+		t.Fatalf("unary err = %v, want *CapabilityError", err)
+	}
+
+	if _, err := cc.ChatCompletionStream(context.Background(), req); !errors.As(err, &capErr) {
+		t.Fatalf("stream err = %v, want *CapabilityError", err)
+	}
+
+	select {
+	case r := <-mirrored:
+		t.Errorf("incapable request mirrored: %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestShadow_FailureIsolated verifies that a failing shadow reaches the
+// comparison only: health state and the caller are untouched.
+func TestShadow_FailureIsolated(t *testing.T) {
+	results := make(chan ShadowResult, 1)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+	}, WithShadow(ModelEntry{Name: "candidate", Client: newStallCompleter(), Timeout: 10 * time.Millisecond}, 1,
+		func(r ShadowResult) { results <- r }))
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	r := waitShadow(t, results)
+	if !errors.Is(r.ShadowErr, context.DeadlineExceeded) {
+		t.Errorf("shadow error = %v, want its timeout", r.ShadowErr)
+	}
+
+	st := cc.Status()
+	if len(st) != 1 || st[0].State != EntryActive || st[0].Failures != 0 {
+		t.Errorf("status = %+v, want the primary untouched and no shadow entry", st)
+	}
+}
+
+// TestShadow_Stream verifies that a stream's comparison gets what the caller
+// read, accumulated.
+func TestShadow_Stream(t *testing.T) {
+	gate := &gateCompleter{release: make(chan struct{})}
+	close(gate.release)
+
+	results := make(chan ShadowResult, 1)
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &scriptedCompleter{deltas: []string{"a", "b"}}},
+	}, WithShadow(ModelEntry{Name: "candidate", Client: gate}, 1, func(r ShadowResult) { results <- r }))
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	if text, err := drain(s); err != nil || text != "ab" {
+		t.Fatalf("stream = %q, %v", text, err)
+	}
+
+	r := waitShadow(t, results)
+	if r.PrimaryErr != nil || r.Primary.Choices[0].Message.Content.Text() != "ab" || r.Shadow.Model != "candidate" {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+// TestShadow_Sampling verifies the sampling rate and the cap on mirrors in
+// flight.
+func TestShadow_Sampling(t *testing.T) {
+	var mirrored int
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+	}, WithShadow(ModelEntry{Client: &okCompleter{}}, 0.25, nil))
+
+	for range 400 {
+		if call := cc.mirror(context.Background(), testRequest()); call != nil {
+			mirrored++
+
+			call.complete(nil, nil)
+		}
+	}
+
+	if mirrored < 50 || mirrored > 150 {
+		t.Errorf("mirrored %d of 400 at rate 0.25", mirrored)
+	}
+
+	gate := &gateCompleter{release: make(chan struct{})}
+	defer close(gate.release)
+
+	capped, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+	}, WithShadow(ModelEntry{Client: gate, MaxConcurrency: 1}, 1, nil))
+
+	if capped.mirror(context.Background(), testRequest()) == nil {
+		t.Fatal("first request not mirrored")
+	}
+
+	if capped.mirror(context.Background(), testRequest()) != nil {
+		t.Fatal("mirror beyond MaxConcurrency")
+	}
+}
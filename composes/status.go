@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"fmt"
+	"time"
+)
+
+// EntryState is an entry's place in the rotation, as reported by Status and
+// to health listeners.
+type EntryState string
+
+const (
+	// EntryActive is in rotation: its circuit breaker is closed.
+	EntryActive EntryState = "active"
+	// EntryError is out of rotation because its circuit breaker is open or
+	// half-open.
+	EntryError EntryState = "error"
+	// EntryDraining was taken out of rotation by Drain and still has calls
+	// or streams in flight.
+	EntryDraining EntryState = "draining"
+	// EntryDisabled was taken out of rotation by Disable, or by Drain once
+	// nothing is in flight.
+	EntryDisabled EntryState = "disabled"
+)
+
+// EntryStatus is a point-in-time report of one entry.
+type EntryStatus struct {
+	Index int
+	Name  string
+	State EntryState
+	// Breaker is the circuit breaker state, tracked even while an operator
+	// override is in place.
+	Breaker    BreakerState
+	LastError  error
+	ErrorTime  time.Time
+	ErrorCount int
+	// NextProbe is when an open circuit admits its next trial; zero in the
+	// other states.
+	NextProbe time.Time
+	InFlight  int
+	Latency   time.Duration
+	TTFT      time.Duration
+	ErrorRate float64
+	// Attempts, Successes and Failures count the entry's admitted attempts
+	// and their recorded outcomes since construction. Failures only counts
+	// errors the classifier marks unhealthy.
+	Attempts  uint64
+	Successes uint64
+	Failures  uint64
+	// PromptTokens and CacheReadTokens sum the usage of the responses the
+	// entry served; their ratio is the entry's prompt cache hit rate.
+	PromptTokens    uint64
+	CacheReadTokens uint64
+}
+
+// Event reports that an entry entered or left the rotation: a change of
+// EntryState.
+type Event struct {
+	Index int
+	Model string
+	From  EntryState
+	To    EntryState
+	// Err is the failure that took the entry out of rotation; nil otherwise.
+	Err  error
+	Time time.Time
+}
+
+// WithHealthListener registers fn to be called whenever an entry's
+// EntryState changes. It is called synchronously, outside internal locks,
+// and must not block.
+func WithHealthListener(fn func(Event)) ComposeOption {
+	return func(c *ComposeClient) {
+		c.healthListener = fn
+	}
+}
+
+// Status returns a report of every entry in declaration order.
+func (c *ComposeClient) Status() []EntryStatus {
+	result := make([]EntryStatus, len(c.entries))
+
+	for i, e := range c.entries {
+		result[i] = c.health[i].status()
+		result[i].Index = i
+		result[i].Name = e.Name
+	}
+
+	return result
+}
+
+// Disable takes every entry named name out of rotation at once. Calls and
+// streams in flight are not interrupted.
+func (c *ComposeClient) Disable(name string) error {
+	return c.setAdmin(name, EntryDisabled)
+}
+
+// Drain takes every entry named name out of rotation and reports it
+// EntryDraining until its calls and streams in flight have finished, then
+// EntryDisabled.
+func (c *ComposeClient) Drain(name string) error {
+	return c.setAdmin(name, EntryDraining)
+}
+
+// Enable returns every entry named name to rotation, subject to its circuit
+// breaker.
+func (c *ComposeClient) Enable(name string) error {
+	return c.setAdmin(name, "")
+}
+
+// setAdmin applies an operator override to the entries named name. Entries
+// are matched by ModelEntry.Name, so one call covers every endpoint of a
+// model.
+func (c *ComposeClient) setAdmin(name string, admin EntryState) error {
+	found := false
+
+	for i, e := range c.entries {
+		if e.Name == name {
+			found = true
+
+			c.health[i].setAdmin(admin)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("aimodel/composes: no entry named %q", name)
+	}
+
+	return nil
+}
+
+// notifier returns the transition callback of entry idx, or nil without a
+// listener.
+func (c *ComposeClient) notifier(idx int) func(before, after states, err error, at time.Time) {
+	if c.breakerListener == nil && c.healthListener == nil {
+		return nil
+	}
+
+	model := c.entries[idx].Name
+
+	return func(before, after states, err error, at time.Time) {
+		if c.breakerListener != nil && before.breaker != after.breaker {
+			c.breakerListener(BreakerEvent{Index: idx, Model: model, From: before.breaker, To: after.breaker, Err: err, Time: at})
+		}
+
+		if c.healthListener != nil && before.entry != after.entry {
+			c.healthListener(Event{Index: idx, Model: model, From: before.entry, To: after.entry, Err: err, Time: at})
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestStatus_ReportsEntries verifies the counters, states and next probe
+// time reported by Status.
+func TestStatus_ReportsEntries(t *testing.T) {
+	down := &ais.APIError{StatusCode: 503}
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &errCompleter{err: down}},
+		{Name: "m2", Client: &okCompleter{}},
+	}, WithRecoveryInterval(time.Minute))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc.nowFunc = func() time.Time { return now }
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	st := cc.Status()
+
+	m1 := st[0]
+	if m1.Name != "m1" || m1.State != EntryError || m1.Breaker != BreakerOpen ||
+		!errors.Is(m1.LastError, down) || m1.ErrorCount != 1 || !m1.ErrorTime.Equal(now) ||
+		!m1.NextProbe.Equal(now.Add(time.Minute)) || m1.Attempts != 1 || m1.Failures != 1 {
+		t.Errorf("m1 = %+v", m1)
+	}
+
+	m2 := st[1]
+	if m2.Index != 1 || m2.State != EntryActive || m2.Attempts != 1 || m2.Successes != 1 ||
+		m2.InFlight != 0 || !m2.NextProbe.IsZero() {
+		t.Errorf("m2 = %+v", m2)
+	}
+}
+
+// TestHealthListener_RotationChanges verifies that the listener sees an
+// entry leave and rejoin the rotation, but not the breaker's half-open step.
+func TestHealthListener_RotationChanges(t *testing.T) {
+	flaky := &errCompleter{err: &ais.APIError{StatusCode: 500}}
+
+	var events []Event
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "flaky", Client: flaky},
+		{Name: "backup", Client: &okCompleter{}},
+	}, WithRecoveryInterval(time.Second), WithHealthListener(func(e Event) {
+		events = append(events, e)
+	}))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc.nowFunc = func() time.Time { return now }
+
+	_, _ = cc.ChatCompletion(context.Background(), testRequest())
+
+	// The trial after the open timeout succeeds.
+	now = now.Add(time.Second)
+	flaky.err = nil
+	_, _ = cc.ChatCompletion(context.Background(), testRequest())
+
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want two", events)
+	}
+
+	if e := events[0]; e.Model != "flaky" || e.From != EntryActive || e.To != EntryError || e.Err == nil {
+		t.Errorf("first event = %+v, want flaky active → error with the cause", e)
+	}
+
+	if e := events[1]; e.From != EntryError || e.To != EntryActive || !e.Time.Equal(now) {
+		t.Errorf("second event = %+v, want error → active at the trial", e)
+	}
+}
+
+// TestDisableEnable verifies that a disabled entry gets no traffic until it
+// is enabled again.
+func TestDisableEnable(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &okCompleter{}},
+		{Name: "m2", Client: &okCompleter{}},
+	})
+
+	if err := cc.Disable("m1"); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "m2" {
+		t.Fatalf("got %v, %v; want m2 while m1 is disabled", resp, err)
+	}
+
+	if st := cc.Status()[0].State; st != EntryDisabled {
+		t.Fatalf("state = %s, want disabled", st)
+	}
+
+	if err := cc.Enable("m1"); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	resp, err = cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "m1" {
+		t.Fatalf("got %v, %v; want m1 once enabled", resp, err)
+	}
+
+	if err := cc.Disable("nope"); err == nil {
+		t.Fatal("expected an error for an unknown entry")
+	}
+}
+
+// TestDrain_WaitsForInFlight verifies that a drained entry takes no new
+// requests and turns disabled when its open stream ends.
+func TestDrain_WaitsForInFlight(t *testing.T) {
+	var events []Event
+
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &scriptedCompleter{deltas: []string{"hi"}}},
+		{Name: "m2", Client: &okCompleter{}},
+	}, WithHealthListener(func(e Event) { events = append(events, e) }))
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	if err := cc.Drain("m1"); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	if st := cc.Status()[0]; st.State != EntryDraining || st.InFlight != 1 {
+		t.Fatalf("status = %+v, want draining with the stream in flight", st)
+	}
+
+	resp, err := cc.ChatCompletion(context.Background(), testRequest())
+	if err != nil || resp.Model != "m2" {
+		t.Fatalf("got %v, %v; want m2 while m1 drains", resp, err)
+	}
+
+	if _, err := drain(s); err != nil {
+		t.Fatalf("drain stream: %v", err)
+	}
+
+	if st := cc.Status()[0].State; st != EntryDisabled {
+		t.Fatalf("state = %s, want disabled once the stream ended", st)
+	}
+
+	want := []EntryState{EntryDraining, EntryDisabled}
+	if len(events) != len(want) || events[0].To != want[0] || events[1].To != want[1] {
+		t.Fatalf("events = %+v, want transitions to %v", events, want)
+	}
+}
@@ -17,9 +17,100 @@
 
 package composes
 
-import "math/rand"
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"time"
 
-// Strategy determines how models are selected for requests.
+	"github.com/vogo/aimodel/ais"
+)
+
+// Selector decides the order in which a request's candidate entries are
+// tried. The dispatch loop attempts them in turn until one succeeds, so a
+// selector only ranks; failover applies uniformly to every policy. Returned
+// indices refer to Snapshot.Entries; out-of-range and repeated indices are
+// ignored, and an empty result makes the request fail with ErrNoActiveModels
+// unless a circuit breaker trial is due. An entry whose breaker rejects the
+// attempt is skipped at dispatch. Select may be called concurrently.
+type Selector interface {
+	Select(s *Snapshot) []int
+}
+
+// SelectorFunc adapts an ordinary function to the Selector interface.
+type SelectorFunc func(s *Snapshot) []int
+
+// Select calls f(s).
+func (f SelectorFunc) Select(s *Snapshot) []int {
+	return f(s)
+}
+
+// Snapshot is the read-only view a Selector ranks: the request and the state
+// of every entry at dispatch time, in declaration order. It must not be
+// modified or retained past Select.
+type Snapshot struct {
+	// Request is the caller's request, before the entry's model override.
+	Request *ais.ChatRequest
+	Entries []EntrySnapshot
+
+	rng func(n int) int
+}
+
+// EntrySnapshot is the state of one entry at dispatch time.
+type EntrySnapshot struct {
+	// Name is the entry's model name; empty when the client default is used.
+	Name string
+	// Weight is the entry's weight, with values <= 0 normalized to 1.
+	Weight int
+	// Healthy reports whether the entry's circuit breaker is closed. The
+	// built-in selectors return healthy entries only; trials for the others
+	// are added by the dispatch loop.
+	Healthy bool
+	// Capable reports whether the entry's Capabilities cover the request.
+	// Dispatch drops the incapable entries a selector returns.
+	Capable bool
+	// State is the entry's circuit breaker state.
+	State BreakerState
+	// ErrorCount is the number of consecutive errors, reset on success.
+	ErrorCount int
+	// LastError is the error that last marked the entry unhealthy.
+	LastError error
+	// Latency is an exponentially weighted moving average of the entry's
+	// successful attempts (for a stream, until it opened); zero before the
+	// first success.
+	Latency time.Duration
+	// TTFT is the moving average of the time to the first content chunk of
+	// the entry's streams, measured from the open attempt.
+	TTFT time.Duration
+	// InFlight is the number of the entry's calls and open streams.
+	InFlight int
+	// ErrorRate is the failed share of the entry's recent attempts, in [0, 1].
+	ErrorRate float64
+}
+
+// Intn returns a pseudo-random number in [0, n) from the client's source,
+// for selectors that randomize. It panics if n <= 0.
+func (s *Snapshot) Intn(n int) int {
+	return s.rng(n)
+}
+
+// Healthy returns the indices of the healthy entries capable of serving the
+// request, in declaration order.
+func (s *Snapshot) Healthy() []int {
+	result := make([]int, 0, len(s.Entries))
+
+	for i, e := range s.Entries {
+		if e.Healthy && e.Capable {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+// Strategy names a built-in selector. Every Strategy is a Selector, so the
+// constants can be passed to NewComposeClient directly; an unknown value
+// selects like StrategyFailover.
 type Strategy string
 
 const (
@@ -29,93 +120,111 @@ const (
 	StrategyRandom Strategy = "random"
 	// StrategyWeight selects models based on their weight proportionally.
 	StrategyWeight Strategy = "weighted"
+	// StrategyP2C compares two random active models and puts the one with the
+	// lower latency-weighted load first.
+	StrategyP2C Strategy = "p2c"
+	// StrategyLeastInFlight selects active models by ascending in-flight count.
+	StrategyLeastInFlight Strategy = "least_in_flight"
 )
 
-// selectModels returns an ordered list of model indices to try.
-// The caller iterates and attempts each until one succeeds.
-func (c *ComposeClient) selectModels() []int {
-	switch c.strategy {
+// Select ranks the entries with the built-in selector st names.
+func (st Strategy) Select(s *Snapshot) []int {
+	switch st {
 	case StrategyRandom:
-		return c.selectRandom()
+		return selectRandom(s)
 	case StrategyWeight:
-		return c.selectWeighted()
+		return selectWeighted(s)
+	case StrategyP2C:
+		return selectP2C(s)
+	case StrategyLeastInFlight:
+		return selectLeastInFlight(s)
 	default:
-		return c.selectFailover()
+		return selectFailover(s)
 	}
 }
 
-// selectFailover returns indices in definition order, skipping error models.
-func (c *ComposeClient) selectFailover() []int {
-	result := make([]int, 0, len(c.entries))
+// selectModels returns an ordered list of model indices to try for req,
+// keeping the eligible entries only; nil eligible admits every entry. The
+// caller iterates and attempts each until one succeeds.
+func (c *ComposeClient) selectModels(req *ais.ChatRequest, eligible []bool) []int {
+	snap := &Snapshot{
+		Request: req,
+		Entries: make([]EntrySnapshot, len(c.entries)),
+		rng:     c.intn,
+	}
 
-	for i := range c.entries {
-		if c.health[i].isActive() {
-			result = append(result, i)
-		}
+	for i, e := range c.entries {
+		snap.Entries[i] = c.health[i].snapshot()
+		snap.Entries[i].Name = e.Name
+		snap.Entries[i].Weight = max(e.Weight, 1)
+		snap.Entries[i].Capable = eligible == nil || eligible[i]
 	}
 
-	return result
-}
+	selector := c.selector
+	if selector == nil {
+		selector = StrategyFailover
+	}
 
-// selectRandom returns a shuffled list of active model indices.
-func (c *ComposeClient) selectRandom() []int {
-	active := make([]int, 0, len(c.entries))
+	// Keep the valid, capable, first-seen indices only.
+	order := selector.Select(snap)
+	seen := make([]bool, len(c.entries))
+	result := make([]int, 0, len(order))
 
-	for i := range c.entries {
-		if c.health[i].isActive() {
-			active = append(active, i)
+	for _, idx := range order {
+		if idx >= 0 && idx < len(seen) && !seen[idx] && snap.Entries[idx].Capable {
+			seen[idx] = true
+			result = append(result, idx)
 		}
 	}
 
+	return result
+}
+
+// intn draws from the client's random source under its lock.
+func (c *ComposeClient) intn(n int) int {
 	c.mu.Lock()
-	c.rng.Shuffle(len(active), func(i, j int) {
-		active[i], active[j] = active[j], active[i]
-	})
-	c.mu.Unlock()
+	defer c.mu.Unlock()
 
-	return active
+	return c.rng.Intn(n)
 }
 
-// selectWeighted selects from active models proportional to their weights.
-// Returns a full ordering: pick one by weight, then repeat with remaining.
-func (c *ComposeClient) selectWeighted() []int {
-	type candidate struct {
-		idx    int
-		weight int
-	}
-
-	candidates := make([]candidate, 0, len(c.entries))
+// selectFailover returns indices in definition order, skipping error models.
+func selectFailover(s *Snapshot) []int {
+	return s.Healthy()
+}
 
-	for i := range c.entries {
-		if c.health[i].isActive() {
-			w := c.entries[i].Weight
-			if w <= 0 {
-				w = 1
-			}
+// selectRandom returns a shuffled list of active model indices.
+func selectRandom(s *Snapshot) []int {
+	active := s.Healthy()
 
-			candidates = append(candidates, candidate{idx: i, weight: w})
-		}
+	for i := len(active) - 1; i > 0; i-- {
+		j := s.Intn(i + 1)
+		active[i], active[j] = active[j], active[i]
 	}
 
-	result := make([]int, 0, len(candidates))
+	return active
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// selectWeighted selects from active models proportional to their weights.
+// Returns a full ordering: pick one by weight, then repeat with remaining.
+func selectWeighted(s *Snapshot) []int {
+	candidates := s.Healthy()
+	result := make([]int, 0, len(candidates))
 
 	for len(candidates) > 0 {
 		total := 0
-		for _, cand := range candidates {
-			total += cand.weight
+		for _, idx := range candidates {
+			total += s.Entries[idx].Weight
 		}
 
-		r := c.rng.Intn(total)
+		r := s.Intn(total)
 		cumulative := 0
 
-		for j, cand := range candidates {
-			cumulative += cand.weight
+		for j, idx := range candidates {
+			cumulative += s.Entries[idx].Weight
 
 			if r < cumulative {
-				result = append(result, cand.idx)
+				result = append(result, idx)
 				candidates = append(candidates[:j], candidates[j+1:]...)
 
 				break
@@ -126,6 +235,58 @@ func (c *ComposeClient) selectWeighted() []int {
 	return result
 }
 
+// selectP2C applies the power of two random choices: of two random active
+// models, the one with the lower load score goes first, then the other, then
+// the rest by ascending score. Sampling two instead of taking the global
+// minimum keeps concurrent requests from herding onto one entry between
+// measurements.
+func selectP2C(s *Snapshot) []int {
+	active := s.Healthy()
+	if len(active) < 2 {
+		return active
+	}
+
+	i := s.Intn(len(active))
+
+	j := s.Intn(len(active) - 1)
+	if j >= i {
+		j++
+	}
+
+	if load(s.Entries[active[j]]) < load(s.Entries[active[i]]) {
+		i, j = j, i
+	}
+
+	first, second := active[i], active[j]
+	rest := slices.DeleteFunc(active, func(idx int) bool { return idx == first || idx == second })
+
+	slices.SortStableFunc(rest, func(a, b int) int {
+		return cmp.Compare(load(s.Entries[a]), load(s.Entries[b]))
+	})
+
+	return append([]int{first, second}, rest...)
+}
+
+// load is the P2C score of an entry: its average latency scaled by the
+// requests it would be serving with this one, inflated by its recent error
+// rate. An entry without a latency sample scores 0, so it is tried and
+// measured first.
+func load(e EntrySnapshot) float64 {
+	return float64(e.Latency) * float64(e.InFlight+1) / max(1-e.ErrorRate, 0.05)
+}
+
+// selectLeastInFlight returns active model indices by ascending in-flight
+// count, ties in definition order.
+func selectLeastInFlight(s *Snapshot) []int {
+	active := s.Healthy()
+
+	slices.SortStableFunc(active, func(a, b int) int {
+		return cmp.Compare(s.Entries[a].InFlight, s.Entries[b].InFlight)
+	})
+
+	return active
+}
+
 // randSource returns a new deterministic rand for testing or real rand.
 func newRand(seed int64) *rand.Rand {
 	return rand.New(rand.NewSource(seed))
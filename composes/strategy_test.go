@@ -18,13 +18,16 @@
 package composes
 
 import (
+	"context"
 	"errors"
 	"math"
 	"testing"
 	"time"
+
+	"github.com/vogo/aimodel/ais"
 )
 
-func newTestComposeClient(strategy Strategy, entries []ModelEntry) *ComposeClient {
+func newTestComposeClient(selector Selector, entries []ModelEntry) *ComposeClient {
 	health := make([]*modelHealth, len(entries))
 	for i := range health {
 		health[i] = newModelHealth()
@@ -33,19 +36,36 @@ func newTestComposeClient(strategy Strategy, entries []ModelEntry) *ComposeClien
 	return &ComposeClient{
 		entries:          entries,
 		health:           health,
-		strategy:         strategy,
+		selector:         selector,
 		recoveryInterval: defaultRecoveryInterval,
 		nowFunc:          time.Now,
 		rng:              newRand(42),
 	}
 }
 
+func TestStrategy_Constant(t *testing.T) {
+	// The strategies stay string constants usable as selectors.
+	const configured Strategy = StrategyFailover
+
+	c := newTestComposeClient(configured, []ModelEntry{
+		{Name: "m0"}, {Name: "m1"},
+	})
+	assertIntSlice(t, c.selectModels(testRequest(), nil), []int{0, 1})
+
+	c.selector = Strategy("unknown")
+	assertIntSlice(t, c.selectModels(testRequest(), nil), []int{0, 1})
+
+	if string(StrategyWeight) != "weighted" {
+		t.Errorf("StrategyWeight = %q", StrategyWeight)
+	}
+}
+
 func TestSelectFailover_AllActive(t *testing.T) {
 	c := newTestComposeClient(StrategyFailover, []ModelEntry{
 		{Name: "m0"}, {Name: "m1"}, {Name: "m2"},
 	})
 
-	got := c.selectModels()
+	got := c.selectModels(testRequest(), nil)
 	want := []int{0, 1, 2}
 
 	assertIntSlice(t, got, want)
@@ -57,7 +77,7 @@ func TestSelectFailover_SkipError(t *testing.T) {
 	})
 	c.health[1].markError(errors.New("fail"), time.Now())
 
-	got := c.selectModels()
+	got := c.selectModels(testRequest(), nil)
 	want := []int{0, 2}
 
 	assertIntSlice(t, got, want)
@@ -71,7 +91,7 @@ func TestSelectFailover_AllError(t *testing.T) {
 	c.health[0].markError(errors.New("fail"), now)
 	c.health[1].markError(errors.New("fail"), now)
 
-	got := c.selectModels()
+	got := c.selectModels(testRequest(), nil)
 	if len(got) != 0 {
 		t.Fatalf("expected empty list, got %v", got)
 	}
@@ -82,7 +102,7 @@ func TestSelectRandom_AllActive(t *testing.T) {
 		{Name: "m0"}, {Name: "m1"}, {Name: "m2"},
 	})
 
-	got := c.selectModels()
+	got := c.selectModels(testRequest(), nil)
 	if len(got) != 3 {
 		t.Fatalf("expected 3 indices, got %d", len(got))
 	}
@@ -106,7 +126,7 @@ func TestSelectRandom_SkipError(t *testing.T) {
 	})
 	c.health[0].markError(errors.New("fail"), time.Now())
 
-	got := c.selectModels()
+	got := c.selectModels(testRequest(), nil)
 	if len(got) != 2 {
 		t.Fatalf("expected 2 indices, got %d", len(got))
 	}
@@ -128,7 +148,7 @@ func TestSelectRandom_Distribution(t *testing.T) {
 	iterations := 3000
 
 	for range iterations {
-		got := c.selectModels()
+		got := c.selectModels(testRequest(), nil)
 		counts[got[0]]++
 	}
 
@@ -153,7 +173,7 @@ func TestSelectWeighted_ProportionalDistribution(t *testing.T) {
 	iterations := 4000
 
 	for range iterations {
-		got := c.selectModels()
+		got := c.selectModels(testRequest(), nil)
 		counts[got[0]]++
 	}
 
@@ -174,7 +194,7 @@ func TestSelectWeighted_ZeroWeightTreatedAsOne(t *testing.T) {
 	iterations := 2000
 
 	for range iterations {
-		got := c.selectModels()
+		got := c.selectModels(testRequest(), nil)
 		counts[got[0]]++
 	}
 
@@ -192,12 +212,169 @@ func TestSelectWeighted_SkipError(t *testing.T) {
 	})
 	c.health[0].markError(errors.New("fail"), time.Now())
 
-	got := c.selectModels()
+	got := c.selectModels(testRequest(), nil)
 	if len(got) != 1 || got[0] != 1 {
 		t.Fatalf("expected [1], got %v", got)
 	}
 }
 
+// TestSelectModels_SanitizesOrder verifies that out-of-range and repeated
+// indices from a custom selector are dropped.
+func TestSelectModels_SanitizesOrder(t *testing.T) {
+	c := newTestComposeClient(SelectorFunc(func(*Snapshot) []int {
+		return []int{5, 1, 1, -1, 0}
+	}), []ModelEntry{{Name: "m0"}, {Name: "m1"}})
+
+	assertIntSlice(t, c.selectModels(testRequest(), nil), []int{1, 0})
+}
+
+// TestSelector_Snapshot verifies that a custom selector sees the request and
+// the entries' weights, health and latency, and that its order is dispatched.
+func TestSelector_Snapshot(t *testing.T) {
+	long := &scriptedCompleter{}
+	short := &errCompleter{err: errors.New("unused")}
+
+	var last *Snapshot
+
+	// Route long prompts to the large-context entry, the rest in order.
+	byLength := SelectorFunc(func(s *Snapshot) []int {
+		last = s
+
+		if len(s.Request.Messages[0].Content.Text()) > 10 {
+			return []int{1, 0}
+		}
+
+		return s.Healthy()
+	})
+
+	cc, _ := NewComposeClient(byLength, []ModelEntry{
+		{Name: "small", Client: short, Weight: 0},
+		{Name: "large", Client: long, Weight: 3},
+	})
+
+	req := &ais.ChatRequest{Messages: []ais.Message{{
+		Role:    ais.RoleUser,
+		Content: ais.NewTextContent("a rather long prompt"),
+	}}}
+
+	s, err := cc.ChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	_ = s.Close()
+
+	if long.opens != 1 || short.calls != 0 {
+		t.Fatalf("large opens = %d, small calls = %d; want 1, 0", long.opens, short.calls)
+	}
+
+	if last.Request != req || last.Entries[0].Weight != 1 || last.Entries[1].Weight != 3 {
+		t.Errorf("snapshot = %+v, want the request and normalized weights", last)
+	}
+
+	cc.health[0].markError(errors.New("fail"), time.Unix(0, 0))
+	cc.health[1] = newModelHealth()
+	cc.health[1].observeLatency(250 * time.Millisecond)
+
+	cc.selectModels(req, nil)
+
+	if got := last.Entries[0]; got.Healthy || got.ErrorCount != 1 || got.LastError == nil {
+		t.Errorf("small = %+v, want unhealthy with one error", got)
+	}
+
+	if got := last.Entries[1]; !got.Healthy || got.Latency != 250*time.Millisecond {
+		t.Errorf("large = %+v, want healthy with 250ms latency", got)
+	}
+}
+
+func TestSelectLeastInFlight(t *testing.T) {
+	c := newTestComposeClient(StrategyLeastInFlight, []ModelEntry{
+		{Name: "m0"}, {Name: "m1"}, {Name: "m2"},
+	})
+	c.health[0].begin(time.Now())
+	c.health[0].begin(time.Now())
+	c.health[1].begin(time.Now())
+
+	assertIntSlice(t, c.selectModels(testRequest(), nil), []int{2, 1, 0})
+}
+
+// TestSelectP2C_AvoidsSlowEntry verifies that the slowest entry loses every
+// pairing and so is never tried first.
+func TestSelectP2C_AvoidsSlowEntry(t *testing.T) {
+	c := newTestComposeClient(StrategyP2C, []ModelEntry{
+		{Name: "eu"}, {Name: "us"}, {Name: "ap"},
+	})
+	c.health[0].observeLatency(40 * time.Millisecond)
+	c.health[1].observeLatency(50 * time.Millisecond)
+	c.health[2].observeLatency(900 * time.Millisecond)
+
+	counts := make(map[int]int)
+
+	for range 300 {
+		got := c.selectModels(testRequest(), nil)
+		if len(got) != 3 {
+			t.Fatalf("expected a full ordering, got %v", got)
+		}
+
+		counts[got[0]]++
+	}
+
+	if counts[2] != 0 {
+		t.Fatalf("slow entry was first %d times", counts[2])
+	}
+
+	// Load counts too: a busy fast entry loses to an idle one.
+	for range 20 {
+		c.health[0].begin(time.Now())
+	}
+
+	if got := c.selectModels(testRequest(), nil); got[0] == 0 {
+		t.Fatalf("busy entry first in %v", got)
+	}
+}
+
+// TestInFlight_HeldUntilStreamEnds verifies in-flight and TTFT accounting
+// for streams and in-flight release for unary calls.
+func TestInFlight_HeldUntilStreamEnds(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &scriptedCompleter{deltas: []string{"hi"}}},
+	})
+
+	// Each clock reading advances 10ms.
+	var tick time.Duration
+
+	cc.nowFunc = func() time.Time {
+		tick += 10 * time.Millisecond
+
+		return time.Unix(0, 0).Add(tick)
+	}
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	if got := cc.health[0].snapshot().InFlight; got != 1 {
+		t.Fatalf("in flight while streaming = %d, want 1", got)
+	}
+
+	if _, err := drain(s); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+
+	if got := cc.health[0].snapshot(); got.InFlight != 0 || got.Latency <= 0 || got.TTFT < got.Latency {
+		t.Fatalf("after the stream: %+v, want nothing in flight and TTFT at least the open latency", got)
+	}
+
+	if _, err := cc.ChatCompletion(context.Background(), testRequest()); err == nil {
+		t.Fatal("expected the unscripted unary call to fail")
+	}
+
+	if got := cc.health[0].snapshot().InFlight; got != 0 {
+		t.Fatalf("in flight after a failed call = %d, want 0", got)
+	}
+}
+
 func assertIntSlice(t *testing.T, got, want []int) {
 	t.Helper()
 
@@ -0,0 +1,466 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// StreamFailover selects how ChatCompletionStream reacts to an error returned
+// by Recv after the stream opened. Whatever the mode, the error classifier
+// decides whether such an error marks the serving entry unhealthy, and an
+// ActionAbort verdict returns it to the caller.
+type StreamFailover int
+
+const (
+	// StreamFailoverOff returns the Recv error to the caller. It is the
+	// default.
+	StreamFailoverOff StreamFailover = iota
+	// StreamFailoverBeforeContent switches to the next candidate when the
+	// stream fails before any content reached the caller; after that the
+	// error is returned.
+	StreamFailoverBeforeContent
+	// StreamFailoverReplay switches to the next candidate at any point. The
+	// replacement replays the request from the start and the content the
+	// caller already received is dropped according to the ReplayDedup policy.
+	StreamFailoverReplay
+)
+
+// ReplayDedup decides how StreamFailoverReplay removes the content the caller
+// already received from a replacement stream.
+type ReplayDedup int
+
+const (
+	// DedupByLength drops as many characters (runes) of text, thinking and
+	// tool-call arguments as were delivered, trusting the replacement to
+	// produce the same prefix. It is the default.
+	DedupByLength ReplayDedup = iota
+	// DedupByPrefix drops the delivered prefix only while the replacement
+	// reproduces it exactly; on divergence the stream fails with
+	// ErrReplayDiverged.
+	DedupByPrefix
+)
+
+// ErrReplayDiverged reports that a replacement stream did not reproduce the
+// content already delivered, under DedupByPrefix.
+var ErrReplayDiverged = errors.New("aimodel/composes: replacement stream diverged from delivered content")
+
+// WithStreamFailover enables mid-stream failover for ChatCompletionStream.
+func WithStreamFailover(mode StreamFailover) ComposeOption {
+	return func(c *ComposeClient) {
+		c.streamFailover = mode
+	}
+}
+
+// WithReplayDedup sets the deduplication policy of StreamFailoverReplay.
+func WithReplayDedup(dedup ReplayDedup) ComposeOption {
+	return func(c *ComposeClient) {
+		c.replayDedup = dedup
+	}
+}
+
+// failoverStream is the chunk source behind a compose stream. It feeds Recv
+// errors into the serving entry's health, holds the entry's in-flight slot
+// until the stream ends and, when enabled, resumes the candidate list with a
+// replacement stream.
+type failoverStream struct {
+	c          *ComposeClient
+	ctx        context.Context
+	req        *ais.ChatRequest
+	candidates []int
+	pos        int // position of the serving entry in candidates
+	errs       []ais.ModelError
+	outer      *aimodel.Stream
+
+	mu     sync.Mutex // guards cur, idx, held and closed against a concurrent Close
+	cur    *aimodel.Stream
+	idx    int  // entry index of cur
+	held   bool // whether cur still holds its entry's in-flight slot
+	closed bool
+
+	// contentSent reports whether any content reached the caller; delivered
+	// accumulates it per choice for replay. replay accumulates the raw
+	// replacement stream per choice; nil until a failover after content.
+	contentSent bool
+	delivered   map[int]*ais.Message
+	replay      map[int]*ais.Message
+}
+
+// newFailoverStream opens a stream on the first candidate that accepts req
+// and wraps it.
+func (c *ComposeClient) newFailoverStream(ctx context.Context, req *ais.ChatRequest, candidates []int) (*aimodel.Stream, error) {
+	f := &failoverStream{
+		c:          c,
+		ctx:        ctx,
+		req:        req,
+		candidates: candidates,
+		pos:        -1,
+		delivered:  map[int]*ais.Message{},
+	}
+
+	if err := f.dispatch(); err != nil {
+		return nil, err
+	}
+
+	f.outer = aimodel.NewStream(f.recv, f.close)
+	f.outer.SetMeta(f.cur.Meta())
+
+	return f.outer, nil
+}
+
+// dispatch opens the first candidate after the serving one that accepts the
+// request and makes it current.
+func (f *failoverStream) dispatch() error {
+	next, pos, errs, err := dispatch(f.ctx, f.c, f.req, f.candidates[f.pos+1:], f.errs, f.c.openStream, settleStream, true)
+	f.errs = errs
+
+	if err != nil {
+		return err
+	}
+
+	f.pos += 1 + pos
+	idx := f.candidates[f.pos]
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		f.c.health[idx].end()
+		_ = next.Close()
+
+		return ais.ErrStreamClosed
+	}
+
+	f.cur, f.idx, f.held = next, idx, true
+
+	return nil
+}
+
+// current returns the serving stream, or nil once closed.
+func (f *failoverStream) current() *aimodel.Stream {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
+	return f.cur
+}
+
+// release gives back the serving entry's in-flight slot, once.
+func (f *failoverStream) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.releaseLocked()
+}
+
+func (f *failoverStream) releaseLocked() {
+	if f.held {
+		f.held = false
+		f.c.health[f.idx].end()
+	}
+}
+
+func (f *failoverStream) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	f.releaseLocked()
+
+	return f.cur.Close()
+}
+
+func (f *failoverStream) recv() (*ais.StreamChunk, error) {
+	for {
+		cur := f.current()
+		if cur == nil {
+			return nil, ais.ErrStreamClosed
+		}
+
+		chunk, err := cur.Recv()
+		if err == nil {
+			if chunk == nil {
+				return nil, nil
+			}
+
+			if f.replay != nil {
+				if chunk, err = f.dedup(chunk); err != nil {
+					f.release()
+
+					return nil, err
+				}
+
+				if chunk == nil {
+					continue
+				}
+			}
+
+			f.track(chunk)
+
+			return chunk, nil
+		}
+
+		f.release()
+
+		// The end of the stream, a caller cancellation and the caller's own
+		// Close are not the entry's fault.
+		if errors.Is(err, io.EOF) || f.ctx.Err() != nil || f.current() == nil {
+			return nil, err
+		}
+
+		idx := f.candidates[f.pos]
+
+		class := f.c.classify(err)
+		if class.MarkUnhealthy {
+			f.c.health[idx].markError(err, f.c.nowFunc())
+		}
+
+		if class.Action == ActionAbort || !f.canFailover() {
+			return nil, err
+		}
+
+		f.errs = append(f.errs, ais.ModelError{Model: f.c.entries[idx].Name, Err: err})
+
+		if err := f.failover(cur); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// canFailover reports whether the configured mode allows switching now.
+func (f *failoverStream) canFailover() bool {
+	switch f.c.streamFailover {
+	case StreamFailoverBeforeContent:
+		return !f.contentSent
+	case StreamFailoverReplay:
+		return true
+	default:
+		return false
+	}
+}
+
+// failover replaces the failed stream old with the next candidate.
+func (f *failoverStream) failover(old *aimodel.Stream) error {
+	_ = old.Close()
+
+	if err := f.dispatch(); err != nil {
+		return err
+	}
+
+	f.outer.SetMeta(f.cur.Meta())
+
+	if f.contentSent {
+		f.replay = map[int]*ais.Message{}
+	}
+
+	return nil
+}
+
+// track records the content of a chunk delivered to the caller.
+func (f *failoverStream) track(chunk *ais.StreamChunk) {
+	if f.c.streamFailover == StreamFailoverOff {
+		return
+	}
+
+	for i := range chunk.Choices {
+		d := &chunk.Choices[i].Delta
+		if !hasContent(d) {
+			continue
+		}
+
+		f.contentSent = true
+
+		if f.c.streamFailover == StreamFailoverReplay {
+			messageFor(f.delivered, chunk.Choices[i].Index).AppendDelta(d)
+		}
+	}
+}
+
+// dedup rewrites a replacement chunk so only content beyond what was
+// delivered remains. It returns nil when nothing is left to deliver.
+func (f *failoverStream) dedup(chunk *ais.StreamChunk) (*ais.StreamChunk, error) {
+	out := *chunk
+	out.Choices = nil
+
+	for _, cc := range chunk.Choices {
+		raw := messageFor(f.replay, cc.Index)
+		sent := messageFor(f.delivered, cc.Index)
+		caughtUp := caughtUp(raw, sent)
+
+		delta, err := f.dedupDelta(raw, sent, &cc.Delta)
+		if err != nil {
+			return nil, err
+		}
+
+		raw.AppendDelta(&cc.Delta)
+
+		if !caughtUp {
+			delta.Extensions = nil
+		}
+
+		cc.Delta = delta
+		if hasContent(&cc.Delta) || cc.Delta.Role != "" || cc.FinishReason != nil || len(cc.Extensions) > 0 {
+			out.Choices = append(out.Choices, cc)
+		}
+	}
+
+	if len(out.Choices) == 0 && out.Usage == nil && len(out.Extensions) == 0 {
+		return nil, nil
+	}
+
+	return &out, nil
+}
+
+// dedupDelta returns the part of delta d beyond the delivered message sent,
+// given the replacement's progress raw before d.
+func (f *failoverStream) dedupDelta(raw, sent *ais.Message, d *ais.Message) (ais.Message, error) {
+	out := ais.Message{Extensions: d.Extensions}
+
+	if sent.Role == "" {
+		out.Role = d.Role
+	}
+
+	text, err := f.beyond(raw.Content.Text(), sent.Content.Text(), d.Content.Text())
+	if err != nil {
+		return out, err
+	}
+
+	out.Content = ais.NewTextContent(text)
+
+	if out.Thinking, err = f.beyond(raw.Thinking, sent.Thinking, d.Thinking); err != nil {
+		return out, err
+	}
+
+	for _, tc := range d.ToolCalls {
+		if tc.Index >= len(sent.ToolCalls) {
+			out.ToolCalls = append(out.ToolCalls, tc)
+
+			continue
+		}
+
+		var rawArgs string
+		if tc.Index < len(raw.ToolCalls) {
+			rawArgs = raw.ToolCalls[tc.Index].Function.Arguments
+		}
+
+		prev := sent.ToolCalls[tc.Index]
+
+		args, err := f.beyond(rawArgs, prev.Function.Arguments, tc.Function.Arguments)
+		if err != nil {
+			return out, err
+		}
+
+		kept := ais.ToolCall{Index: tc.Index, Function: ais.FunctionCall{Arguments: args}}
+		if prev.ID == "" {
+			kept.ID = tc.ID
+		}
+
+		if prev.Type == "" {
+			kept.Type = tc.Type
+		}
+
+		if prev.Function.Name == "" {
+			kept.Function.Name = tc.Function.Name
+		}
+
+		if kept.ID != "" || kept.Type != "" || kept.Function.Name != "" || args != "" {
+			out.ToolCalls = append(out.ToolCalls, kept)
+		}
+	}
+
+	return out, nil
+}
+
+// beyond returns the part of delta, which follows the replacement's prior
+// text raw, that lies past the delivered text sent. Under DedupByPrefix the
+// overlapping part must equal what was delivered; under DedupByLength the
+// overlap is counted in runes, so the cut never splits a character.
+func (f *failoverStream) beyond(raw, sent, delta string) (string, error) {
+	if f.c.replayDedup == DedupByPrefix {
+		start := len(raw)
+		skip := min(max(len(sent)-start, 0), len(delta))
+
+		if skip > 0 && delta[:skip] != sent[start:start+skip] {
+			return "", ErrReplayDiverged
+		}
+
+		return delta[skip:], nil
+	}
+
+	skip := utf8.RuneCountInString(sent) - utf8.RuneCountInString(raw)
+
+	for i := range delta {
+		if skip <= 0 {
+			return delta[i:], nil
+		}
+
+		skip--
+	}
+
+	return "", nil
+}
+
+// caughtUp reports whether the replacement has produced at least everything
+// that was delivered, after which its extensions are new information.
+func caughtUp(raw, sent *ais.Message) bool {
+	if shorter(raw.Content.Text(), sent.Content.Text()) || shorter(raw.Thinking, sent.Thinking) ||
+		len(raw.ToolCalls) < len(sent.ToolCalls) {
+		return false
+	}
+
+	for i, tc := range sent.ToolCalls {
+		if shorter(raw.ToolCalls[i].Function.Arguments, tc.Function.Arguments) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// shorter reports whether a has fewer runes than b, the unit DedupByLength
+// counts in.
+func shorter(a, b string) bool {
+	return utf8.RuneCountInString(a) < utf8.RuneCountInString(b)
+}
+
+// hasContent reports whether a delta carries anything the caller consumes as
+// content: text, thinking, tool calls or provider message extensions.
+func hasContent(d *ais.Message) bool {
+	return d.Content.Text() != "" || d.Thinking != "" || len(d.ToolCalls) > 0 || len(d.Extensions) > 0
+}
+
+// messageFor returns the message accumulated for a choice index, creating it.
+func messageFor(m map[int]*ais.Message, index int) *ais.Message {
+	msg, ok := m[index]
+	if !ok {
+		msg = &ais.Message{}
+		m[index] = msg
+	}
+
+	return msg
+}
@@ -0,0 +1,219 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// scriptedCompleter streams text deltas and then fails with err, or ends
+// cleanly when err is nil.
+type scriptedCompleter struct {
+	deltas []string
+	err    error
+	opens  int
+}
+
+func (s *scriptedCompleter) ChatCompletion(context.Context, *ais.ChatRequest) (*ais.ChatResponse, error) {
+	return nil, errors.New("unary not scripted")
+}
+
+func (s *scriptedCompleter) ChatCompletionStream(context.Context, *ais.ChatRequest) (*aimodel.Stream, error) {
+	s.opens++
+	i := 0
+
+	return aimodel.NewStream(func() (*ais.StreamChunk, error) {
+		if i == len(s.deltas) {
+			if s.err != nil {
+				return nil, s.err
+			}
+
+			return nil, io.EOF
+		}
+
+		i++
+
+		return &ais.StreamChunk{Choices: []ais.StreamChunkChoice{{
+			Delta: ais.Message{Role: ais.RoleAssistant, Content: ais.NewTextContent(s.deltas[i-1])},
+		}}}, nil
+	}, nil), nil
+}
+
+// drain reads the stream to its end and returns the text and final error.
+func drain(s *aimodel.Stream) (string, error) {
+	defer s.Close()
+
+	var b strings.Builder
+
+	for text, err := range s.TextDeltas() {
+		if err != nil {
+			return b.String(), err
+		}
+
+		b.WriteString(text)
+	}
+
+	return b.String(), nil
+}
+
+var errMidStream = errors.New("connection reset")
+
+// TestStream_RecvErrorMarksUnhealthy verifies that without failover a Recv
+// error reaches the caller and marks the serving entry unhealthy.
+func TestStream_RecvErrorMarksUnhealthy(t *testing.T) {
+	primary := &scriptedCompleter{deltas: []string{"hel"}, err: errMidStream}
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: primary},
+		{Name: "m2", Client: &scriptedCompleter{deltas: []string{"hello"}}},
+	})
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	text, err := drain(s)
+	if !errors.Is(err, errMidStream) || text != "hel" {
+		t.Fatalf("got %q, %v; want %q, %v", text, err, "hel", errMidStream)
+	}
+
+	if cc.health[0].isActive() {
+		t.Error("m1 should be marked unhealthy by the Recv error")
+	}
+}
+
+// TestStream_FailoverBeforeContent covers both sides of the content boundary.
+func TestStream_FailoverBeforeContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		primary  []string
+		wantText string
+		wantErr  error
+	}{
+		{name: "no content yet", primary: nil, wantText: "hello"},
+		{name: "content delivered", primary: []string{"he"}, wantText: "he", wantErr: errMidStream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+				{Name: "m1", Client: &scriptedCompleter{deltas: tt.primary, err: errMidStream}},
+				{Name: "m2", Client: &scriptedCompleter{deltas: []string{"hel", "lo"}}},
+			}, WithStreamFailover(StreamFailoverBeforeContent))
+
+			s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+			if err != nil {
+				t.Fatalf("ChatCompletionStream: %v", err)
+			}
+
+			text, err := drain(s)
+			if text != tt.wantText || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %q, %v; want %q, %v", text, err, tt.wantText, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestStream_FailoverReplay verifies that a replacement stream's replay of
+// delivered content is dropped under both dedup policies.
+func TestStream_FailoverReplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		dedup    ReplayDedup
+		backup   []string
+		wantText string
+		wantErr  error
+	}{
+		{name: "length", dedup: DedupByLength, backup: []string{"h", "ello wo", "rld"}, wantText: "hello world"},
+		{name: "prefix match", dedup: DedupByPrefix, backup: []string{"hello", " world"}, wantText: "hello world"},
+		{name: "prefix diverged", dedup: DedupByPrefix, backup: []string{"howdy"}, wantText: "hel", wantErr: ErrReplayDiverged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+				{Name: "m1", Client: &scriptedCompleter{deltas: []string{"he", "l"}, err: errMidStream}},
+				{Name: "m2", Client: &scriptedCompleter{deltas: tt.backup}},
+			}, WithStreamFailover(StreamFailoverReplay), WithReplayDedup(tt.dedup))
+
+			s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+			if err != nil {
+				t.Fatalf("ChatCompletionStream: %v", err)
+			}
+
+			text, err := drain(s)
+			if text != tt.wantText || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %q, %v; want %q, %v", text, err, tt.wantText, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestStream_FailoverReplayMultibyte verifies that DedupByLength counts the
+// delivered content in characters, so a replacement never resumes inside a
+// multibyte character.
+func TestStream_FailoverReplayMultibyte(t *testing.T) {
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &scriptedCompleter{deltas: []string{"ab"}, err: errMidStream}},
+		{Name: "m2", Client: &scriptedCompleter{deltas: []string{"日本", "語です"}}},
+	}, WithStreamFailover(StreamFailoverReplay))
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	text, err := drain(s)
+	if err != nil || text != "ab語です" || !utf8.ValidString(text) {
+		t.Fatalf("got %q, %v; want %q", text, err, "ab語です")
+	}
+}
+
+// TestStream_FailoverExhausted verifies that a failover with no candidate
+// left reports every entry's error.
+func TestStream_FailoverExhausted(t *testing.T) {
+	backupErr := errors.New("backup down")
+	cc, _ := NewComposeClient(StrategyFailover, []ModelEntry{
+		{Name: "m1", Client: &scriptedCompleter{err: errMidStream}},
+		{Name: "m2", Client: &scriptedCompleter{err: backupErr}},
+	}, WithStreamFailover(StreamFailoverReplay))
+
+	s, err := cc.ChatCompletionStream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	_, err = drain(s)
+
+	var me *ais.MultiError
+	if !errors.As(err, &me) || len(me.Errors) != 2 {
+		t.Fatalf("err = %v, want MultiError with 2 entries", err)
+	}
+
+	if !errors.Is(err, errMidStream) || !errors.Is(err, backupErr) {
+		t.Errorf("err = %v, want both entry errors", err)
+	}
+}
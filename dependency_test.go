@@ -73,17 +73,19 @@ func hasProviderImport(imports map[string]bool, want string) bool {
 	return false
 }
 
-// TestProvidersAreIndependent verifies the two built-in provider subpackages do
-// not depend on each other — a vendor API change touches only its own package.
+// TestProvidersAreIndependent verifies the provider subpackages do not depend
+// on each other — a vendor API change touches only its own package.
 func TestProvidersAreIndependent(t *testing.T) {
-	openaiImports := packageImports(t, "provider/openai")
-	if hasProviderImport(openaiImports, "anthropic") {
-		t.Error("provider/openai must not import provider/anthropic")
-	}
+	providers := []string{"openai", "openai/responses", "anthropic", "gemini"}
+
+	for _, p := range providers {
+		imports := packageImports(t, "provider/"+p)
 
-	anthropicImports := packageImports(t, "provider/anthropic")
-	if hasProviderImport(anthropicImports, "openai") {
-		t.Error("provider/anthropic must not import provider/openai")
+		for _, other := range providers {
+			if other != p && hasProviderImport(imports, other) {
+				t.Errorf("provider/%s must not import provider/%s", p, other)
+			}
+		}
 	}
 }
 
@@ -91,7 +93,7 @@ func TestProvidersAreIndependent(t *testing.T) {
 // api foundation, never on the root package (which would create a cycle) or on
 // composes.
 func TestProvidersDoNotDependOnRoot(t *testing.T) {
-	for _, dir := range []string{"provider/openai", "provider/anthropic"} {
+	for _, dir := range []string{"provider/openai", "provider/openai/responses", "provider/anthropic", "provider/gemini"} {
 		imports := packageImports(t, dir)
 
 		if imports["github.com/vogo/aimodel"] {
@@ -104,24 +106,27 @@ func TestProvidersDoNotDependOnRoot(t *testing.T) {
 	}
 }
 
-// TestComposesDependsOnlyOnCapability verifies composes depends on the root
-// capability surface plus the canonical api package, never on the registry or
-// any vendor provider.
-func TestComposesDependsOnlyOnCapability(t *testing.T) {
-	imports := packageImports(t, "composes")
+// TestWrappersDependOnlyOnCapability verifies the wrapper packages (composes,
+// retries, quota, pricing) depend on the root capability surface plus the
+// canonical api package, never on any vendor provider. Only composes'
+// configuration loader consults the registry, to validate provider names.
+func TestWrappersDependOnlyOnCapability(t *testing.T) {
+	for _, dir := range []string{"composes", "retries", "quota", "pricing"} {
+		imports := packageImports(t, dir)
 
-	for path := range imports {
-		if strings.Contains(path, "/provider/") {
-			t.Errorf("composes must not import a provider subpackage, found %q", path)
+		for path := range imports {
+			if strings.Contains(path, "/provider/") {
+				t.Errorf("%s must not import a provider subpackage, found %q", dir, path)
+			}
 		}
-	}
 
-	if !imports["github.com/vogo/aimodel"] {
-		t.Error("composes should depend on the root capability interface")
-	}
+		if !imports["github.com/vogo/aimodel"] {
+			t.Errorf("%s should depend on the root capability interface", dir)
+		}
 
-	if !imports["github.com/vogo/aimodel/ais"] {
-		t.Error("composes should take canonical types directly from the ais package")
+		if !imports["github.com/vogo/aimodel/ais"] {
+			t.Errorf("%s should take canonical types directly from the ais package", dir)
+		}
 	}
 }
 
@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+	"github.com/vogo/aimodel/provider/anthropic"
+)
+
+// These tests prove Stream.Collect rebuilds exactly the response the unary
+// call returns, for both built-in providers. Each fixture pairs a unary body
+// with the SSE stream the vendor sends for the same completion.
+
+// newEquivalenceServer answers a streaming request with sse and any other
+// request with unary.
+func newEquivalenceServer(t *testing.T, unary, sse string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(sse))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(unary))
+	}))
+}
+
+// assertUnaryEqualsCollected runs the same request unary and streamed and
+// compares the results, ignoring the HTTP metadata.
+func assertUnaryEqualsCollected(t *testing.T, c *aimodel.Client) {
+	t.Helper()
+
+	req := &ais.ChatRequest{Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("hi")}}}
+
+	unary, err := c.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	stream, err := c.ChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	collected, err := stream.Collect()
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if collected.Meta == nil {
+		t.Error("collected response has no Meta")
+	}
+
+	unary.Meta, collected.Meta = nil, nil
+
+	if !reflect.DeepEqual(unary, collected) {
+		u, _ := json.MarshalIndent(unary, "", "  ")
+		s, _ := json.MarshalIndent(collected, "", "  ")
+		t.Errorf("collected stream differs from unary response\nunary:\n%s\ncollected:\n%s", u, s)
+	}
+}
+
+func TestOpenAIStreamCollectEqualsUnary(t *testing.T) {
+	unary := `{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"gpt-4o","service_tier":"default",
+"choices":[
+ {"index":0,"message":{"role":"assistant","content":"Hello world","reasoning_content":"Think first."},"finish_reason":"stop"},
+ {"index":1,"message":{"role":"assistant","content":null,"tool_calls":[
+  {"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}},
+  {"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]},"finish_reason":"tool_calls"}],
+"usage":{"prompt_tokens":12,"completion_tokens":30,"total_tokens":42,"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":5}}}`
+
+	chunk := func(body string) string {
+		return `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","service_tier":"default",` + body + "}\n\n"
+	}
+
+	sse := chunk(`"choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"Think "},"finish_reason":null},{"index":1,"delta":{"role":"assistant"},"finish_reason":null}]`) +
+		chunk(`"choices":[{"index":0,"delta":{"reasoning_content":"first."},"finish_reason":null}]`) +
+		chunk(`"choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]},"finish_reason":null}]`) +
+		chunk(`"choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null},{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]},"finish_reason":null}]`) +
+		chunk(`"choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}},{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]},"finish_reason":null}]`) +
+		chunk(`"choices":[{"index":0,"delta":{"content":" world"},"finish_reason":"stop"}]`) +
+		chunk(`"choices":[{"index":1,"delta":{},"finish_reason":"tool_calls"}]`) +
+		chunk(`"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":30,"total_tokens":42,"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":5}}`) +
+		"data: [DONE]\n\n"
+
+	srv := newEquivalenceServer(t, unary, sse)
+	defer srv.Close()
+
+	c, err := aimodel.NewClient(aimodel.WithAPIKey("sk-test"), aimodel.WithBaseURL(srv.URL), aimodel.WithDefaultModel("gpt-4o"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	assertUnaryEqualsCollected(t, c)
+}
+
+func TestAnthropicStreamCollectEqualsUnary(t *testing.T) {
+	unary := `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4",
+"container":{"id":"container_1","expires_at":"2026-10-16T13:00:00Z"},
+"content":[
+ {"type":"thinking","thinking":"Let me think.","signature":"sig-1"},
+ {"type":"redacted_thinking","data":"encrypted"},
+ {"type":"text","text":"Checking the weather."},
+ {"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}},
+ {"type":"thinking","thinking":"Second thought.","signature":"sig-2"},
+ {"type":"text","text":"Done."}],
+"stop_reason":"refusal","stop_details":{"type":"refusal","category":"cyber"},
+"usage":{"input_tokens":10,"cache_read_input_tokens":4,"cache_creation_input_tokens":2,"output_tokens":25}}`
+
+	event := func(name, data string) string {
+		return "event: " + name + "\ndata: " + data + "\n\n"
+	}
+
+	sse := strings.Join([]string{
+		event("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[],"container":{"id":"container_1","expires_at":"2026-10-16T13:00:00Z"},"usage":{"input_tokens":10,"cache_read_input_tokens":4,"cache_creation_input_tokens":2,"output_tokens":1}}}`),
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me "}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"think."}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-1"}}`),
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`),
+		event("content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"encrypted"}}`),
+		event("content_block_stop", `{"type":"content_block_stop","index":1}`),
+		event("content_block_start", `{"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`),
+		event("ping", `{"type":"ping"}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"Checking "}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"the weather."}}`),
+		event("content_block_stop", `{"type":"content_block_stop","index":2}`),
+		event("content_block_start", `{"type":"content_block_start","index":3,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":3,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":3,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`),
+		event("content_block_stop", `{"type":"content_block_stop","index":3}`),
+		event("content_block_start", `{"type":"content_block_start","index":4,"content_block":{"type":"thinking","thinking":""}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":4,"delta":{"type":"thinking_delta","thinking":"Second thought."}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":4,"delta":{"type":"signature_delta","signature":"sig-2"}}`),
+		event("content_block_stop", `{"type":"content_block_stop","index":4}`),
+		event("content_block_start", `{"type":"content_block_start","index":5,"content_block":{"type":"text","text":""}}`),
+		event("content_block_delta", `{"type":"content_block_delta","index":5,"delta":{"type":"text_delta","text":"Done."}}`),
+		event("content_block_stop", `{"type":"content_block_stop","index":5}`),
+		event("message_delta", `{"type":"message_delta","delta":{"stop_reason":"refusal","stop_details":{"type":"refusal","category":"cyber"}},"usage":{"output_tokens":25}}`),
+		event("message_stop", `{"type":"message_stop"}`),
+	}, "")
+
+	srv := newEquivalenceServer(t, unary, sse)
+	defer srv.Close()
+
+	c, err := aimodel.NewClient(
+		aimodel.WithAPIKey("sk-ant-test"),
+		aimodel.WithBaseURL(srv.URL),
+		aimodel.WithProvider(anthropic.Name),
+		aimodel.WithDefaultModel("claude-sonnet-4"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	assertUnaryEqualsCollected(t, c)
+}
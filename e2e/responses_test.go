@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+	"github.com/vogo/aimodel/provider/openai/responses"
+)
+
+// These tests exercise the full client pipeline against the OpenAI Responses
+// provider through httptest.
+
+func newResponsesClient(t *testing.T, url string) *aimodel.Client {
+	t.Helper()
+
+	c, err := aimodel.NewClient(
+		aimodel.WithAPIKey("sk-test"),
+		aimodel.WithBaseURL(url),
+		aimodel.WithProvider(responses.Name),
+	)
+	if err != nil {
+		t.Fatalf("aimodel.NewClient: %v", err)
+	}
+
+	return c
+}
+
+func TestResponsesChatCompletionChaining(t *testing.T) {
+	var bodies []map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" {
+			t.Errorf("path = %s, want /responses", r.URL.Path)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"resp_`+strconv.Itoa(len(bodies))+`","object":"response","status":"completed","model":"gpt-5",`+
+			`"output":[{"type":"message","id":"msg","status":"completed","role":"assistant","content":[{"type":"output_text","text":"Hello!"}]}],`+
+			`"usage":{"input_tokens":10,"output_tokens":5,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	c := newResponsesClient(t, srv.URL)
+
+	first, err := c.ChatCompletion(context.Background(), &ais.ChatRequest{
+		Model:    "gpt-5",
+		Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("Hi")}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if first.Choices[0].Message.Content.Text() != "Hello!" || first.Usage.TotalTokens != 15 {
+		t.Errorf("response = %+v", first)
+	}
+
+	// Chain the second turn onto the stored first response.
+	next := &ais.ChatRequest{
+		Model:    "gpt-5",
+		Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("And?")}},
+	}
+	ext := responses.MessageExtensionOf(&first.Choices[0].Message)
+	responses.ExtendRequest(next, &responses.RequestExtension{PreviousResponseID: ext.ResponseID})
+
+	if _, err := c.ChatCompletion(context.Background(), next); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if bodies[1]["previous_response_id"] != "resp_1" {
+		t.Errorf("previous_response_id = %v, want resp_1", bodies[1]["previous_response_id"])
+	}
+}
+
+func TestResponsesChatCompletionStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if body["stream"] != true {
+			t.Error("stream should be true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, ""+
+			"event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_s\",\"model\":\"gpt-5\",\"status\":\"in_progress\",\"output\":[]}}\n\n"+
+			"event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"output_index\":0,\"delta\":\"Hel\"}\n\n"+
+			"event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"output_index\":0,\"delta\":\"lo\"}\n\n"+
+			"event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_s\",\"status\":\"completed\",\"output\":[],\"usage\":{\"input_tokens\":3,\"output_tokens\":2,\"total_tokens\":5}}}\n\n")
+	}))
+	defer srv.Close()
+
+	stream, err := newResponsesClient(t, srv.URL).ChatCompletionStream(context.Background(), &ais.ChatRequest{
+		Model:    "gpt-5",
+		Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("Hi")}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var text string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		for _, c := range chunk.Choices {
+			text += c.Delta.Content.Text()
+		}
+	}
+
+	if text != "Hello" {
+		t.Errorf("text = %q", text)
+	}
+	if u := stream.Usage(); u == nil || u.TotalTokens != 5 {
+		t.Errorf("usage = %+v", u)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"context"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Embedder is the embeddings capability contract. Like ChatCompleter it is a
+// small per-interaction-form interface; *Client implements it, and calls fail
+// with ais.ErrEmbeddingUnsupported when the selected provider does not
+// implement ais.EmbeddingProvider.
+type Embedder interface {
+	Embed(ctx context.Context, req *ais.EmbeddingRequest) (*ais.EmbeddingResponse, error)
+}
+
+// Compile-time check: *Client implements Embedder.
+var _ Embedder = (*Client)(nil)
+
+// Embed sends an embeddings request, delegating the protocol-specific work to
+// the client's resolved provider. The client's default model is a chat model
+// and is not applied: req.Model names the embedding model.
+//
+// Embed does not run the WithMiddleware chain, whose Call carries chat
+// requests only; wrap the Embedder to intercept it. Its response carries the
+// HTTP metadata in Meta, and API errors carry it as for chat.
+func (c *Client) Embed(ctx context.Context, req *ais.EmbeddingRequest) (*ais.EmbeddingResponse, error) {
+	if req == nil {
+		return nil, ais.ErrNilRequest
+	}
+
+	ep, ok := c.provider.(ais.EmbeddingProvider)
+	if !ok {
+		return nil, ais.ErrEmbeddingUnsupported
+	}
+
+	r := req.Clone()
+
+	httpReq, err := ep.NewEmbeddingRequest(ctx, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if !isSuccess(resp.StatusCode) {
+		return nil, c.parseError(resp)
+	}
+
+	out, err := ep.ParseEmbeddingResponse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Meta = responseMeta(resp)
+
+	return out, nil
+}
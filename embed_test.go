@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+	"github.com/vogo/aimodel/provider/anthropic"
+)
+
+// base64Vector encodes v the way the embeddings API does for
+// encoding_format "base64": little-endian float32 values.
+func base64Vector(v ...float32) string {
+	raw := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(f))
+	}
+
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// TestClientEmbedBatchBase64 verifies a batched base64 request reaches
+// /embeddings with its options and comes back as decoded float vectors.
+func TestClientEmbedBatchBase64(t *testing.T) {
+	var body map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("path = %q, want /embeddings", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-request-id", "req_embed")
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-3-small","data":[` +
+			`{"object":"embedding","index":1,"embedding":"` + base64Vector(0.5, -1) + `"},` +
+			`{"object":"embedding","index":0,"embedding":"` + base64Vector(0.25, 2) + `"}],` +
+			`"usage":{"prompt_tokens":6,"total_tokens":6}}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(WithAPIKey("sk-test"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	req := &ais.EmbeddingRequest{
+		Model:          "text-embedding-3-small",
+		Input:          []string{"alpha", "beta"},
+		Dimensions:     2,
+		EncodingFormat: ais.EncodingFormatBase64,
+	}
+
+	resp, err := c.Embed(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	if body["dimensions"] != float64(2) || body["encoding_format"] != "base64" {
+		t.Errorf("request body = %v", body)
+	}
+	if input, _ := body["input"].([]any); len(input) != 2 {
+		t.Errorf("input = %v, want two texts", body["input"])
+	}
+
+	if len(resp.Data) != 2 {
+		t.Fatalf("data len = %d, want 2", len(resp.Data))
+	}
+	if d := resp.Data[0]; d.Index != 1 || len(d.Embedding) != 2 || d.Embedding[0] != 0.5 || d.Embedding[1] != -1 {
+		t.Errorf("data[0] = %+v", d)
+	}
+	if d := resp.Data[1]; d.Index != 0 || d.Embedding[0] != 0.25 || d.Embedding[1] != 2 {
+		t.Errorf("data[1] = %+v", d)
+	}
+	if resp.Usage.PromptTokens != 6 || resp.Usage.TotalTokens != 6 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if resp.Meta == nil || resp.Meta.RequestID != "req_embed" {
+		t.Errorf("meta = %+v, want request ID req_embed", resp.Meta)
+	}
+}
+
+// TestClientEmbedAPIError verifies a non-2xx embeddings response goes through
+// the provider's error parsing.
+func TestClientEmbedAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad model","type":"invalid_request_error","code":"model_not_found"}}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(WithAPIKey("sk-test"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = c.Embed(context.Background(), &ais.EmbeddingRequest{Model: "nope", Input: []string{"x"}})
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *ais.APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "model_not_found" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+}
+
+// TestClientEmbedUnsupportedProvider verifies a provider without an
+// embeddings endpoint fails before any network I/O.
+func TestClientEmbedUnsupportedProvider(t *testing.T) {
+	c, err := NewClient(WithAPIKey("sk-test"), WithProvider(anthropic.Name), WithBaseURL("http://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = c.Embed(context.Background(), &ais.EmbeddingRequest{Model: "m", Input: []string{"x"}})
+	if !errors.Is(err, ais.ErrEmbeddingUnsupported) {
+		t.Errorf("err = %v, want ErrEmbeddingUnsupported", err)
+	}
+}
+
+// TestEmbedNilRequest verifies a nil request fails with ErrNilRequest instead
+// of panicking.
+func TestEmbedNilRequest(t *testing.T) {
+	c, err := NewClient(WithAPIKey("sk-test"), WithBaseURL("http://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := c.Embed(context.Background(), nil); !errors.Is(err, ais.ErrNilRequest) {
+		t.Errorf("Embed err = %v, want ErrNilRequest", err)
+	}
+}
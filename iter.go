@@ -0,0 +1,170 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"errors"
+	"io"
+	"iter"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// ToolCallEvent is a tool call whose arguments are complete, as yielded by
+// Stream.ToolCallEvents.
+type ToolCallEvent struct {
+	// ChoiceIndex is the index of the choice that made the call.
+	ChoiceIndex int
+	// ToolCall is the fully accumulated call: ID, name and the complete
+	// arguments JSON.
+	ToolCall ais.ToolCall
+}
+
+// Chunks returns an iterator over the stream's chunks. The iteration ends at
+// the end of the stream, or after yielding a non-EOF error as the final pair;
+// the stream is closed when the loop ends, whether it ran out or broke early.
+func (s *Stream) Chunks() iter.Seq2[*ais.StreamChunk, error] {
+	return func(yield func(*ais.StreamChunk, error) bool) {
+		defer func() { _ = s.Close() }()
+
+		for {
+			chunk, err := s.Recv()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(nil, err)
+				}
+
+				return
+			}
+
+			if chunk != nil && !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
+
+// TextDeltas returns an iterator over the non-empty content text deltas of
+// the first choice (index 0). Use Chunks for n > 1. Errors and closing follow
+// Chunks.
+func (s *Stream) TextDeltas() iter.Seq2[string, error] {
+	return s.choiceDeltas(func(m *ais.Message) string { return m.Content.Text() })
+}
+
+// ThinkingDeltas returns an iterator over the non-empty thinking deltas of the
+// first choice (index 0). Errors and closing follow Chunks.
+func (s *Stream) ThinkingDeltas() iter.Seq2[string, error] {
+	return s.choiceDeltas(func(m *ais.Message) string { return m.Thinking })
+}
+
+// choiceDeltas yields the non-empty strings pick extracts from the deltas of
+// choice 0.
+func (s *Stream) choiceDeltas(pick func(*ais.Message) string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for chunk, err := range s.Chunks() {
+			if err != nil {
+				yield("", err)
+
+				return
+			}
+
+			for i := range chunk.Choices {
+				c := &chunk.Choices[i]
+				if c.Index != 0 {
+					continue
+				}
+
+				if text := pick(&c.Delta); text != "" && !yield(text, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ToolCallEvents returns an iterator over the stream's tool calls, each
+// yielded once its arguments are complete: when the same choice starts a
+// later tool call, when the choice reports a finish reason, or when the
+// stream ends. Calls are yielded in index order per choice. Errors and
+// closing follow Chunks; calls still pending when an error arrives are not
+// yielded, since their arguments may be truncated.
+func (s *Stream) ToolCallEvents() iter.Seq2[ToolCallEvent, error] {
+	return func(yield func(ToolCallEvent, error) bool) {
+		var (
+			msgs    = map[int]*ais.Message{}
+			emitted = map[int]int{} // choice index → number of calls yielded
+			order   []int           // choice indices in first-seen order
+		)
+
+		// flush yields the calls of choice ci below index upTo.
+		flush := func(ci, upTo int) bool {
+			calls := msgs[ci].ToolCalls
+			for emitted[ci] < min(upTo, len(calls)) {
+				call := calls[emitted[ci]]
+				emitted[ci]++
+
+				if !yield(ToolCallEvent{ChoiceIndex: ci, ToolCall: call}, nil) {
+					return false
+				}
+			}
+
+			return true
+		}
+
+		for chunk, err := range s.Chunks() {
+			if err != nil {
+				yield(ToolCallEvent{}, err)
+
+				return
+			}
+
+			for i := range chunk.Choices {
+				c := &chunk.Choices[i]
+
+				m, ok := msgs[c.Index]
+				if !ok {
+					m = &ais.Message{}
+					msgs[c.Index] = m
+					order = append(order, c.Index)
+				}
+
+				// A delta on a later tool call completes every earlier one.
+				upTo := emitted[c.Index]
+				for _, tc := range c.Delta.ToolCalls {
+					upTo = max(upTo, tc.Index)
+				}
+
+				m.AppendDelta(&c.Delta)
+
+				if c.FinishReason != nil {
+					upTo = len(m.ToolCalls)
+				}
+
+				if !flush(c.Index, upTo) {
+					return
+				}
+			}
+		}
+
+		for _, ci := range order {
+			if !flush(ci, len(msgs[ci].ToolCalls)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// trackedStream returns a stream over chunks that ends with err, and a
+// function reporting whether the stream was closed.
+func trackedStream(err error, chunks ...*ais.StreamChunk) (*Stream, func() bool) {
+	s := newStream(io.NopCloser(strings.NewReader("")), &sequenceDecoder{chunks: chunks, err: err})
+
+	return s, s.closed.Load
+}
+
+func textChunk(index int, text, thinking string) *ais.StreamChunk {
+	return &ais.StreamChunk{Choices: []ais.StreamChunkChoice{{Index: index, Delta: ais.Message{Content: ais.NewTextContent(text), Thinking: thinking}}}}
+}
+
+func TestStreamChunksClosesAtEnd(t *testing.T) {
+	s, closed := trackedStream(io.EOF, &ais.StreamChunk{ID: "a"}, &ais.StreamChunk{ID: "b"})
+
+	var ids []string
+
+	for chunk, err := range s.Chunks() {
+		if err != nil {
+			t.Fatalf("err = %v", err)
+		}
+
+		ids = append(ids, chunk.ID)
+	}
+
+	if strings.Join(ids, ",") != "a,b" || !closed() {
+		t.Errorf("ids = %v, closed = %v", ids, closed())
+	}
+}
+
+func TestStreamChunksClosesOnBreak(t *testing.T) {
+	s, closed := trackedStream(io.EOF, &ais.StreamChunk{ID: "a"}, &ais.StreamChunk{ID: "b"})
+
+	for range s.Chunks() {
+		break
+	}
+
+	if !closed() {
+		t.Error("stream not closed after break")
+	}
+}
+
+func TestStreamChunksYieldsError(t *testing.T) {
+	boom := errors.New("boom")
+	s, closed := trackedStream(boom, &ais.StreamChunk{ID: "a"})
+
+	var errs []error
+
+	for _, err := range s.Chunks() {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], boom) || !closed() {
+		t.Errorf("errs = %v, closed = %v", errs, closed())
+	}
+}
+
+func TestStreamTextAndThinkingDeltas(t *testing.T) {
+	chunks := []*ais.StreamChunk{
+		textChunk(0, "", "hm"),
+		textChunk(0, "Hel", ""),
+		textChunk(1, "other choice", "x"),
+		textChunk(0, "lo", ""),
+	}
+
+	s, _ := trackedStream(io.EOF, chunks...)
+
+	var text strings.Builder
+
+	for delta, err := range s.TextDeltas() {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		text.WriteString(delta)
+	}
+
+	if text.String() != "Hello" {
+		t.Errorf("text = %q, want Hello", text.String())
+	}
+
+	s, _ = trackedStream(io.EOF, chunks...)
+
+	var thinking []string
+
+	for delta := range s.ThinkingDeltas() {
+		thinking = append(thinking, delta)
+	}
+
+	if strings.Join(thinking, ",") != "hm" {
+		t.Errorf("thinking = %v, want [hm]", thinking)
+	}
+}
+
+func TestStreamToolCallEvents(t *testing.T) {
+	stop := "tool_calls"
+	tool := func(choice, index int, id, name, args string) *ais.StreamChunk {
+		return &ais.StreamChunk{Choices: []ais.StreamChunkChoice{{Index: choice, Delta: ais.Message{
+			ToolCalls: []ais.ToolCall{{Index: index, ID: id, Function: ais.FunctionCall{Name: name, Arguments: args}}},
+		}}}}
+	}
+
+	var order []string
+
+	record := func(s *Stream) {
+		for ev, err := range s.ToolCallEvents() {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order = append(order, ev.ToolCall.ID+":"+ev.ToolCall.Function.Arguments)
+
+			if ev.ToolCall.ID == "c0" && ev.ChoiceIndex != 1 {
+				t.Errorf("c0 choice = %d, want 1", ev.ChoiceIndex)
+			}
+		}
+	}
+
+	s, closed := trackedStream(io.EOF,
+		tool(0, 0, "a", "f", `{"x":`),
+		tool(1, 0, "c0", "g", `{}`),
+		tool(0, 0, "", "", `1}`),
+		tool(0, 1, "b", "f", `{"y":`),
+		tool(0, 1, "", "", `2}`),
+		&ais.StreamChunk{Choices: []ais.StreamChunkChoice{{Index: 0, FinishReason: &stop}}},
+	)
+	record(s)
+
+	// "a" completes when "b" starts; "b" on the finish reason; choice 1's
+	// call has no finish reason and is flushed at the end of the stream.
+	want := `a:{"x":1},b:{"y":2},c0:{}`
+	if got := strings.Join(order, ","); got != want || !closed() {
+		t.Errorf("events = %s, want %s (closed = %v)", got, want, closed())
+	}
+}
+
+func TestStreamToolCallEventsDropsPendingOnError(t *testing.T) {
+	boom := errors.New("boom")
+	s, _ := trackedStream(boom, &ais.StreamChunk{Choices: []ais.StreamChunkChoice{{Delta: ais.Message{
+		ToolCalls: []ais.ToolCall{{Index: 0, ID: "a", Function: ais.FunctionCall{Arguments: `{"trunc`}}},
+	}}}})
+
+	var (
+		calls int
+		got   error
+	)
+
+	for _, err := range s.ToolCallEvents() {
+		if err != nil {
+			got = err
+
+			continue
+		}
+
+		calls++
+	}
+
+	if calls != 0 || !errors.Is(got, boom) {
+		t.Errorf("calls = %d, err = %v; want no calls and boom", calls, got)
+	}
+}
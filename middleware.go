@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Call is one chat call as seen by a middleware chain. The chain's terminal
+// handler fills the result fields; a middleware reads them after its next
+// handler returns.
+type Call struct {
+	// Request is the call's own copy of the caller's request. On a Client it
+	// has Stream and the default model applied, and the HTTP request is built
+	// from it before the chain runs, so changes to it do not reach the wire —
+	// edit HTTPRequest instead. On a composes.ComposeClient it is the
+	// per-entry request, and changes do reach the entry.
+	Request *ais.ChatRequest
+
+	// HTTPRequest is the request built by the provider's NewChatRequest.
+	// Middleware may add headers, sign or replace it before calling next.
+	// It is nil when the call does not go over HTTP (composes).
+	HTTPRequest *http.Request
+
+	// HTTPResponse is the raw HTTP response, set once the call reached the
+	// server — including non-2xx responses. For a unary call its body has
+	// already been consumed; read the status and headers only. Nil for
+	// transport failures and in composes.
+	HTTPResponse *http.Response
+
+	// Response is the parsed unary response; Stream is the streaming result.
+	// Exactly one is set when the call succeeds.
+	Response *ais.ChatResponse
+	Stream   *Stream
+}
+
+// Handler performs a chat call, filling the result fields of call.
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware wraps a Handler. It may inspect or modify the call before
+// calling next, inspect the result and error after, or return without
+// calling next (e.g. to inject a fault or serve a cached response).
+type Middleware func(next Handler) Handler
+
+// WithMiddleware appends middleware to the client's chat chain. The first
+// middleware is the outermost: it sees the call first and the result last.
+// The chain wraps ChatCompletion and ChatCompletionStream; Embed is not
+// affected.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *clientConfig) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// ApplyMiddleware wraps h in mw, the first middleware outermost. With no
+// middleware it returns h unchanged.
+func ApplyMiddleware(h Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+
+	return h
+}
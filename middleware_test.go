@@ -0,0 +1,243 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestMiddlewareSeesCallAndResult verifies the chain order, header injection
+// on the provider-built request, and that each middleware sees the cloned
+// request, the HTTP response and the parsed response.
+func TestMiddlewareSeesCallAndResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Signature"); got != "signed" {
+			t.Errorf("X-Signature = %q, want signed", got)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = io.WriteString(w, `{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	var order []string
+
+	outer := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			order = append(order, "outer-before")
+			err := next(ctx, call)
+			order = append(order, "outer-after")
+
+			return err
+		}
+	}
+	signer := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			order = append(order, "signer-before")
+			if call.Request.Model != "default-model" || call.Request.Stream {
+				t.Errorf("request = %+v, want the default model applied and Stream false", call.Request)
+			}
+			call.HTTPRequest.Header.Set("X-Signature", "signed")
+
+			err := next(ctx, call)
+			order = append(order, "signer-after")
+
+			if call.HTTPResponse == nil || call.HTTPResponse.Header.Get("X-Request-Id") != "req-1" {
+				t.Errorf("HTTPResponse = %+v", call.HTTPResponse)
+			}
+			if call.Response == nil || call.Response.ID != "c1" {
+				t.Errorf("Response = %+v", call.Response)
+			}
+
+			return err
+		}
+	}
+
+	c, err := NewClient(WithAPIKey("k"), WithBaseURL(srv.URL), WithDefaultModel("default-model"), WithMiddleware(outer), WithMiddleware(signer))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	req := &ais.ChatRequest{Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("hi")}}}
+	resp, err := c.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Choices[0].Message.Content.Text() != "hi" {
+		t.Errorf("content = %q", resp.Choices[0].Message.Content.Text())
+	}
+	if req.Model != "" {
+		t.Errorf("caller request mutated: model = %q", req.Model)
+	}
+
+	want := "outer-before,signer-before,signer-after,outer-after"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+// TestMiddlewareSeesAPIError verifies a non-2xx response reaches middleware
+// as both the HTTP response and the parsed error.
+func TestMiddlewareSeesAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error":{"message":"slow down","type":"rate_limit"}}`)
+	}))
+	defer srv.Close()
+
+	var (
+		status int
+		seen   error
+	)
+
+	audit := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			err := next(ctx, call)
+			if call.HTTPResponse != nil {
+				status = call.HTTPResponse.StatusCode
+			}
+			seen = err
+
+			return err
+		}
+	}
+
+	c, err := NewClient(WithAPIKey("k"), WithBaseURL(srv.URL), WithMiddleware(audit))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = c.ChatCompletion(context.Background(), &ais.ChatRequest{Model: "m"})
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) || !errors.As(seen, &apiErr) || status != http.StatusTooManyRequests {
+		t.Errorf("err = %v, seen = %v, status = %d", err, seen, status)
+	}
+}
+
+// TestMiddlewareFaultInjection verifies a middleware can short-circuit the
+// call without any HTTP traffic.
+func TestMiddlewareFaultInjection(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits++ }))
+	defer srv.Close()
+
+	injected := errors.New("injected")
+	fault := func(Handler) Handler {
+		return func(context.Context, *Call) error { return injected }
+	}
+
+	c, err := NewClient(WithAPIKey("k"), WithBaseURL(srv.URL), WithMiddleware(fault))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := c.ChatCompletionStream(context.Background(), &ais.ChatRequest{Model: "m"}); !errors.Is(err, injected) {
+		t.Errorf("err = %v, want injected", err)
+	}
+	if hits != 0 {
+		t.Errorf("server hits = %d, want 0", hits)
+	}
+}
+
+// TestChatNilRequest verifies a nil chat request fails with ErrNilRequest on
+// both paths, without panicking or reaching the middleware or the server.
+func TestChatNilRequest(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits++ }))
+	defer srv.Close()
+
+	calls := 0
+	count := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			calls++
+			return next(ctx, call)
+		}
+	}
+
+	c, err := NewClient(WithAPIKey("k"), WithBaseURL(srv.URL), WithMiddleware(count))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := c.ChatCompletion(context.Background(), nil); !errors.Is(err, ais.ErrNilRequest) {
+		t.Errorf("ChatCompletion err = %v, want ErrNilRequest", err)
+	}
+	if _, err := c.ChatCompletionStream(context.Background(), nil); !errors.Is(err, ais.ErrNilRequest) {
+		t.Errorf("ChatCompletionStream err = %v, want ErrNilRequest", err)
+	}
+	if calls != 0 || hits != 0 {
+		t.Errorf("middleware calls = %d, server hits = %d, want 0", calls, hits)
+	}
+}
+
+// TestMiddlewareStream verifies the streaming path exposes the Stream to
+// middleware, which can intercept its chunks.
+func TestMiddlewareStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"id\":\"s1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"a\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	var chunks int
+
+	count := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			if !call.Request.Stream {
+				t.Error("Request.Stream = false on the streaming path")
+			}
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+			call.Stream = InterceptStream(call.Stream, func(*ais.StreamChunk) { chunks++ }, nil)
+
+			return nil
+		}
+	}
+
+	c, err := NewClient(WithAPIKey("k"), WithBaseURL(srv.URL), WithMiddleware(count))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	s, err := c.ChatCompletionStream(context.Background(), &ais.ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	for {
+		if _, err := s.Recv(); err != nil {
+			break
+		}
+	}
+
+	if chunks != 1 {
+		t.Errorf("intercepted chunks = %d, want 1", chunks)
+	}
+}
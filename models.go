@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"context"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// ListModels returns the IDs of the models the backend serves, through the
+// provider's ais.ModelLister. It fails with ais.ErrModelListUnsupported,
+// without network I/O, when the provider does not list models. Like Embed it
+// does not run the middleware chain.
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	ml, ok := c.provider.(ais.ModelLister)
+	if !ok {
+		return nil, ais.ErrModelListUnsupported
+	}
+
+	httpReq, err := ml.NewListModelsRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if !isSuccess(resp.StatusCode) {
+		return nil, c.parseError(resp)
+	}
+
+	return ml.ParseListModelsResponse(resp.Body)
+}
+
+// CheckHealth checks that the backend is reachable and accepts the client's
+// credentials by listing its models, which costs no tokens. It does not prove
+// a particular model serves requests. It fails with
+// ais.ErrModelListUnsupported when the provider does not list models, so
+// callers such as the composes active health check fall back to a chat probe.
+func (c *Client) CheckHealth(ctx context.Context) error {
+	_, err := c.ListModels(ctx)
+
+	return err
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aimodel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+	"github.com/vogo/aimodel/provider/anthropic"
+)
+
+// TestClientListModels verifies the model list goes through GET /models and
+// that CheckHealth reports its outcome.
+func TestClientListModels(t *testing.T) {
+	status := http.StatusOK
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/models" {
+			t.Errorf("request = %s %s, want GET /models", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model"},{"id":"o3","object":"model"}]}`))
+		} else {
+			_, _ = w.Write([]byte(`{"error":{"message":"bad key","type":"invalid_request_error","code":"invalid_api_key"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(WithAPIKey("sk-test"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	ids, err := c.ListModels(context.Background())
+	if err != nil || len(ids) != 2 || ids[0] != "gpt-4o" || ids[1] != "o3" {
+		t.Fatalf("ListModels = %v, %v", ids, err)
+	}
+
+	if err := c.CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth: %v", err)
+	}
+
+	status = http.StatusUnauthorized
+
+	var apiErr *ais.APIError
+	if err := c.CheckHealth(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("CheckHealth err = %v, want a 401 APIError", err)
+	}
+}
+
+// TestClientListModelsUnsupported verifies a provider without a model list
+// fails before any network I/O with an error wrapping errors.ErrUnsupported.
+func TestClientListModelsUnsupported(t *testing.T) {
+	c, err := NewClient(WithAPIKey("sk-test"), WithProvider(anthropic.Name), WithBaseURL("http://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	err = c.CheckHealth(context.Background())
+	if !errors.Is(err, ais.ErrModelListUnsupported) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("err = %v, want ErrModelListUnsupported", err)
+	}
+}
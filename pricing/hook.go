@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// CallCost is the priced outcome of one call.
+type CallCost struct {
+	// Model is the model that served the call, as the response names it, or
+	// the requested model when the response does not.
+	Model string
+	// Usage is the call's usage report; nil when it reported none, e.g. an
+	// OpenAI stream without stream_options.include_usage.
+	Usage *ais.Usage
+	Cost  Breakdown
+	// Err wraps ErrUnknownModel when the table has no price for Model.
+	Err error
+}
+
+// Middleware returns an aimodel.Middleware that prices each successful call
+// with table (Default when nil) and passes the result to report. Install it
+// on a Client with aimodel.WithMiddleware, or on a compose with
+// composes.WithMiddleware to price every entry attempt — hedged and
+// abandoned ones included, since they are billed too.
+//
+// A unary call is reported when it returns. A stream is reported once, when
+// it ends or is closed, with the last usage chunk — the value Stream.Usage
+// reports. Failed calls are not reported.
+func Middleware(table *Table, report func(ctx context.Context, c CallCost)) aimodel.Middleware {
+	if table == nil {
+		table = Default()
+	}
+
+	return func(next aimodel.Handler) aimodel.Handler {
+		return func(ctx context.Context, call *aimodel.Call) error {
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+
+			if call.Response != nil {
+				var usage *ais.Usage
+				if u := call.Response.Usage; u.PromptTokens+u.CompletionTokens+u.TotalTokens > 0 {
+					usage = &call.Response.Usage
+				}
+
+				report(ctx, price(table, modelOf(call.Response.Model, call.Request), usage))
+
+				return nil
+			}
+
+			if call.Stream != nil {
+				call.Stream = watch(ctx, table, call.Request, call.Stream, report)
+			}
+
+			return nil
+		}
+	}
+}
+
+// watch reports the cost of s when it ends or is closed.
+func watch(ctx context.Context, table *Table, req *ais.ChatRequest, s *aimodel.Stream, report func(context.Context, CallCost)) *aimodel.Stream {
+	var (
+		mu    sync.Mutex // Close may race Recv
+		model string
+		usage *ais.Usage
+	)
+
+	return aimodel.InterceptStream(s, func(chunk *ais.StreamChunk) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}, func(error) {
+		mu.Lock()
+		m, u := modelOf(model, req), usage
+		mu.Unlock()
+
+		report(ctx, price(table, m, u))
+	})
+}
+
+func price(table *Table, model string, usage *ais.Usage) CallCost {
+	cost, err := table.Cost(model, usage)
+
+	return CallCost{Model: model, Usage: usage, Cost: cost, Err: err}
+}
+
+// modelOf returns the served model, or the requested one when unknown.
+func modelOf(served string, req *ais.ChatRequest) string {
+	if served == "" && req != nil {
+		return req.Model
+	}
+
+	return served
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+func recordCosts(costs *[]CallCost) func(context.Context, CallCost) {
+	return func(_ context.Context, c CallCost) {
+		*costs = append(*costs, c)
+	}
+}
+
+func TestMiddlewareUnary(t *testing.T) {
+	var costs []CallCost
+
+	h := aimodel.ApplyMiddleware(func(_ context.Context, call *aimodel.Call) error {
+		call.Response = &ais.ChatResponse{Model: "gpt-2025", Usage: ais.Usage{PromptTokens: 1_000_000}}
+
+		return nil
+	}, Middleware(testTable(), recordCosts(&costs)))
+
+	if err := h(context.Background(), &aimodel.Call{Request: &ais.ChatRequest{Model: "gpt"}}); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	if len(costs) != 1 || costs[0].Model != "gpt-2025" || costs[0].Err != nil || !near(costs[0].Cost.Total, 2) {
+		t.Errorf("costs = %+v", costs)
+	}
+}
+
+func TestMiddlewareSkipsFailures(t *testing.T) {
+	var costs []CallCost
+
+	h := aimodel.ApplyMiddleware(func(context.Context, *aimodel.Call) error {
+		return errors.New("boom")
+	}, Middleware(testTable(), recordCosts(&costs)))
+
+	if err := h(context.Background(), &aimodel.Call{Request: &ais.ChatRequest{Model: "gpt"}}); err == nil {
+		t.Fatal("want the error")
+	}
+
+	if len(costs) != 0 {
+		t.Errorf("costs = %+v, want none", costs)
+	}
+}
+
+func TestMiddlewareStream(t *testing.T) {
+	var costs []CallCost
+
+	h := aimodel.ApplyMiddleware(func(_ context.Context, call *aimodel.Call) error {
+		chunks := []*ais.StreamChunk{{ID: "c1"}, {ID: "c2", Usage: &ais.Usage{CompletionTokens: 1_000_000}}}
+		call.Stream = aimodel.NewStream(func() (*ais.StreamChunk, error) {
+			if len(chunks) == 0 {
+				return nil, io.EOF
+			}
+
+			c := chunks[0]
+			chunks = chunks[1:]
+
+			return c, nil
+		}, nil)
+
+		return nil
+	}, Middleware(testTable(), recordCosts(&costs)))
+
+	call := &aimodel.Call{Request: &ais.ChatRequest{Model: "claude"}}
+	if err := h(context.Background(), call); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	if len(costs) != 0 {
+		t.Fatalf("reported before the stream ended: %+v", costs)
+	}
+
+	if _, err := call.Stream.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if len(costs) != 1 || costs[0].Model != "claude" || !near(costs[0].Cost.Output, 15) {
+		t.Errorf("costs = %+v", costs)
+	}
+}
+
+func TestMiddlewareUnknownModel(t *testing.T) {
+	var costs []CallCost
+
+	h := aimodel.ApplyMiddleware(func(_ context.Context, call *aimodel.Call) error {
+		call.Response = &ais.ChatResponse{Usage: ais.Usage{PromptTokens: 1}}
+
+		return nil
+	}, Middleware(testTable(), recordCosts(&costs)))
+
+	if err := h(context.Background(), &aimodel.Call{Request: &ais.ChatRequest{Model: "mystery"}}); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	if len(costs) != 1 || costs[0].Model != "mystery" || !errors.Is(costs[0].Err, ErrUnknownModel) {
+		t.Errorf("costs = %+v", costs)
+	}
+}
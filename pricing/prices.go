@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+// builtinPrices seeds the Default table with published list prices (USD per
+// million tokens, standard tier; Gemini Pro at the up-to-200k-prompt rate).
+// Models without a known public price are not listed: add them with
+// Table.Set or Table.LoadJSON.
+var builtinPrices = map[string]Price{
+	// OpenAI: cached input at the listed discount, 50% off through the Batch API.
+	"gpt-4o":       {Input: 2.5, Output: 10, CacheRead: 1.25, BatchDiscount: 0.5},
+	"gpt-4o-mini":  {Input: 0.15, Output: 0.6, CacheRead: 0.075, BatchDiscount: 0.5},
+	"gpt-4.1":      {Input: 2, Output: 8, CacheRead: 0.5, BatchDiscount: 0.5},
+	"gpt-4.1-mini": {Input: 0.4, Output: 1.6, CacheRead: 0.1, BatchDiscount: 0.5},
+	"gpt-4.1-nano": {Input: 0.1, Output: 0.4, CacheRead: 0.025, BatchDiscount: 0.5},
+	"o1":           {Input: 15, Output: 60, CacheRead: 7.5, BatchDiscount: 0.5},
+	"o3":           {Input: 2, Output: 8, CacheRead: 0.5, BatchDiscount: 0.5},
+	"o3-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.55, BatchDiscount: 0.5},
+	"o4-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.275, BatchDiscount: 0.5},
+
+	// Gemini: context cache reads at the listed rate, 50% off in batch mode.
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10, CacheRead: 0.125, BatchDiscount: 0.5},
+	"gemini-2.5-flash":      {Input: 0.3, Output: 2.5, CacheRead: 0.03, BatchDiscount: 0.5},
+	"gemini-2.5-flash-lite": {Input: 0.1, Output: 0.4, CacheRead: 0.01, BatchDiscount: 0.5},
+
+	// Anthropic: cache reads at 0.1×, 5-minute writes at 1.25× and 1-hour
+	// writes at 2× the input price; $10 per thousand web searches.
+	"claude-haiku-4-5": {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite5m: 1.25, CacheWrite1h: 2, BatchDiscount: 0.5, Search: 0.01},
+}
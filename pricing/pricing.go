@@ -0,0 +1,244 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pricing turns token usage into money. It keeps a per-model price
+// table — overridable from JSON, since prices change — computes the cost of
+// an ais.Usage including the billing its provider extensions report, and
+// reports the cost of each call through a middleware. It imports no provider
+// package.
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// ErrUnknownModel is returned for a model the table has no price for.
+var ErrUnknownModel = errors.New("aimodel/pricing: unknown model")
+
+// Price is the list price of one model. Token prices are in USD per million
+// tokens; Search is in USD per server-side search request.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	// CacheRead prices prompt tokens served from the cache; zero means the
+	// input price.
+	CacheRead float64 `json:"cache_read,omitempty"`
+	// CacheWrite5m / CacheWrite1h price prompt tokens written to the cache
+	// with a 5-minute or 1-hour TTL. A zero CacheWrite5m means the input
+	// price; a zero CacheWrite1h means the 5-minute price.
+	CacheWrite5m float64 `json:"cache_write_5m,omitempty"`
+	CacheWrite1h float64 `json:"cache_write_1h,omitempty"`
+	// BatchDiscount is the fraction taken off token costs when the usage
+	// reports the "batch" service tier, e.g. 0.5.
+	BatchDiscount float64 `json:"batch_discount,omitempty"`
+	Search        float64 `json:"search,omitempty"`
+}
+
+// Breakdown is the cost of one usage report in USD, split by what was billed.
+type Breakdown struct {
+	Input      float64 `json:"input"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+	Output     float64 `json:"output"`
+	Search     float64 `json:"search"`
+	Total      float64 `json:"total"`
+}
+
+// Table maps model names to prices. It is safe for concurrent use, so a
+// table can be updated while calls are being priced.
+type Table struct {
+	mu     sync.RWMutex
+	prices map[string]Price
+}
+
+// NewTable returns a table holding prices.
+func NewTable(prices map[string]Price) *Table {
+	t := &Table{prices: make(map[string]Price, len(prices))}
+	for model, p := range prices {
+		t.prices[model] = p
+	}
+
+	return t
+}
+
+var defaultTable = NewTable(builtinPrices)
+
+// Default returns the shared table used by Cost, seeded with the built-in
+// prices. Update it with Set or LoadJSON when prices change.
+func Default() *Table {
+	return defaultTable
+}
+
+// Cost prices u for model with the Default table.
+func Cost(model string, u *ais.Usage) (Breakdown, error) {
+	return defaultTable.Cost(model, u)
+}
+
+// Set sets the price of model.
+func (t *Table) Set(model string, p Price) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prices[model] = p
+}
+
+// LoadJSON reads an object mapping model names to prices and sets each
+// one, leaving models it does not mention unchanged:
+//
+//	{"claude-haiku-4-5": {"input": 1, "output": 5, "cache_read": 0.1}}
+//
+// Unknown fields are rejected. On error the table is not changed.
+func (t *Table) LoadJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var prices map[string]Price
+	if err := dec.Decode(&prices); err != nil {
+		return fmt.Errorf("aimodel/pricing: decode price table: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for model, p := range prices {
+		t.prices[model] = p
+	}
+
+	return nil
+}
+
+// Lookup returns the price of model. A dated or versioned model name, such
+// as "gpt-4o-2024-08-06" or "claude-haiku-4-5@20251001", falls back to the
+// longest listed name it extends with a "-" or "@" and digits.
+func (t *Table) Lookup(model string) (Price, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if p, ok := t.prices[model]; ok {
+		return p, true
+	}
+
+	var (
+		best  string
+		price Price
+	)
+
+	for name, p := range t.prices {
+		if len(name) > len(best) && isVersionOf(model, name) {
+			best, price = name, p
+		}
+	}
+
+	return price, best != ""
+}
+
+// isVersionOf reports whether model is name followed by a version suffix: a
+// "-" or "@" and then only digits and dashes.
+func isVersionOf(model, name string) bool {
+	if len(model) < len(name)+2 || model[:len(name)] != name {
+		return false
+	}
+
+	rest := model[len(name):]
+	if rest[0] != '-' && rest[0] != '@' {
+		return false
+	}
+
+	for _, r := range rest[1:] {
+		if (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Cost prices u for model. Cache reads and writes are billed out of the
+// prompt tokens that include them, cache writes at the price of their TTL;
+// web searches add the search fee; prompt tokens that PromptTokens excludes
+// (Gemini's tool-use prompt) are billed as input. The usage extensions are
+// read through the ais billing interfaces (ais.CacheWriteUsage,
+// ais.ExtraPromptUsage, ais.WebSearchUsage). A nil usage costs nothing.
+func (t *Table) Cost(model string, u *ais.Usage) (Breakdown, error) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return Breakdown{}, fmt.Errorf("%w %q", ErrUnknownModel, model)
+	}
+
+	if u == nil {
+		return Breakdown{}, nil
+	}
+
+	cacheRead := or(p.CacheRead, p.Input)
+	write5m := or(p.CacheWrite5m, p.Input)
+	write1h := or(p.CacheWrite1h, write5m)
+
+	input := u.PromptTokens - u.CacheReadTokens
+
+	var b Breakdown
+
+	for _, v := range u.Extensions {
+		if w, ok := v.(ais.CacheWriteUsage); ok {
+			ttl5m, ttl1h := w.CacheWrites()
+			input -= ttl5m + ttl1h
+			b.CacheWrite += perMillion(ttl5m, write5m) + perMillion(ttl1h, write1h)
+		}
+
+		if e, ok := v.(ais.ExtraPromptUsage); ok {
+			input += e.ExtraPromptTokens()
+		}
+
+		if w, ok := v.(ais.WebSearchUsage); ok {
+			b.Search += float64(w.WebSearches()) * p.Search
+		}
+	}
+
+	b.Input = perMillion(max(input, 0), p.Input)
+	b.CacheRead = perMillion(u.CacheReadTokens, cacheRead)
+	b.Output = perMillion(u.CompletionTokens, p.Output)
+
+	if u.ServiceTier == "batch" && p.BatchDiscount > 0 {
+		keep := 1 - p.BatchDiscount
+		b.Input *= keep
+		b.CacheRead *= keep
+		b.CacheWrite *= keep
+		b.Output *= keep
+	}
+
+	b.Total = b.Input + b.CacheRead + b.CacheWrite + b.Output + b.Search
+
+	return b, nil
+}
+
+func perMillion(tokens int, price float64) float64 {
+	return float64(tokens) * price / 1e6
+}
+
+// or returns v, or fallback when v is zero.
+func or(v, fallback float64) float64 {
+	if v == 0 {
+		return fallback
+	}
+
+	return v
+}
@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+	"github.com/vogo/aimodel/provider/anthropic"
+	"github.com/vogo/aimodel/provider/gemini"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func testTable() *Table {
+	return NewTable(map[string]Price{
+		"gpt":    {Input: 2, Output: 8, CacheRead: 0.5, BatchDiscount: 0.5},
+		"claude": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite5m: 3.75, CacheWrite1h: 6, Search: 0.01},
+		"gemini": {Input: 1, Output: 10},
+	})
+}
+
+func TestCostCachedPrompt(t *testing.T) {
+	b, err := testTable().Cost("gpt", &ais.Usage{PromptTokens: 1_000_000, CacheReadTokens: 400_000, CompletionTokens: 100_000})
+	if err != nil {
+		t.Fatalf("Cost: %v", err)
+	}
+
+	if !near(b.Input, 1.2) || !near(b.CacheRead, 0.2) || !near(b.Output, 0.8) || !near(b.Total, 2.2) {
+		t.Errorf("breakdown = %+v", b)
+	}
+}
+
+func TestCostAnthropicExtension(t *testing.T) {
+	u := &ais.Usage{PromptTokens: 1_000_000, CacheReadTokens: 200_000, CompletionTokens: 10_000}
+	u.Extensions.Set(anthropic.Name, &anthropic.UsageExtension{
+		CacheWriteTokens:   300_000,
+		CacheWrite5mTokens: 100_000,
+		CacheWrite1hTokens: 200_000,
+		ServerToolUse:      &anthropic.ServerToolUse{WebSearchRequests: 3, WebFetchRequests: 2},
+	})
+
+	b, err := testTable().Cost("claude", u)
+	if err != nil {
+		t.Fatalf("Cost: %v", err)
+	}
+
+	// 500k uncached input, 200k reads, 100k 5m writes + 200k 1h writes.
+	if !near(b.Input, 1.5) || !near(b.CacheRead, 0.06) || !near(b.CacheWrite, 0.375+1.2) ||
+		!near(b.Output, 0.15) || !near(b.Search, 0.03) || !near(b.Total, 1.5+0.06+1.575+0.15+0.03) {
+		t.Errorf("breakdown = %+v", b)
+	}
+}
+
+func TestCostAnthropicWritesWithoutSplit(t *testing.T) {
+	u := &ais.Usage{PromptTokens: 100_000}
+	u.Extensions.Set(anthropic.Name, &anthropic.UsageExtension{CacheWriteTokens: 100_000})
+
+	b, _ := testTable().Cost("claude", u)
+	if !near(b.Input, 0) || !near(b.CacheWrite, 0.375) {
+		t.Errorf("breakdown = %+v, want the writes at the 5-minute price", b)
+	}
+}
+
+func TestCostGeminiToolUsePrompt(t *testing.T) {
+	u := &ais.Usage{PromptTokens: 100_000, CompletionTokens: 1000}
+	u.Extensions.Set(gemini.Name, &gemini.UsageExtension{ToolUsePromptTokens: 50_000})
+
+	b, _ := testTable().Cost("gemini", u)
+	if !near(b.Input, 0.15) || !near(b.Output, 0.01) {
+		t.Errorf("breakdown = %+v", b)
+	}
+}
+
+func TestCostFallbacks(t *testing.T) {
+	u := &ais.Usage{PromptTokens: 1_000_000, CacheReadTokens: 1_000_000}
+
+	b, _ := testTable().Cost("gemini", u)
+	if !near(b.CacheRead, 1) {
+		t.Errorf("cache read = %v, want the input price", b.CacheRead)
+	}
+}
+
+func TestCostBatchDiscount(t *testing.T) {
+	b, _ := testTable().Cost("gpt", &ais.Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000, ServiceTier: "batch"})
+	if !near(b.Total, 5) {
+		t.Errorf("total = %v, want half of 10", b.Total)
+	}
+}
+
+func TestCostUnknownModel(t *testing.T) {
+	_, err := testTable().Cost("mystery", &ais.Usage{PromptTokens: 1})
+	if !errors.Is(err, ErrUnknownModel) || !strings.Contains(err.Error(), "mystery") {
+		t.Errorf("err = %v", err)
+	}
+
+	if b, err := testTable().Cost("gpt", nil); err != nil || b != (Breakdown{}) {
+		t.Errorf("nil usage = %+v, %v", b, err)
+	}
+}
+
+func TestLookupVersionedName(t *testing.T) {
+	tb := NewTable(map[string]Price{"gpt-4o": {Input: 1}, "gpt-4o-mini": {Input: 2}})
+
+	for model, want := range map[string]float64{
+		"gpt-4o":                 1,
+		"gpt-4o-2024-08-06":      1,
+		"gpt-4o-mini-2024-07-18": 2,
+		"gpt-4o@20240806":        1,
+	} {
+		if p, ok := tb.Lookup(model); !ok || p.Input != want {
+			t.Errorf("Lookup(%q) = %+v, %v, want input %v", model, p, ok, want)
+		}
+	}
+
+	for _, model := range []string{"gpt-4o-audio", "gpt-4", "gpt-4o-"} {
+		if _, ok := tb.Lookup(model); ok {
+			t.Errorf("Lookup(%q) found a price", model)
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	tb := testTable()
+
+	err := tb.LoadJSON(strings.NewReader(`{"gpt": {"input": 4, "output": 16}, "new-model": {"input": 1, "output": 2}}`))
+	if err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+
+	if p, _ := tb.Lookup("gpt"); p.Input != 4 || p.CacheRead != 0 {
+		t.Errorf("gpt = %+v, want replaced", p)
+	}
+
+	if _, ok := tb.Lookup("new-model"); !ok {
+		t.Error("new-model not added")
+	}
+
+	if _, ok := tb.Lookup("claude"); !ok {
+		t.Error("claude dropped, want unmentioned models kept")
+	}
+
+	if err := tb.LoadJSON(strings.NewReader(`{"gpt": {"inptu": 1}}`)); err == nil {
+		t.Error("want an unknown field rejected")
+	}
+}
+
+func TestDefaultTable(t *testing.T) {
+	if _, err := Cost("claude-haiku-4-5-20251001", &ais.Usage{PromptTokens: 10}); err != nil {
+		t.Errorf("Cost: %v", err)
+	}
+}
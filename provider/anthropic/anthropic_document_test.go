@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// documentBlocks translates a single user message carrying parts and returns
+// its native content blocks.
+func documentBlocks(t *testing.T, parts ...ContentPart) []ContentBlock {
+	t.Helper()
+
+	ar, err := toAnthropicRequest(&ChatRequest{
+		Model:    ModelAnthropicClaude4Sonnet,
+		Messages: []Message{{Role: RoleUser, Content: NewPartsContent(parts...)}},
+	})
+	if err != nil {
+		t.Fatalf("toAnthropicRequest: %v", err)
+	}
+
+	var blocks []ContentBlock
+	if err := json.Unmarshal(ar.Messages[0].Content, &blocks); err != nil {
+		t.Fatalf("unmarshal content: %v", err)
+	}
+
+	return blocks
+}
+
+// TestToAnthropicRequestFilePDFDataURI verifies a PDF data URI becomes a
+// base64 document block titled with the filename.
+func TestToAnthropicRequestFilePDFDataURI(t *testing.T) {
+	blocks := documentBlocks(t, ContentPart{Type: "file", File: &ais.File{
+		FileData: "data:application/pdf;base64,JVBERi0x",
+		Filename: "report.pdf",
+	}})
+
+	if len(blocks) != 1 {
+		t.Fatalf("blocks len = %d, want 1", len(blocks))
+	}
+	b := blocks[0]
+	if b.Type != "document" || b.Title != "report.pdf" {
+		t.Errorf("block = %+v, want document titled report.pdf", b)
+	}
+	if b.Source == nil || b.Source.Type != "base64" || b.Source.MediaType != "application/pdf" || b.Source.Data != "JVBERi0x" {
+		t.Errorf("source = %+v", b.Source)
+	}
+}
+
+// TestToAnthropicRequestFilePlainText verifies a text/plain data URI is
+// decoded into a text document source.
+func TestToAnthropicRequestFilePlainText(t *testing.T) {
+	blocks := documentBlocks(t, ContentPart{Type: "file", File: &ais.File{
+		FileData: "data:text/plain;base64,aGVsbG8gd29ybGQ=",
+	}})
+
+	if len(blocks) != 1 || blocks[0].Source == nil {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	src := blocks[0].Source
+	if src.Type != "text" || src.MediaType != "text/plain" || src.Data != "hello world" {
+		t.Errorf("source = %+v, want decoded text source", src)
+	}
+}
+
+// TestToAnthropicRequestFileURL verifies a file URL becomes a url document
+// source.
+func TestToAnthropicRequestFileURL(t *testing.T) {
+	blocks := documentBlocks(t, ContentPart{Type: "file", File: &ais.File{
+		FileURL: "https://example.com/paper.pdf",
+	}})
+
+	if len(blocks) != 1 || blocks[0].Source == nil {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if src := blocks[0].Source; src.Type != "url" || src.URL != "https://example.com/paper.pdf" {
+		t.Errorf("source = %+v, want url source", src)
+	}
+}
+
+// TestToAnthropicRequestUnrepresentableParts verifies parts the Messages API
+// cannot carry fail translation with a ContentPartError rather than being
+// dropped.
+func TestToAnthropicRequestUnrepresentableParts(t *testing.T) {
+	tests := []struct {
+		name string
+		part ContentPart
+	}{
+		{"audio", ContentPart{Type: "input_audio", InputAudio: &ais.InputAudio{Data: "UklGRg==", Format: "wav"}}},
+		{"file id", ContentPart{Type: "file", File: &ais.File{FileID: "file-abc"}}},
+		{"unsupported media", ContentPart{Type: "file", File: &ais.File{FileData: "data:image/png;base64,iVBOR"}}},
+		{"missing file", ContentPart{Type: "file"}},
+		{"unknown type", ContentPart{Type: "video_url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := toAnthropicRequest(&ChatRequest{
+				Model:    ModelAnthropicClaude4Sonnet,
+				Messages: []Message{{Role: RoleUser, Content: NewPartsContent(tt.part)}},
+			})
+
+			var cpe *ais.ContentPartError
+			if !errors.As(err, &cpe) {
+				t.Fatalf("err = %v, want *ais.ContentPartError", err)
+			}
+			if cpe.Provider != Name || cpe.Type != tt.part.Type {
+				t.Errorf("error = %+v", cpe)
+			}
+		})
+	}
+}
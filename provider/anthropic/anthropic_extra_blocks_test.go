@@ -213,8 +213,8 @@ func TestAnthropicStream_ExtraBlocks(t *testing.T) {
 }
 
 // TestAnthropicStream_KnownBlocksNoExtra is the regression guard: a stream of
-// only modelled events must produce no ExtraBlocks, and signature_delta stays
-// ignored rather than being misclassified as unknown.
+// only modelled events must produce no ExtraBlocks, and signature_delta is
+// not misclassified as unknown.
 func TestAnthropicStream_KnownBlocksNoExtra(t *testing.T) {
 	body := "" +
 		"event: message_start\n" +
@@ -20,6 +20,7 @@ package anthropic
 import (
 	"errors"
 	"io"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -69,6 +70,63 @@ func TestAnthropicStreamTextDelta(t *testing.T) {
 	}
 }
 
+// TestAnthropicStreamBlocksFaithful verifies that consecutive text blocks
+// reach the consumer exactly as sent, each delta marked with its block.
+func TestAnthropicStreamBlocksFaithful(t *testing.T) {
+	body := "" +
+		"event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":10,"output_tokens":0}}}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"One."}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Two."}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":1}` + "\n\n" +
+		"event: message_stop\n" +
+		`data: {"type":"message_stop"}` + "\n\n"
+
+	s := newAnthropicStream(io.NopCloser(strings.NewReader(body)))
+
+	var (
+		texts  []string
+		blocks []int
+	)
+
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+
+		for _, c := range chunk.Choices {
+			if text := c.Delta.Content.Text(); text != "" {
+				texts = append(texts, text)
+
+				bd, _ := c.Extensions.Value(Name).(*BlockDelta)
+				if bd == nil {
+					t.Fatalf("text delta %q carries no BlockDelta", text)
+				}
+
+				blocks = append(blocks, bd.BlockIndex())
+			}
+		}
+	}
+
+	if !slices.Equal(texts, []string{"One.", "Two."}) || !slices.Equal(blocks, []int{0, 1}) {
+		t.Errorf("texts = %q in blocks %v, want the wire deltas only", texts, blocks)
+	}
+}
+
 func TestAnthropicStreamToolUse(t *testing.T) {
 	body := "" +
 		"event: message_start\n" +
@@ -19,8 +19,11 @@ package anthropic
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/vogo/aimodel/ais"
 )
 
 // responseBlocks wraps hand-built content blocks as response blocks, filling
@@ -769,6 +772,30 @@ func TestParseDataURI(t *testing.T) {
 			uri:    "data:image/jpeg;charset=utf-8,hello",
 			wantOK: false,
 		},
+		{
+			name:      "parameters",
+			uri:       "data:text/plain;charset=utf-8;base64,SGk=",
+			wantMedia: "text/plain",
+			wantData:  "SGk=",
+			wantOK:    true,
+		},
+		{
+			name:      "default media type",
+			uri:       "data:;base64,SGk=",
+			wantMedia: "text/plain",
+			wantData:  "SGk=",
+			wantOK:    true,
+		},
+		{
+			name:   "parameters without base64",
+			uri:    "data:text/plain;charset=utf-8,hello",
+			wantOK: false,
+		},
+		{
+			name:   "no comma",
+			uri:    "data:image/png;base64",
+			wantOK: false,
+		},
 		{
 			name:      "webp",
 			uri:       "data:image/webp;base64,UklGR",
@@ -1056,6 +1083,52 @@ func TestToAnthropicMessageThinkingWithToolCalls(t *testing.T) {
 	}
 }
 
+func TestToAnthropicMessageToolCallsWithParts(t *testing.T) {
+	msg := Message{
+		Role: RoleAssistant,
+		Content: NewPartsContent(
+			ContentPart{Type: "text", Text: "Here is the chart."},
+			ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: "data:image/png;base64,iVBOR"}},
+		),
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Type: "function", Function: FunctionCall{Name: "plot", Arguments: `{}`}},
+		},
+	}
+
+	am, err := toAnthropicMessage(msg)
+	if err != nil {
+		t.Fatalf("toAnthropicMessage: %v", err)
+	}
+
+	var blocks []ContentBlock
+	if err := json.Unmarshal(am.Content, &blocks); err != nil {
+		t.Fatalf("unmarshal blocks: %v", err)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(blocks))
+	}
+
+	if blocks[0].Type != "text" || blocks[0].Text != "Here is the chart." {
+		t.Errorf("block[0] = %+v, want the text part", blocks[0])
+	}
+
+	if blocks[1].Type != "image" || blocks[1].Source == nil || blocks[1].Source.MediaType != "image/png" {
+		t.Errorf("block[1] = %+v, want the image part", blocks[1])
+	}
+
+	if blocks[2].Type != "tool_use" {
+		t.Errorf("block[2].type = %q, want tool_use", blocks[2].Type)
+	}
+
+	msg.Content = NewPartsContent(ContentPart{Type: "input_audio", InputAudio: &ais.InputAudio{Data: "AAAA", Format: "wav"}})
+
+	var partErr *ais.ContentPartError
+	if _, err := toAnthropicMessage(msg); !errors.As(err, &partErr) {
+		t.Errorf("err = %v, want a *ais.ContentPartError", err)
+	}
+}
+
 func TestToAnthropicRequestToolResultMissingID(t *testing.T) {
 	req := &ChatRequest{
 		Model: ModelAnthropicClaude4Sonnet,
@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// thinkingBlocksOf returns the replayable thinking blocks preserved on a
+// message's Anthropic extension, or nil when it carries none.
+func thinkingBlocksOf(m *Message) []ThinkingBlock {
+	if ext := MessageExtensionOf(m); ext != nil {
+		return ext.ThinkingBlocks
+	}
+
+	return nil
+}
+
+// TestFromAnthropicResponse_ThinkingSignatures verifies the unary decoder keeps
+// every thinking block's signature and the redacted_thinking payload, in
+// arrival order, while Message.Thinking still carries the joined text.
+func TestFromAnthropicResponse_ThinkingSignatures(t *testing.T) {
+	body := `{"id":"msg_t","model":"claude-sonnet-4","stop_reason":"tool_use",
+		"usage":{"input_tokens":1,"output_tokens":1},
+		"content":[
+			{"type":"thinking","thinking":"first","signature":"sig_1"},
+			{"type":"redacted_thinking","data":"enc_1"},
+			{"type":"thinking","thinking":"second","signature":"sig_2"},
+			{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"a":1}}
+		]}`
+
+	var ar MessagesResponse
+	if err := json.Unmarshal([]byte(body), &ar); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	msg := fromAnthropicResponse(&ar).Choices[0].Message
+
+	if msg.Thinking != "first\nsecond" {
+		t.Errorf("thinking = %q", msg.Thinking)
+	}
+
+	want := []ThinkingBlock{
+		{Type: "thinking", Thinking: "first", Signature: "sig_1"},
+		{Type: "redacted_thinking", Data: "enc_1"},
+		{Type: "thinking", Thinking: "second", Signature: "sig_2"},
+	}
+	if got := thinkingBlocksOf(&msg); !reflect.DeepEqual(got, want) {
+		t.Errorf("thinking blocks = %+v\nwant %+v", got, want)
+	}
+
+	// redacted_thinking is modelled now, so it no longer lands in ExtraBlocks.
+	if got := extraBlocksOf(&msg); got != nil {
+		t.Errorf("extra blocks = %v, want nil", got)
+	}
+}
+
+// TestAnthropicStream_ThinkingSignatures verifies the stream decoder captures
+// signature_delta and redacted_thinking, emitting each block whole so that
+// AppendDelta accumulates the same blocks the unary decoder produces.
+func TestAnthropicStream_ThinkingSignatures(t *testing.T) {
+	body := "" +
+		"event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":1,"output_tokens":0}}}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":" think"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig_abc"}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"enc_xyz"}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":1}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"a\":1}"}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":2}` + "\n\n" +
+		"event: message_delta\n" +
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":2}}` + "\n\n" +
+		"event: message_stop\n" +
+		`data: {"type":"message_stop"}` + "\n\n"
+
+	s := newAnthropicStream(io.NopCloser(strings.NewReader(body)))
+
+	acc := Message{Role: RoleAssistant}
+
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+
+		if len(chunk.Choices) > 0 {
+			acc.AppendDelta(&chunk.Choices[0].Delta)
+		}
+	}
+
+	if acc.Thinking != "Let me think" {
+		t.Errorf("thinking = %q", acc.Thinking)
+	}
+
+	want := []ThinkingBlock{
+		{Type: "thinking", Thinking: "Let me think", Signature: "sig_abc"},
+		{Type: "redacted_thinking", Data: "enc_xyz"},
+	}
+	if got := thinkingBlocksOf(&acc); !reflect.DeepEqual(got, want) {
+		t.Errorf("thinking blocks = %+v\nwant %+v", got, want)
+	}
+
+	if got := extraBlocksOf(&acc); got != nil {
+		t.Errorf("extra blocks = %v, want nil", got)
+	}
+
+	// The accumulated message replays as signed blocks ahead of the tool call.
+	am, err := toAnthropicMessage(acc)
+	if err != nil {
+		t.Fatalf("toAnthropicMessage: %v", err)
+	}
+
+	var blocks []map[string]any
+	if err := json.Unmarshal(am.Content, &blocks); err != nil {
+		t.Fatalf("unmarshal blocks: %v", err)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3: %v", len(blocks), blocks)
+	}
+
+	if blocks[0]["type"] != "thinking" || blocks[0]["signature"] != "sig_abc" || blocks[0]["thinking"] != "Let me think" {
+		t.Errorf("block[0] = %v", blocks[0])
+	}
+
+	if blocks[1]["type"] != "redacted_thinking" || blocks[1]["data"] != "enc_xyz" {
+		t.Errorf("block[1] = %v", blocks[1])
+	}
+
+	if blocks[2]["type"] != "tool_use" {
+		t.Errorf("block[2] = %v", blocks[2])
+	}
+}
+
+// TestToAnthropicMessage_ReplaysThinkingBlocks verifies the preserved blocks
+// take precedence over Message.Thinking, and that a message with no preserved
+// blocks still falls back to the unsigned rebuild.
+func TestToAnthropicMessage_ReplaysThinkingBlocks(t *testing.T) {
+	msg := Message{
+		Role:     RoleAssistant,
+		Thinking: "joined text",
+		Content:  NewTextContent("Answer."),
+	}
+	ExtendMessage(&msg, &MessageExtension{
+		CacheBreakpoint: true,
+		ThinkingBlocks: []ThinkingBlock{
+			{Type: "thinking", Thinking: "a", Signature: "sig_a"},
+			{Type: "redacted_thinking", Data: "enc"},
+		},
+	})
+
+	am, err := toAnthropicMessage(msg)
+	if err != nil {
+		t.Fatalf("toAnthropicMessage: %v", err)
+	}
+
+	var blocks []ContentBlock
+	if err := json.Unmarshal(am.Content, &blocks); err != nil {
+		t.Fatalf("unmarshal blocks: %v", err)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3: %+v", len(blocks), blocks)
+	}
+
+	if blocks[0].Type != "thinking" || blocks[0].Thinking != "a" || blocks[0].Signature != "sig_a" {
+		t.Errorf("block[0] = %+v", blocks[0])
+	}
+
+	if blocks[1].Type != "redacted_thinking" || blocks[1].Data != "enc" || blocks[1].Thinking != "" {
+		t.Errorf("block[1] = %+v", blocks[1])
+	}
+
+	if blocks[2].Type != "text" || blocks[2].CacheControl == nil {
+		t.Errorf("block[2] = %+v, want text with cache_control", blocks[2])
+	}
+
+	// Thinking blocks alone (no text, no tool calls) still force block form.
+	only := Message{Role: RoleAssistant}
+	ExtendMessage(&only, &MessageExtension{
+		ThinkingBlocks: []ThinkingBlock{{Type: "redacted_thinking", Data: "enc"}},
+	})
+
+	am, err = toAnthropicMessage(only)
+	if err != nil {
+		t.Fatalf("toAnthropicMessage: %v", err)
+	}
+
+	if !strings.Contains(string(am.Content), `"redacted_thinking"`) {
+		t.Errorf("content = %s, want redacted_thinking block", am.Content)
+	}
+}
@@ -83,8 +83,9 @@ type RequestExtension struct {
 
 // MessageExtension carries the Anthropic-only per-message extension. On the
 // request side, CacheBreakpoint marks a prompt-cache boundary. On the
-// response side, this provider stores the content blocks the canonical layer
-// does not model in ExtraBlocks.
+// response side, this provider stores the signed thinking blocks in
+// ThinkingBlocks and the content blocks the canonical layer does not model in
+// ExtraBlocks; both are replayed when the message is sent back.
 type MessageExtension struct {
 	// CacheBreakpoint asks the translator to emit a cache boundary at the end
 	// of this message's content blocks (cache_control on the last block).
@@ -103,11 +104,34 @@ type MessageExtension struct {
 	// original delta of any subsequent unrecognized content_block_delta as
 	// separate elements; callers reassemble them if they need to.
 	ExtraBlocks []json.RawMessage
+
+	// ThinkingBlocks holds the assistant's thinking and redacted_thinking
+	// blocks in arrival order, together with their signatures. The canonical
+	// Message.Thinking only carries the joined text; Anthropic rejects an
+	// unsigned thinking block in a multi-turn tool-use exchange, so when this
+	// field is set the translator replays these blocks verbatim instead of
+	// rebuilding one from Message.Thinking.
+	ThinkingBlocks []ThinkingBlock
+}
+
+// ThinkingBlock is one thinking or redacted_thinking content block of an
+// assistant turn. A "thinking" block carries the text and its Signature; a
+// "redacted_thinking" block carries only the encrypted Data. The JSON tags
+// match the wire shape.
+type ThinkingBlock struct {
+	// Type is "thinking" or "redacted_thinking".
+	Type string `json:"type"`
+	// Thinking is the full text of a thinking block.
+	Thinking string `json:"thinking,omitempty"`
+	// Signature authenticates a thinking block; it is opaque to this wrapper.
+	Signature string `json:"signature,omitempty"`
+	// Data is the encrypted payload of a redacted_thinking block.
+	Data string `json:"data,omitempty"`
 }
 
 // MergeExtension implements ais.ExtensionMerger so streaming deltas
-// accumulate: ExtraBlocks concatenate in arrival order and the breakpoint
-// flag sticks. It returns a fresh value — neither the receiver nor the delta
+// accumulate: ExtraBlocks and ThinkingBlocks concatenate in arrival order and
+// the breakpoint flag sticks. It returns a fresh value — neither the receiver nor the delta
 // is mutated, so previously delivered chunks stay intact.
 func (e *MessageExtension) MergeExtension(delta any) any {
 	d, ok := delta.(*MessageExtension)
@@ -119,9 +143,17 @@ func (e *MessageExtension) MergeExtension(delta any) any {
 		CacheBreakpoint: e.CacheBreakpoint || d.CacheBreakpoint,
 	}
 
-	merged.ExtraBlocks = make([]json.RawMessage, 0, len(e.ExtraBlocks)+len(d.ExtraBlocks))
-	merged.ExtraBlocks = append(merged.ExtraBlocks, e.ExtraBlocks...)
-	merged.ExtraBlocks = append(merged.ExtraBlocks, d.ExtraBlocks...)
+	if len(e.ExtraBlocks)+len(d.ExtraBlocks) > 0 {
+		merged.ExtraBlocks = make([]json.RawMessage, 0, len(e.ExtraBlocks)+len(d.ExtraBlocks))
+		merged.ExtraBlocks = append(merged.ExtraBlocks, e.ExtraBlocks...)
+		merged.ExtraBlocks = append(merged.ExtraBlocks, d.ExtraBlocks...)
+	}
+
+	if len(e.ThinkingBlocks)+len(d.ThinkingBlocks) > 0 {
+		merged.ThinkingBlocks = make([]ThinkingBlock, 0, len(e.ThinkingBlocks)+len(d.ThinkingBlocks))
+		merged.ThinkingBlocks = append(merged.ThinkingBlocks, e.ThinkingBlocks...)
+		merged.ThinkingBlocks = append(merged.ThinkingBlocks, d.ThinkingBlocks...)
+	}
 
 	return merged
 }
@@ -177,6 +209,20 @@ type ChoiceExtension struct {
 	StopDetails *StopDetails
 }
 
+// BlockDelta marks the content block (the content_block_delta index) a
+// streamed text or thinking delta belongs to, written by this provider on
+// the ais.StreamChunkChoice of those deltas. It implements ais.BlockDelta:
+// ais.Accumulator joins different blocks with "\n" like the unary response
+// and does not merge the marker into the response.
+type BlockDelta struct {
+	Index int
+}
+
+// BlockIndex implements ais.BlockDelta.
+func (b *BlockDelta) BlockIndex() int {
+	return b.Index
+}
+
 // ResponseContainer is the server-side execution container returned
 // alongside a response. ExpiresAt is kept as the server-supplied string —
 // this wrapper neither parses nor acts on the expiry.
@@ -226,6 +272,25 @@ type UsageExtension struct {
 	InferenceGeo string
 }
 
+// CacheWrites implements ais.CacheWriteUsage. Without a TTL breakdown every
+// write counts as a 5-minute one, the API default.
+func (u *UsageExtension) CacheWrites() (ttl5m, ttl1h int) {
+	if u.CacheWrite5mTokens+u.CacheWrite1hTokens == 0 {
+		return u.CacheWriteTokens, 0
+	}
+
+	return u.CacheWrite5mTokens, u.CacheWrite1hTokens
+}
+
+// WebSearches implements ais.WebSearchUsage.
+func (u *UsageExtension) WebSearches() int {
+	if u.ServerToolUse == nil {
+		return 0
+	}
+
+	return u.ServerToolUse.WebSearchRequests
+}
+
 // --- setters (request side) ---
 
 // ExtendRequest attaches the Anthropic request extension to a canonical
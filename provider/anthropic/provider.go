@@ -51,19 +51,20 @@ type Options struct {
 	// Beta enables one or more Anthropic beta features via the
 	// "anthropic-beta" request header. Empty strings are ignored; on the wire
 	// the values are joined with commas. Unset omits the header.
-	Beta []string
+	Beta []string `json:"beta,omitempty"`
 
 	// Version overrides the "anthropic-version" request header. Empty keeps
 	// the default (anthropicAPIVersion).
-	Version string
+	Version string `json:"version,omitempty"`
 
 	// UserProfileID sets the "anthropic-user-profile-id" request header,
 	// associating requests with an end-user profile. Empty leaves it unset.
-	UserProfileID string
+	UserProfileID string `json:"user_profile_id,omitempty"`
 }
 
 // New constructs an Anthropic provider. The base URL is optional (it defaults
-// to the public endpoint). cfg.Options, when set, must be Options.
+// to the public endpoint). cfg.Options, when set, must be Options or its JSON
+// encoding as a json.RawMessage.
 func New(cfg ais.Config) (ais.ChatProvider, error) {
 	p := &provider{
 		apiKey:  cfg.APIKey,
@@ -71,6 +72,15 @@ func New(cfg ais.Config) (ais.ChatProvider, error) {
 		version: anthropicAPIVersion,
 	}
 
+	if raw, ok := cfg.Options.(json.RawMessage); ok {
+		var o Options
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return nil, fmt.Errorf("aimodel/anthropic: decode provider options: %w", err)
+		}
+
+		cfg.Options = o
+	}
+
 	switch o := cfg.Options.(type) {
 	case nil:
 	case Options:
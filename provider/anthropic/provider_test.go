@@ -18,7 +18,9 @@
 package anthropic
 
 import (
+	"encoding/json"
 	"net/http"
+	"slices"
 	"testing"
 
 	"github.com/vogo/aimodel/ais"
@@ -196,3 +198,17 @@ func TestNewRejectsUnknownOptions(t *testing.T) {
 		t.Fatal("expected error for unknown options type")
 	}
 }
+
+// TestNewDecodesJSONOptions verifies the factory accepts Options in their JSON
+// encoding, as configuration loaders pass them, and rejects malformed ones.
+func TestNewDecodesJSONOptions(t *testing.T) {
+	p := newProvider(t, json.RawMessage(`{"beta":["fast-mode"],"version":"2099-01-01","user_profile_id":"user_abc"}`))
+
+	if !slices.Equal(p.beta, []string{"fast-mode"}) || p.version != "2099-01-01" || p.userProfileID != "user_abc" {
+		t.Fatalf("provider = %+v", p)
+	}
+
+	if _, err := New(ais.Config{APIKey: "sk-ant-test", Options: json.RawMessage(`{"beta":"x"}`)}); err == nil {
+		t.Fatal("expected error for malformed options")
+	}
+}
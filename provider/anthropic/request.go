@@ -18,6 +18,7 @@
 package anthropic
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -342,24 +343,49 @@ func toAnthropicMessage(m ais.Message) (MessagesMessage, error) {
 		return toAnthropicToolResultMessage([]ais.Message{m})
 	}
 
-	cacheBreakpoint, err := messageCacheBreakpoint(&m)
+	ext, err := extensionOf[MessageExtension](m.Extensions, "Message")
 	if err != nil {
 		return MessagesMessage{}, err
 	}
 
+	cacheBreakpoint := ext != nil && ext.CacheBreakpoint
+
+	var thinkingBlocks []ThinkingBlock
+	if ext != nil {
+		thinkingBlocks = ext.ThinkingBlocks
+	}
+
 	// Assistant messages with thinking, tool calls, or both require content-block format.
-	if m.Role == ais.RoleAssistant && (m.Thinking != "" || len(m.ToolCalls) > 0) {
+	if m.Role == ais.RoleAssistant && (m.Thinking != "" || len(thinkingBlocks) > 0 || len(m.ToolCalls) > 0) {
 		var blocks []ContentBlock
 
-		if m.Thinking != "" {
+		switch {
+		case len(thinkingBlocks) > 0:
+			// Replay the signed blocks received from Anthropic verbatim;
+			// an unsigned rebuild from m.Thinking would be rejected.
+			for _, tb := range thinkingBlocks {
+				blocks = append(blocks, ContentBlock{
+					Type:      tb.Type,
+					Thinking:  tb.Thinking,
+					Signature: tb.Signature,
+					Data:      tb.Data,
+				})
+			}
+		case m.Thinking != "":
 			blocks = append(blocks, ContentBlock{
 				Type:     "thinking",
 				Thinking: m.Thinking,
 			})
 		}
 
-		text := m.Content.Text()
-		if text != "" {
+		if parts := m.Content.Parts(); len(parts) > 0 {
+			partBlocks, err := toAnthropicPartBlocks(parts)
+			if err != nil {
+				return MessagesMessage{}, err
+			}
+
+			blocks = append(blocks, partBlocks...)
+		} else if text := m.Content.Text(); text != "" {
 			blocks = append(blocks, ContentBlock{
 				Type: "text",
 				Text: text,
@@ -391,37 +417,9 @@ func toAnthropicMessage(m ais.Message) (MessagesMessage, error) {
 
 	// Multimodal content with parts.
 	if parts := m.Content.Parts(); len(parts) > 0 {
-		var blocks []ContentBlock
-
-		for _, p := range parts {
-			switch p.Type {
-			case "text":
-				blocks = append(blocks, ContentBlock{
-					Type: "text",
-					Text: p.Text,
-				})
-			case "image_url":
-				if p.ImageURL == nil {
-					continue
-				}
-
-				block := ContentBlock{Type: "image"}
-
-				if mediaType, b64Data, ok := parseDataURI(p.ImageURL.URL); ok {
-					block.Source = &ContentSource{
-						Type:      "base64",
-						MediaType: mediaType,
-						Data:      b64Data,
-					}
-				} else {
-					block.Source = &ContentSource{
-						Type: "url",
-						URL:  p.ImageURL.URL,
-					}
-				}
-
-				blocks = append(blocks, block)
-			}
+		blocks, err := toAnthropicPartBlocks(parts)
+		if err != nil {
+			return MessagesMessage{}, err
 		}
 
 		if cacheBreakpoint && len(blocks) > 0 {
@@ -464,6 +462,111 @@ func toAnthropicMessage(m ais.Message) (MessagesMessage, error) {
 	return am, nil
 }
 
+// toAnthropicPartBlocks converts multimodal content parts to content blocks:
+// text, images (inline data URIs or URLs) and documents. Audio and unknown
+// part types fail with an *ais.ContentPartError.
+func toAnthropicPartBlocks(parts []ais.ContentPart) ([]ContentBlock, error) {
+	var blocks []ContentBlock
+
+	for _, p := range parts {
+		switch p.Type {
+		case "text":
+			blocks = append(blocks, ContentBlock{
+				Type: "text",
+				Text: p.Text,
+			})
+		case "image_url":
+			if p.ImageURL == nil {
+				continue
+			}
+
+			block := ContentBlock{Type: "image"}
+
+			if mediaType, b64Data, ok := parseDataURI(p.ImageURL.URL); ok {
+				block.Source = &ContentSource{
+					Type:      "base64",
+					MediaType: mediaType,
+					Data:      b64Data,
+				}
+			} else {
+				block.Source = &ContentSource{
+					Type: "url",
+					URL:  p.ImageURL.URL,
+				}
+			}
+
+			blocks = append(blocks, block)
+		case "file":
+			block, err := toAnthropicDocumentBlock(p.File)
+			if err != nil {
+				return nil, err
+			}
+
+			blocks = append(blocks, block)
+		case "input_audio":
+			return nil, &ais.ContentPartError{
+				Provider: Name,
+				Type:     p.Type,
+				Reason:   "the Messages API accepts no audio input",
+			}
+		default:
+			return nil, &ais.ContentPartError{
+				Provider: Name,
+				Type:     p.Type,
+				Reason:   "unknown content part type",
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+// toAnthropicDocumentBlock maps a canonical file part onto a document block.
+// PDFs travel as a base64 or url source and plain text as a text source; any
+// other media type, and provider-side file IDs (which need the Files API),
+// fail with a *ais.ContentPartError.
+func toAnthropicDocumentBlock(f *ais.File) (ContentBlock, error) {
+	fail := func(reason string) (ContentBlock, error) {
+		return ContentBlock{}, &ais.ContentPartError{Provider: Name, Type: "file", Reason: reason}
+	}
+
+	if f == nil {
+		return fail("missing file payload")
+	}
+
+	block := ContentBlock{Type: "document", Title: f.Filename}
+
+	switch {
+	case f.FileData != "":
+		mediaType, b64Data, ok := parseDataURI(f.FileData)
+		if !ok {
+			return fail("file_data must be a base64 data URI")
+		}
+
+		switch mediaType {
+		case "application/pdf":
+			block.Source = &ContentSource{Type: "base64", MediaType: mediaType, Data: b64Data}
+		case "text/plain":
+			text, err := base64.StdEncoding.DecodeString(b64Data)
+			if err != nil {
+				return fail(fmt.Sprintf("decode text/plain data: %v", err))
+			}
+
+			block.Source = &ContentSource{Type: "text", MediaType: mediaType, Data: string(text)}
+		default:
+			return fail(fmt.Sprintf("unsupported document media type %q", mediaType))
+		}
+	case f.FileURL != "":
+		block.Source = &ContentSource{Type: "url", URL: f.FileURL}
+	case f.FileID != "":
+		return fail("file IDs are not supported; use file_data or file_url")
+	default:
+		return fail("missing file payload")
+	}
+
+	return block, nil
+}
+
 func convertToolChoice(tc any) *ToolChoice {
 	switch v := tc.(type) {
 	case string:
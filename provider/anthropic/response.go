@@ -35,10 +35,24 @@ func fromAnthropicResponse(ar *MessagesResponse) *ais.ChatResponse {
 
 	var extraBlocks []json.RawMessage
 
+	var thinkingBlocks []ThinkingBlock
+
 	for _, block := range ar.Content {
 		switch block.Type {
 		case "thinking":
 			thinkingParts = append(thinkingParts, block.Thinking)
+			thinkingBlocks = append(thinkingBlocks, ThinkingBlock{
+				Type:      "thinking",
+				Thinking:  block.Thinking,
+				Signature: block.Signature,
+			})
+		case "redacted_thinking":
+			// The encrypted payload has no canonical text; it only matters
+			// when the turn is sent back, so keep it for replay.
+			thinkingBlocks = append(thinkingBlocks, ThinkingBlock{
+				Type: "redacted_thinking",
+				Data: block.Data,
+			})
 		case "text":
 			textParts = append(textParts, block.Text)
 
@@ -76,8 +90,11 @@ func fromAnthropicResponse(ar *MessagesResponse) *ais.ChatResponse {
 		msg.Content = ais.NewTextContent(strings.Join(textParts, "\n"))
 	}
 
-	if len(extraBlocks) > 0 {
-		msg.Extensions.Set(Name, &MessageExtension{ExtraBlocks: extraBlocks})
+	if len(extraBlocks) > 0 || len(thinkingBlocks) > 0 {
+		msg.Extensions.Set(Name, &MessageExtension{
+			ExtraBlocks:    extraBlocks,
+			ThinkingBlocks: thinkingBlocks,
+		})
 	}
 
 	choice := ais.Choice{
@@ -105,34 +122,32 @@ func fromAnthropicResponse(ar *MessagesResponse) *ais.ChatResponse {
 	return cr
 }
 
-// anthropicCanonicalUsage builds a canonical Usage from an Anthropic usage
-// object, folding cached/created tokens into PromptTokens (as before). The
-// cross-provider counts stay canonical; cache-write totals, the per-TTL
-// breakdown, server-tool counts and the inference geography go into the
-// UsageExtension namespace (attached only when any of them is present).
+// parseDataURI parses a base64 data URI (e.g. "data:image/jpeg;base64,/9j..."
+// or "data:text/plain;charset=utf-8;base64,SGk=") and returns the media type
+// and base64-encoded data. Parameters other than the final ";base64" marker
+// are dropped; a URI without the marker is not accepted.
 func parseDataURI(uri string) (mediaType, data string, ok bool) {
-	const prefix = "data:"
-
-	if !strings.HasPrefix(uri, prefix) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
 		return "", "", false
 	}
 
-	// Format: data:<mediaType>;base64,<data>
-	rest := uri[len(prefix):]
-
-	semicolon := strings.Index(rest, ";")
-	if semicolon < 0 {
+	// Format: data:[<mediaType>][;<param>=<value>]...;base64,<data>
+	header, data, ok := strings.Cut(rest, ",")
+	if !ok {
 		return "", "", false
 	}
 
-	mediaType = rest[:semicolon]
-
-	rest = rest[semicolon+1:]
-	if !strings.HasPrefix(rest, "base64,") {
+	params := strings.Split(header, ";")
+	if len(params) < 2 || !strings.EqualFold(strings.TrimSpace(params[len(params)-1]), "base64") {
 		return "", "", false
 	}
 
-	data = rest[len("base64,"):]
+	mediaType = strings.TrimSpace(params[0])
+	if mediaType == "" {
+		// RFC 2397 default.
+		mediaType = "text/plain"
+	}
 
 	return mediaType, data, true
 }
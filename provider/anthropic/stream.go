@@ -34,9 +34,10 @@ func (p *provider) NewStreamDecoder(body io.Reader) ais.StreamDecoder {
 	sc.Buffer(make([]byte, 0, 64*1024), ais.MaxStreamLineSize)
 
 	return &streamDecoder{
-		sc:            sc,
-		blockToTool:   make(map[int]int),
-		unknownBlocks: make(map[int]bool),
+		sc:             sc,
+		blockToTool:    make(map[int]int),
+		unknownBlocks:  make(map[int]bool),
+		thinkingBlocks: make(map[int]*ThinkingBlock),
 	}
 }
 
@@ -51,6 +52,26 @@ func extraBlockDelta(raw json.RawMessage) ais.Message {
 	return m
 }
 
+// thinkingBlockDelta wraps one completed thinking or redacted_thinking block
+// as a delta message whose Anthropic extension carries it, so AppendDelta
+// accumulates the replayable blocks next to the streamed thinking text.
+func thinkingBlockDelta(tb ThinkingBlock) ais.Message {
+	var m ais.Message
+
+	m.Extensions.Set(Name, &MessageExtension{ThinkingBlocks: []ThinkingBlock{tb}})
+
+	return m
+}
+
+// blockDelta marks a text or thinking delta with its content block, so
+// ais.Accumulator joins blocks with "\n" like the unary response does.
+func blockDelta(index int) ais.Extensions {
+	var e ais.Extensions
+	e.Set(Name, &BlockDelta{Index: index})
+
+	return e
+}
+
 type streamDecoder struct {
 	sc *bufio.Scanner
 
@@ -70,6 +91,11 @@ type streamDecoder struct {
 	// type this wrapper does not model, so their subsequent deltas are
 	// preserved verbatim instead of being interpreted or dropped.
 	unknownBlocks map[int]bool
+
+	// thinkingBlocks buffers each open thinking block's text and signature by
+	// content block index. The block is emitted whole on content_block_stop,
+	// once its signature_delta has arrived.
+	thinkingBlocks map[int]*ThinkingBlock
 }
 
 //nolint:gocyclo // Faithful 1:1 port of the Anthropic SSE event switch.
@@ -174,7 +200,29 @@ func (d *streamDecoder) Next() (*ais.StreamChunk, error) {
 						},
 					},
 				}, nil
-			case "text", "thinking":
+			case "thinking":
+				d.thinkingBlocks[cbs.Index] = &ThinkingBlock{
+					Type:     "thinking",
+					Thinking: cbs.ContentBlock.Thinking,
+				}
+
+				continue
+			case "redacted_thinking":
+				// A redacted block arrives complete in its start event.
+				return &ais.StreamChunk{
+					ID:    d.msgID,
+					Model: d.model,
+					Choices: []ais.StreamChunkChoice{
+						{
+							Index: 0,
+							Delta: thinkingBlockDelta(ThinkingBlock{
+								Type: "redacted_thinking",
+								Data: cbs.ContentBlock.Data,
+							}),
+						},
+					},
+				}, nil
+			case "text":
 				continue
 			default:
 				// Unmodelled block (server_tool_use, a tool result, a
@@ -223,22 +271,32 @@ func (d *streamDecoder) Next() (*ais.StreamChunk, error) {
 			case "text_delta":
 				chunk.Choices = []ais.StreamChunkChoice{
 					{
-						Index: 0,
+						Index:      0,
+						Extensions: blockDelta(cbd.Index),
 						Delta: ais.Message{
 							Content: ais.NewTextContent(cbd.Delta.Text),
 						},
 					},
 				}
 			case "thinking_delta":
+				if tb := d.thinkingBlocks[cbd.Index]; tb != nil {
+					tb.Thinking += cbd.Delta.Thinking
+				}
+
 				chunk.Choices = []ais.StreamChunkChoice{
 					{
-						Index: 0,
+						Index:      0,
+						Extensions: blockDelta(cbd.Index),
 						Delta: ais.Message{
 							Thinking: cbd.Delta.Thinking,
 						},
 					},
 				}
 			case "signature_delta":
+				if tb := d.thinkingBlocks[cbd.Index]; tb != nil {
+					tb.Signature += cbd.Delta.Signature
+				}
+
 				continue
 			case "input_json_delta":
 				toolIdx, ok := d.blockToTool[cbd.Index]
@@ -323,7 +381,31 @@ func (d *streamDecoder) Next() (*ais.StreamChunk, error) {
 				Message: errResp.Error.Message,
 			}
 
-		case "ping", "content_block_stop":
+		case "content_block_stop":
+			var cbs ContentBlockStopEvent
+			if err := json.Unmarshal(data, &cbs); err != nil {
+				return nil, fmt.Errorf("aimodel: decode content_block_stop: %w", err)
+			}
+
+			tb := d.thinkingBlocks[cbs.Index]
+			if tb == nil {
+				continue
+			}
+
+			delete(d.thinkingBlocks, cbs.Index)
+
+			return &ais.StreamChunk{
+				ID:    d.msgID,
+				Model: d.model,
+				Choices: []ais.StreamChunkChoice{
+					{
+						Index: 0,
+						Delta: thinkingBlockDelta(*tb),
+					},
+				},
+			}, nil
+
+		case "ping":
 			continue
 		}
 	}
@@ -19,6 +19,11 @@ package anthropic
 
 import "github.com/vogo/aimodel/ais"
 
+// anthropicCanonicalUsage builds a canonical Usage from an Anthropic usage
+// object, folding cached/created tokens into PromptTokens (as before). The
+// cross-provider counts stay canonical; cache-write totals, the per-TTL
+// breakdown, server-tool counts and the inference geography go into the
+// UsageExtension namespace (attached only when any of them is present).
 func anthropicCanonicalUsage(u *MessagesUsage) ais.Usage {
 	cu := ais.Usage{
 		PromptTokens:     u.totalInputTokens(),
@@ -99,6 +104,3 @@ func mergeAnthropicUsage(base, next *MessagesUsage) {
 		base.ServiceTier = next.ServiceTier
 	}
 }
-
-// parseDataURI parses a data URI (e.g. "data:image/jpeg;base64,/9j...")
-// and returns the media type and base64-encoded data.
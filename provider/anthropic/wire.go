@@ -91,14 +91,21 @@ type OutputFormat struct {
 }
 
 type ContentBlock struct {
-	Type      string          `json:"type"`
-	Text      string          `json:"text,omitempty"`
-	Thinking  string          `json:"thinking,omitempty"`
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Thinking string `json:"thinking,omitempty"`
+	// Signature authenticates a thinking block; Anthropic requires it when the
+	// block is sent back in a later turn.
+	Signature string `json:"signature,omitempty"`
+	// Data holds the encrypted payload of a redacted_thinking block.
+	Data      string          `json:"data,omitempty"`
 	ID        string          `json:"id,omitempty"`
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
 	ToolUseID string          `json:"tool_use_id,omitempty"`
 	Source    *ContentSource  `json:"source,omitempty"`
+	// Title names a document block (mapped from the canonical filename).
+	Title string `json:"title,omitempty"`
 	// ResultContent holds the content for tool_result blocks.
 	ResultContent string `json:"content,omitempty"`
 	// CacheControl, when set, marks this block as a prompt-cache
@@ -290,6 +297,7 @@ type ContentBlockDelta struct {
 	Type        string `json:"type"`
 	Text        string `json:"text,omitempty"`
 	Thinking    string `json:"thinking,omitempty"`
+	Signature   string `json:"signature,omitempty"`
 	PartialJSON string `json:"partial_json,omitempty"`
 
 	// raw is the verbatim delta sub-object, kept for the same reason as
@@ -312,6 +320,11 @@ func (d *ContentBlockDelta) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type ContentBlockStopEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
 type MessageDeltaEvent struct {
 	Type  string         `json:"type"`
 	Delta MessageDelta   `json:"delta"`
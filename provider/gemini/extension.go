@@ -0,0 +1,245 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// This file is the public Gemini extension surface of the unified provider
+// extension channel (ais.Extensions). Request-side values configure
+// Gemini-only parameters; response-side values carry Gemini-only response
+// metadata written by this provider's translators. Every value lives under
+// the Name namespace of the node's Extensions map.
+//
+// Extension values are read-only once attached: the same value may be shared
+// by a request and its pipeline clones, so callers must not mutate a value
+// after setting it, and accessors return the stored value without copying.
+
+// FinishReason values Gemini surfaces verbatim — they have no canonical
+// equivalent, so mapGeminiFinishReason passes them through. Named here for
+// readability; callers should treat any non-canonical FinishReason as opaque.
+const (
+	// FinishReasonMalformedFunctionCall maps Gemini's
+	// "MALFORMED_FUNCTION_CALL" (the model produced an unparsable function
+	// call).
+	FinishReasonMalformedFunctionCall ais.FinishReason = "MALFORMED_FUNCTION_CALL"
+	// FinishReasonOther maps Gemini's catch-all "OTHER".
+	FinishReasonOther ais.FinishReason = "OTHER"
+)
+
+// RequestExtension carries the Gemini-only request parameters. Attach it with
+// ExtendRequest; the translator reads it before building the wire body.
+type RequestExtension struct {
+	// SafetySettings override the default blocking threshold per harm
+	// category.
+	SafetySettings []SafetySetting
+
+	// CachedContent names a context cache ("cachedContents/…") to prefix the
+	// request with. Empty omits the field.
+	CachedContent string
+
+	// Tools are native tool entries appended after the canonical function
+	// declarations — Gemini's built-in tools such as
+	// Tool{GoogleSearch: &struct{}{}}.
+	Tools []Tool
+
+	// IncludeThoughts asks for thought summaries even when the canonical
+	// request sets no Thinking (e.g. when only ReasoningEffort is set).
+	IncludeThoughts bool
+}
+
+// MessageExtension carries the Gemini-only per-message extension. On the
+// response side, this provider stores the native parts of every model turn in
+// Parts, so what the canonical message cannot hold — thought signatures,
+// inline media, code execution — survives. When the message is sent back,
+// the translator replays Parts verbatim instead of rebuilding the turn from
+// Content and ToolCalls; drop the extension to send an edited turn.
+type MessageExtension struct {
+	Parts []Part
+}
+
+// MergeExtension implements ais.ExtensionMerger so streaming deltas
+// accumulate: Parts concatenate in arrival order. It returns a fresh value —
+// neither the receiver nor the delta is mutated.
+func (e *MessageExtension) MergeExtension(delta any) any {
+	d, ok := delta.(*MessageExtension)
+	if !ok || d == nil {
+		return e
+	}
+
+	merged := &MessageExtension{}
+	if len(e.Parts)+len(d.Parts) > 0 {
+		merged.Parts = make([]Part, 0, len(e.Parts)+len(d.Parts))
+		merged.Parts = append(merged.Parts, e.Parts...)
+		merged.Parts = append(merged.Parts, d.Parts...)
+	}
+
+	return merged
+}
+
+// ChoiceExtension carries the Gemini-only per-candidate response metadata,
+// written by this provider on ais.Choice (unary) and on the ais.StreamChunkChoice
+// that reports it (streaming).
+type ChoiceExtension struct {
+	// FinishReason is Gemini's verbatim finishReason (e.g. "SAFETY",
+	// "RECITATION"), which the canonical FinishReason folds together.
+	FinishReason string
+	// FinishMessage explains FinishReason when Gemini provides one.
+	FinishMessage string
+	// SafetyRatings are the candidate's per-category harm assessments.
+	SafetyRatings []SafetyRating
+	// CitationMetadata and GroundingMetadata are kept as the raw JSON objects
+	// Gemini returned; nil when absent.
+	CitationMetadata  json.RawMessage
+	GroundingMetadata json.RawMessage
+}
+
+// ResponseExtension carries the Gemini-only response-level metadata, written
+// by this provider on ais.ChatResponse (unary) and on any ais.StreamChunk
+// that reports it (streaming).
+type ResponseExtension struct {
+	// PromptFeedback reports the safety assessment of the prompt; nil when the
+	// response carries none.
+	PromptFeedback *PromptFeedback
+}
+
+// UsageExtension carries the Gemini-only usage accounting, written by this
+// provider on ais.Usage.
+type UsageExtension struct {
+	// ToolUsePromptTokens counts the prompt tokens of tool-use results
+	// (usageMetadata.toolUsePromptTokenCount). It is included in the
+	// canonical TotalTokens but not in PromptTokens.
+	ToolUsePromptTokens int
+}
+
+// ExtraPromptTokens implements ais.ExtraPromptUsage.
+func (u *UsageExtension) ExtraPromptTokens() int {
+	return u.ToolUsePromptTokens
+}
+
+// --- setters (request side) ---
+
+// ExtendRequest attaches the Gemini request extension to a canonical request.
+// Passing nil removes a previously attached extension.
+func ExtendRequest(r *ais.ChatRequest, ext *RequestExtension) {
+	setExtension(&r.Extensions, ext)
+}
+
+// ExtendMessage attaches the Gemini message extension to a canonical message.
+// Passing nil removes a previously attached extension.
+func ExtendMessage(m *ais.Message, ext *MessageExtension) {
+	setExtension(&m.Extensions, ext)
+}
+
+// setExtension stores ext under this provider's namespace; a typed nil
+// deletes the entry so the translator sees a genuinely absent extension.
+func setExtension[T any](exts *ais.Extensions, ext *T) {
+	if ext == nil {
+		delete(*exts, Name)
+
+		return
+	}
+
+	exts.Set(Name, ext)
+}
+
+// --- accessors ---
+
+// RequestExtensionOf returns the Gemini request extension attached to r, or
+// nil when absent. A value of any other type also yields nil — the translator
+// rejects such a value with a *ais.ExtensionTypeError before any network I/O.
+func RequestExtensionOf(r *ais.ChatRequest) *RequestExtension {
+	ext, _ := extensionOf[RequestExtension](r.Extensions, "")
+
+	return ext
+}
+
+// MessageExtensionOf returns the Gemini message extension attached to m, or
+// nil when absent (same type contract as RequestExtensionOf).
+func MessageExtensionOf(m *ais.Message) *MessageExtension {
+	ext, _ := extensionOf[MessageExtension](m.Extensions, "")
+
+	return ext
+}
+
+// ChoiceExtensionOf returns the Gemini per-candidate metadata of a unary
+// choice, or nil when the response carries none.
+func ChoiceExtensionOf(c *ais.Choice) *ChoiceExtension {
+	ext, _ := extensionOf[ChoiceExtension](c.Extensions, "")
+
+	return ext
+}
+
+// ChunkChoiceExtensionOf returns the Gemini per-candidate metadata of a
+// stream chunk choice, or nil.
+func ChunkChoiceExtensionOf(c *ais.StreamChunkChoice) *ChoiceExtension {
+	ext, _ := extensionOf[ChoiceExtension](c.Extensions, "")
+
+	return ext
+}
+
+// ResponseExtensionOf returns the Gemini response-level metadata of a unary
+// response, or nil when the response carries none.
+func ResponseExtensionOf(r *ais.ChatResponse) *ResponseExtension {
+	ext, _ := extensionOf[ResponseExtension](r.Extensions, "")
+
+	return ext
+}
+
+// ChunkExtensionOf returns the Gemini response-level metadata of a stream
+// chunk, or nil.
+func ChunkExtensionOf(c *ais.StreamChunk) *ResponseExtension {
+	ext, _ := extensionOf[ResponseExtension](c.Extensions, "")
+
+	return ext
+}
+
+// UsageExtensionOf returns the Gemini usage accounting attached to u, or nil
+// when the response carries none.
+func UsageExtensionOf(u *ais.Usage) *UsageExtension {
+	ext, _ := extensionOf[UsageExtension](u.Extensions, "")
+
+	return ext
+}
+
+// extensionOf reads this provider's namespace from an extension map. A
+// missing or nil entry is equivalent to a zero value (nil, no error). A value
+// of any other type yields a *ais.ExtensionTypeError naming the canonical
+// node.
+func extensionOf[T any](exts ais.Extensions, node string) (*T, error) {
+	v, ok := exts[Name]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	ext, ok := v.(*T)
+	if !ok {
+		return nil, &ais.ExtensionTypeError{
+			Provider: Name,
+			Node:     node,
+			Want:     fmt.Sprintf("*%T", *new(T)),
+			Value:    v,
+		}
+	}
+
+	return ext, nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// collectStream drains a decoder over body and returns every chunk.
+func collectStream(t *testing.T, body string) ([]*ais.StreamChunk, error) {
+	t.Helper()
+
+	dec := newProvider(t, nil).NewStreamDecoder(strings.NewReader(body))
+
+	var chunks []*ais.StreamChunk
+
+	for {
+		chunk, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks, nil
+		}
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// TestGeminiStreamText verifies text and thought deltas, the terminal finish
+// reason and the cumulative usage on the final chunk.
+func TestGeminiStreamText(t *testing.T) {
+	body := "" +
+		`data: {"responseId":"r1","modelVersion":"gemini-2.5-flash","candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"hmm","thought":true}]}}],"usageMetadata":{"promptTokenCount":3}}` + "\n\n" +
+		`data: {"responseId":"r1","candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"Hi"}]}}]}` + "\n\n" +
+		`data: {"responseId":"r1","candidates":[{"index":0,"content":{"role":"model","parts":[{"text":" there"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2,"thoughtsTokenCount":1,"totalTokenCount":6}}` + "\n\n"
+
+	chunks, err := collectStream(t, body)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Thinking != "hmm" || chunks[0].Model != "gemini-2.5-flash" {
+		t.Errorf("first chunk = %+v", chunks[0].Choices[0].Delta)
+	}
+
+	var text strings.Builder
+	for _, c := range chunks {
+		text.WriteString(c.Choices[0].Delta.Content.Text())
+	}
+	if text.String() != "Hi there" {
+		t.Errorf("text = %q", text.String())
+	}
+
+	last := chunks[2]
+	if fr := last.Choices[0].FinishReason; fr == nil || *fr != string(ais.FinishReasonStop) {
+		t.Errorf("finish_reason = %v", fr)
+	}
+	if u := last.Usage; u == nil || u.CompletionTokens != 3 || u.ReasoningTokens != 1 || u.TotalTokens != 6 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+// TestGeminiStreamToolCalls verifies whole function calls arriving across
+// chunks get consecutive tool indexes and turn STOP into tool_calls.
+func TestGeminiStreamToolCalls(t *testing.T) {
+	body := "" +
+		`data: {"candidates":[{"index":0,"content":{"role":"model","parts":[{"functionCall":{"name":"a","args":{}},"thoughtSignature":"sig"}]}}]}` + "\n\n" +
+		`data: {"candidates":[{"index":0,"content":{"role":"model","parts":[{"functionCall":{"id":"c2","name":"b","args":{"x":1}}}]},"finishReason":"STOP"}]}` + "\n\n"
+
+	chunks, err := collectStream(t, body)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	first := chunks[0].Choices[0].Delta.ToolCalls
+	second := chunks[1].Choices[0].Delta.ToolCalls
+	if len(first) != 1 || first[0].Index != 0 || first[0].ID != "a" || first[0].Function.Arguments != "{}" {
+		t.Errorf("first call = %+v", first)
+	}
+	if len(second) != 1 || second[0].Index != 1 || second[0].ID != "c2" || second[0].Function.Arguments != `{"x":1}` {
+		t.Errorf("second call = %+v", second)
+	}
+	if fr := chunks[1].Choices[0].FinishReason; fr == nil || *fr != string(ais.FinishReasonToolCalls) {
+		t.Errorf("finish_reason = %v, want tool_calls", fr)
+	}
+
+	ext := MessageExtensionOf(&chunks[0].Choices[0].Delta)
+	if ext == nil || ext.Parts[0].ThoughtSignature != "sig" {
+		t.Errorf("delta extension = %+v", ext)
+	}
+}
+
+// TestGeminiStreamError verifies an in-band error object surfaces as an
+// *ais.APIError.
+func TestGeminiStreamError(t *testing.T) {
+	body := `data: {"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED"}}` + "\n\n"
+
+	_, err := collectStream(t, body)
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) || apiErr.Type != "RESOURCE_EXHAUSTED" || apiErr.Message != "quota exceeded" {
+		t.Fatalf("err = %v, want *ais.APIError", err)
+	}
+}
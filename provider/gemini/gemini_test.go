@@ -0,0 +1,311 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestToGeminiRequestBasics verifies the system instruction, multimodal user
+// parts, function declarations, tool choice, generation config and the
+// request extension.
+func TestToGeminiRequestBasics(t *testing.T) {
+	req := &ais.ChatRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []ais.Message{
+			{Role: ais.RoleSystem, Content: ais.NewTextContent("be brief")},
+			{Role: ais.RoleUser, Content: ais.NewPartsContent(
+				ais.ContentPart{Type: "text", Text: "what is this?"},
+				ais.ContentPart{Type: "image_url", ImageURL: &ais.ImageURL{URL: "data:image/png;base64,iVBOR"}},
+				ais.ContentPart{Type: "input_audio", InputAudio: &ais.InputAudio{Data: "UklGRg==", Format: "wav"}},
+				ais.ContentPart{Type: "file", File: &ais.File{FileID: "https://generativelanguage.googleapis.com/v1beta/files/abc"}},
+			)},
+		},
+		Temperature:         new(0.2),
+		MaxCompletionTokens: new(256),
+		Stop:                []string{"END"},
+		Tools: []ais.Tool{{Type: "function", Function: ais.FunctionDefinition{
+			Name: "lookup", Description: "look up", Parameters: map[string]any{"type": "object"},
+		}}},
+		ToolChoice:     map[string]any{"type": "function", "function": map[string]any{"name": "lookup"}},
+		ResponseFormat: map[string]any{"type": "json_schema", "json_schema": map[string]any{"schema": map[string]any{"type": "object"}}},
+	}
+	ExtendRequest(req, &RequestExtension{
+		SafetySettings: []SafetySetting{{Category: "HARM_CATEGORY_HATE_SPEECH", Threshold: "BLOCK_ONLY_HIGH"}},
+		Tools:          []Tool{{GoogleSearch: &struct{}{}}},
+	})
+
+	gr, err := toGeminiRequest(req)
+	if err != nil {
+		t.Fatalf("toGeminiRequest: %v", err)
+	}
+
+	if gr.SystemInstruction == nil || gr.SystemInstruction.Parts[0].Text != "be brief" {
+		t.Errorf("systemInstruction = %+v", gr.SystemInstruction)
+	}
+	if len(gr.Contents) != 1 || gr.Contents[0].Role != "user" {
+		t.Fatalf("contents = %+v", gr.Contents)
+	}
+
+	parts := gr.Contents[0].Parts
+	if len(parts) != 4 {
+		t.Fatalf("parts len = %d, want 4", len(parts))
+	}
+	if b := parts[1].InlineData; b == nil || b.MIMEType != "image/png" || b.Data != "iVBOR" {
+		t.Errorf("image part = %+v", parts[1])
+	}
+	if b := parts[2].InlineData; b == nil || b.MIMEType != "audio/wav" {
+		t.Errorf("audio part = %+v", parts[2])
+	}
+	if f := parts[3].FileData; f == nil || !strings.HasSuffix(f.FileURI, "/files/abc") {
+		t.Errorf("file part = %+v", parts[3])
+	}
+
+	if len(gr.Tools) != 2 || gr.Tools[0].FunctionDeclarations[0].Name != "lookup" || gr.Tools[1].GoogleSearch == nil {
+		t.Errorf("tools = %+v", gr.Tools)
+	}
+	if fc := gr.ToolConfig.FunctionCallingConfig; fc.Mode != "ANY" || len(fc.AllowedFunctionNames) != 1 {
+		t.Errorf("functionCallingConfig = %+v", fc)
+	}
+
+	gc := gr.GenerationConfig
+	if *gc.Temperature != 0.2 || *gc.MaxOutputTokens != 256 || gc.StopSequences[0] != "END" {
+		t.Errorf("generationConfig = %+v", gc)
+	}
+	if gc.ResponseMIMEType != "application/json" || gc.ResponseJSONSchema == nil {
+		t.Errorf("response format = %q %v", gc.ResponseMIMEType, gc.ResponseJSONSchema)
+	}
+	if len(gr.SafetySettings) != 1 {
+		t.Errorf("safetySettings = %+v", gr.SafetySettings)
+	}
+}
+
+// TestToGeminiThinkingConfig verifies the canonical thinking controls map to
+// thinkingBudget / thinkingLevel / includeThoughts.
+func TestToGeminiThinkingConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       ais.ChatRequest
+		budget    *int
+		level     string
+		thoughts  bool
+		wantNoCfg bool
+	}{
+		{name: "unset", wantNoCfg: true},
+		{name: "enabled budget", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "enabled", BudgetTokens: 1024}}, budget: new(1024), thoughts: true},
+		{name: "adaptive", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "adaptive"}}, budget: new(-1), thoughts: true},
+		{name: "disabled", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "disabled"}}, budget: new(0)},
+		{name: "omitted display", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "adaptive", Display: "omitted"}}, budget: new(-1)},
+		{name: "effort supersedes budget", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "enabled", BudgetTokens: 1024}, ReasoningEffort: ais.ReasoningEffortLow}, level: "low", thoughts: true},
+		{name: "xhigh", req: ais.ChatRequest{ReasoningEffort: ais.ReasoningEffortXHigh}, level: "high"},
+		{name: "none", req: ais.ChatRequest{ReasoningEffort: ais.ReasoningEffortNone}, budget: new(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := toGeminiThinkingConfig(&tt.req, &RequestExtension{})
+			if tt.wantNoCfg {
+				if tc != nil {
+					t.Errorf("thinkingConfig = %+v, want nil", tc)
+				}
+
+				return
+			}
+			if tc == nil {
+				t.Fatal("thinkingConfig = nil")
+			}
+			if (tc.ThinkingBudget == nil) != (tt.budget == nil) || (tt.budget != nil && *tc.ThinkingBudget != *tt.budget) {
+				t.Errorf("thinkingBudget = %v, want %v", tc.ThinkingBudget, tt.budget)
+			}
+			if tc.ThinkingLevel != tt.level || tc.IncludeThoughts != tt.thoughts {
+				t.Errorf("thinkingConfig = %+v", tc)
+			}
+		})
+	}
+}
+
+// TestToGeminiRequestToolRoundTrip verifies an assistant function call and
+// its tool results translate back, resolving the function name from the
+// preceding call and wrapping non-object results.
+func TestToGeminiRequestToolRoundTrip(t *testing.T) {
+	req := &ais.ChatRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []ais.Message{
+			{Role: ais.RoleUser, Content: ais.NewTextContent("weather?")},
+			{Role: ais.RoleAssistant, ToolCalls: []ais.ToolCall{
+				{ID: "get_weather", Type: "function", Function: ais.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "call-2", Type: "function", Function: ais.FunctionCall{Name: "get_time", Arguments: `{}`}},
+			}},
+			{Role: ais.RoleTool, ToolCallID: "get_weather", Content: ais.NewTextContent(`{"temp":21}`)},
+			{Role: ais.RoleTool, ToolCallID: "call-2", Content: ais.NewTextContent("noon")},
+		},
+	}
+
+	gr, err := toGeminiRequest(req)
+	if err != nil {
+		t.Fatalf("toGeminiRequest: %v", err)
+	}
+
+	if len(gr.Contents) != 3 {
+		t.Fatalf("contents len = %d, want 3 (tool results merged)", len(gr.Contents))
+	}
+
+	model := gr.Contents[1]
+	if model.Role != "model" || len(model.Parts) != 2 {
+		t.Fatalf("model turn = %+v", model)
+	}
+	if fc := model.Parts[0].FunctionCall; fc.ID != "" || fc.Name != "get_weather" || fc.Args["city"] != "Paris" {
+		t.Errorf("synthesized-ID call = %+v, want no id sent", fc)
+	}
+	if fc := model.Parts[1].FunctionCall; fc.ID != "call-2" {
+		t.Errorf("native-ID call = %+v", fc)
+	}
+
+	results := gr.Contents[2]
+	if results.Role != "user" || len(results.Parts) != 2 {
+		t.Fatalf("results turn = %+v", results)
+	}
+	if fr := results.Parts[0].FunctionResponse; fr.Name != "get_weather" || fr.Response["temp"] != float64(21) {
+		t.Errorf("object result = %+v", fr)
+	}
+	if fr := results.Parts[1].FunctionResponse; fr.Name != "get_time" || fr.ID != "call-2" || fr.Response["result"] != "noon" {
+		t.Errorf("text result = %+v", fr)
+	}
+}
+
+// TestToGeminiRequestReplaysParts verifies a model turn carrying the message
+// extension is sent back verbatim on the wire, thought signature included.
+func TestToGeminiRequestReplaysParts(t *testing.T) {
+	assistant := ais.Message{Role: ais.RoleAssistant, Content: ais.NewTextContent("ignored")}
+	ExtendMessage(&assistant, &MessageExtension{Parts: []Part{
+		{FunctionCall: &FunctionCall{Name: "lookup"}, ThoughtSignature: "sig-1"},
+	}})
+
+	gr := requestBody(t, &ais.ChatRequest{Model: "m", Messages: []ais.Message{assistant}})
+
+	parts := gr.Contents[0].Parts
+	if len(parts) != 1 || parts[0].ThoughtSignature != "sig-1" || parts[0].FunctionCall.Name != "lookup" {
+		t.Errorf("replayed parts = %+v", parts)
+	}
+}
+
+// TestToGeminiRequestRejects verifies unrepresentable input fails before any
+// network I/O with a typed error.
+func TestToGeminiRequestRejects(t *testing.T) {
+	var cpe *ais.ContentPartError
+	_, err := toGeminiRequest(&ais.ChatRequest{Model: "m", Messages: []ais.Message{{
+		Role: ais.RoleUser, Content: ais.NewPartsContent(ais.ContentPart{Type: "video_url"}),
+	}}})
+	if !errors.As(err, &cpe) || cpe.Provider != Name {
+		t.Errorf("unknown part err = %v, want *ais.ContentPartError", err)
+	}
+
+	_, err = toGeminiRequest(&ais.ChatRequest{Model: "m", Tools: []ais.Tool{{Type: "web_search_20260209"}}})
+	if err == nil {
+		t.Error("non-function tool accepted")
+	}
+
+	req := &ais.ChatRequest{Model: "m"}
+	req.Extensions.Set(Name, "wrong")
+
+	var ete *ais.ExtensionTypeError
+	if _, err := toGeminiRequest(req); !errors.As(err, &ete) {
+		t.Errorf("mis-typed extension err = %v, want *ais.ExtensionTypeError", err)
+	}
+}
+
+// TestFromGeminiResponse verifies text, thoughts, function calls, finish
+// reason, usage and the Gemini-only extensions.
+func TestFromGeminiResponse(t *testing.T) {
+	const body = `{
+		"responseId": "resp-1",
+		"modelVersion": "gemini-2.5-flash",
+		"candidates": [{
+			"index": 0,
+			"content": {"role": "model", "parts": [
+				{"text": "planning", "thought": true},
+				{"text": "Calling."},
+				{"functionCall": {"name": "lookup", "args": {"q": "go"}}, "thoughtSignature": "sig-1"}
+			]},
+			"finishReason": "STOP",
+			"safetyRatings": [{"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"}]
+		}],
+		"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "thoughtsTokenCount": 7, "cachedContentTokenCount": 4, "toolUsePromptTokenCount": 2, "totalTokenCount": 24}
+	}`
+
+	cr, err := newProvider(t, nil).ParseChatResponse(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("ParseChatResponse: %v", err)
+	}
+
+	if cr.ID != "resp-1" || cr.Model != "gemini-2.5-flash" {
+		t.Errorf("response = %+v", cr)
+	}
+
+	msg := cr.Choices[0].Message
+	if msg.Content.Text() != "Calling." || msg.Thinking != "planning" {
+		t.Errorf("message = %q thinking %q", msg.Content.Text(), msg.Thinking)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "lookup" || msg.ToolCalls[0].Function.Arguments != `{"q":"go"}` {
+		t.Errorf("tool calls = %+v", msg.ToolCalls)
+	}
+	if cr.Choices[0].FinishReason != ais.FinishReasonToolCalls {
+		t.Errorf("finish_reason = %q, want tool_calls", cr.Choices[0].FinishReason)
+	}
+
+	if ext := MessageExtensionOf(&msg); ext == nil || len(ext.Parts) != 3 || ext.Parts[2].ThoughtSignature != "sig-1" {
+		t.Errorf("message extension = %+v", ext)
+	}
+	if ext := ChoiceExtensionOf(&cr.Choices[0]); ext == nil || ext.FinishReason != "STOP" || len(ext.SafetyRatings) != 1 {
+		t.Errorf("choice extension = %+v", ext)
+	}
+
+	u := cr.Usage
+	if u.PromptTokens != 10 || u.CompletionTokens != 12 || u.ReasoningTokens != 7 || u.CacheReadTokens != 4 || u.TotalTokens != 24 {
+		t.Errorf("usage = %+v", u)
+	}
+	if ext := UsageExtensionOf(&u); ext == nil || ext.ToolUsePromptTokens != 2 {
+		t.Errorf("usage extension = %+v", ext)
+	}
+}
+
+// TestMapGeminiFinishReason verifies the canonical folding of finish reasons.
+func TestMapGeminiFinishReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		tools  bool
+		want   ais.FinishReason
+	}{
+		{"STOP", false, ais.FinishReasonStop},
+		{"STOP", true, ais.FinishReasonToolCalls},
+		{"MAX_TOKENS", false, ais.FinishReasonLength},
+		{"SAFETY", false, ais.FinishReasonContentFilter},
+		{"RECITATION", false, ais.FinishReasonContentFilter},
+		{"MALFORMED_FUNCTION_CALL", false, FinishReasonMalformedFunctionCall},
+	}
+
+	for _, tt := range tests {
+		if got := mapGeminiFinishReason(tt.reason, tt.tools); got != tt.want {
+			t.Errorf("mapGeminiFinishReason(%q, %v) = %q, want %q", tt.reason, tt.tools, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const maxNativeBodySize = 1 << 20
+
+// Client calls the Gemini API without canonical translation.
+type Client struct {
+	apiKey, baseURL, version string
+	httpClient               *http.Client
+}
+
+// ClientOption configures a native Client.
+type ClientOption func(*Client)
+
+// WithBaseURL overrides the Gemini API base URL.
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) { c.baseURL = strings.TrimRight(url, "/") }
+}
+
+// WithHTTPClient supplies the HTTP transport used by the client.
+func WithHTTPClient(client *http.Client) ClientOption {
+	if client == nil {
+		panic("aimodel/gemini: nil HTTP client")
+	}
+	return func(c *Client) { c.httpClient = client }
+}
+
+// WithVersion overrides the API version path segment (default "v1beta").
+func WithVersion(version string) ClientOption {
+	return func(c *Client) {
+		if version != "" {
+			c.version = version
+		}
+	}
+}
+
+// NewClient constructs a native Gemini client.
+func NewClient(apiKey string, options ...ClientOption) *Client {
+	client := &Client{
+		apiKey:     apiKey,
+		baseURL:    geminiDefaultBaseURL,
+		version:    geminiAPIVersion,
+		httpClient: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// HTTPError reports a non-2xx Gemini response and retains its bounded body.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	Body       json.RawMessage
+	Err        error
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("gemini: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+func (c *Client) request(ctx context.Context, model string, input *GenerateContentRequest, method string) (*http.Response, error) {
+	if input == nil {
+		return nil, fmt.Errorf("gemini: nil generate content request")
+	}
+	endpoint, ok := modelURL(c.baseURL, c.version, model, method)
+	if !ok {
+		return nil, fmt.Errorf("gemini: model is required")
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: marshal generate content request: %w", err)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gemini: create generate content request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("x-goog-api-key", c.apiKey)
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: send generate content request: %w", err)
+	}
+	return response, nil
+}
+
+func parseNativeError(response *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxNativeBodySize))
+	result := &HTTPError{
+		StatusCode: response.StatusCode,
+		Body:       append(json.RawMessage(nil), body...),
+		Message:    string(body),
+		Err:        err,
+	}
+	if err != nil {
+		result.Message = "failed to read error response"
+		return result
+	}
+	var wire ErrorResponse
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []ErrorResponse
+		if json.Unmarshal(trimmed, &list) == nil && len(list) > 0 {
+			wire = list[0]
+		}
+	} else {
+		_ = json.Unmarshal(trimmed, &wire)
+	}
+	if wire.Error.Message != "" {
+		result.Status = wire.Error.Status
+		result.Message = wire.Error.Message
+	}
+	return result
+}
+
+// GenerateContent performs a non-streaming native generateContent call.
+func (c *Client) GenerateContent(ctx context.Context, model string, request *GenerateContentRequest) (*GenerateContentResponse, error) {
+	response, err := c.request(ctx, model, request, "generateContent")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, parseNativeError(response)
+	}
+	var result GenerateContentResponse
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("gemini: decode generate content response: %w", err)
+	}
+	return &result, nil
+}
+
+// ContentStream reads native streamGenerateContent responses without
+// canonical aggregation.
+type ContentStream struct {
+	body io.ReadCloser
+	scan *bufio.Scanner
+	once sync.Once
+}
+
+// StreamGenerateContent starts a native streamGenerateContent call with SSE
+// framing.
+func (c *Client) StreamGenerateContent(ctx context.Context, model string, request *GenerateContentRequest) (*ContentStream, error) {
+	response, err := c.request(ctx, model, request, "streamGenerateContent?alt=sse")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		defer func() { _ = response.Body.Close() }()
+		return nil, parseNativeError(response)
+	}
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxNativeBodySize)
+	return &ContentStream{body: response.Body, scan: scanner}, nil
+}
+
+// Recv returns the next streamed response in arrival order, or io.EOF.
+func (s *ContentStream) Recv() (*GenerateContentResponse, error) {
+	for s.scan.Scan() {
+		data, ok := strings.CutPrefix(s.scan.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var result GenerateContentResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &result); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("gemini: decode stream chunk: %w", err)
+		}
+		if result.Error != nil {
+			_ = s.Close()
+			return nil, &HTTPError{StatusCode: result.Error.Code, Status: result.Error.Status, Message: result.Error.Message}
+		}
+		return &result, nil
+	}
+	if err := s.scan.Err(); err != nil {
+		_ = s.Close()
+		return nil, fmt.Errorf("gemini: read stream: %w", err)
+	}
+	_ = s.Close()
+	return nil, io.EOF
+}
+
+// Close releases the response body and is safe to call repeatedly.
+func (s *ContentStream) Close() error {
+	var err error
+	s.once.Do(func() { err = s.body.Close() })
+	return err
+}
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNativeGenerateContent(t *testing.T) {
+	var got GenerateContentRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-2.5-flash:generateContent" || r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("request = %s headers=%v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]},"finishReason":"STOP"}],"modelVersion":"gemini-2.5-flash"}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	req := &GenerateContentRequest{Contents: []Content{{Role: "user", Parts: []Part{{Text: "hello"}}}}}
+	resp, err := NewClient("key", WithBaseURL(s.URL), WithHTTPClient(s.Client())).GenerateContent(context.Background(), "models/gemini-2.5-flash", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Contents[0].Parts[0].Text != "hello" || resp.Candidates[0].Content.Parts[0].Text != "hi" {
+		t.Fatalf("got=%+v resp=%+v", got, resp)
+	}
+}
+
+func TestNativeStreamGenerateContent(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1alpha/models/m:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("request = %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		if _, err := io.WriteString(w, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"a\"}]}}]}\n\n"); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	stream, err := NewClient("key", WithBaseURL(s.URL), WithHTTPClient(s.Client()), WithVersion("v1alpha")).StreamGenerateContent(context.Background(), "m", &GenerateContentRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Candidates[0].Content.Parts[0].Text != "a" {
+		t.Fatalf("resp=%+v", resp)
+	}
+	if _, err = stream.Recv(); err != io.EOF {
+		t.Fatalf("EOF=%v", err)
+	}
+	if err = stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNativeHTTPError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := io.WriteString(w, `[{"error":{"code":400,"message":"bad model","status":"INVALID_ARGUMENT"}}]`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	_, err := NewClient("key", WithBaseURL(s.URL), WithHTTPClient(s.Client())).GenerateContent(context.Background(), "m", &GenerateContentRequest{})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 400 || httpErr.Status != "INVALID_ARGUMENT" || httpErr.Message != "bad model" {
+		t.Fatalf("err=%v", err)
+	}
+}
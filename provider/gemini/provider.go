@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gemini implements the Google Gemini API chat provider over
+// generateContent and streamGenerateContent. It owns the full native wire
+// layer (request/response types, translation to and from the canonical
+// schema, SSE parsing) and a native client. Importing this package registers
+// the provider under Name.
+//
+// Gemini API reference: https://ai.google.dev/api/generate-content
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Name is the registered provider name. Select it via the root package's
+// WithProvider(gemini.Name) after importing this package.
+const Name = "gemini"
+
+const (
+	geminiDefaultBaseURL = "https://generativelanguage.googleapis.com"
+	geminiAPIVersion     = "v1beta"
+)
+
+func init() {
+	ais.Register(Name, New)
+}
+
+// Options carries Gemini-specific configuration. Pass it to the root
+// package's client through WithProviderOptions; leaving it unset selects the
+// v1beta API.
+type Options struct {
+	// Version overrides the API version path segment (e.g. "v1"). Empty
+	// keeps the default (geminiAPIVersion).
+	Version string `json:"version,omitempty"`
+}
+
+// New constructs a Gemini provider. The base URL is optional (it defaults to
+// the public endpoint). cfg.Options, when set, must be Options or its JSON
+// encoding as a json.RawMessage.
+func New(cfg ais.Config) (ais.ChatProvider, error) {
+	p := &provider{
+		apiKey:  cfg.APIKey,
+		baseURL: cfg.BaseURL,
+		version: geminiAPIVersion,
+	}
+	if p.baseURL == "" {
+		p.baseURL = geminiDefaultBaseURL
+	}
+
+	if raw, ok := cfg.Options.(json.RawMessage); ok {
+		var o Options
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return nil, fmt.Errorf("aimodel/gemini: decode provider options: %w", err)
+		}
+
+		cfg.Options = o
+	}
+
+	switch o := cfg.Options.(type) {
+	case nil:
+	case Options:
+		if o.Version != "" {
+			p.version = o.Version
+		}
+	default:
+		return nil, fmt.Errorf("aimodel/gemini: unexpected provider options of type %T", cfg.Options)
+	}
+
+	return p, nil
+}
+
+type provider struct {
+	apiKey  string
+	baseURL string
+	version string
+}
+
+// modelURL builds the URL of a model method, e.g. generateContent. The model
+// may be given with or without its "models/" resource prefix; ok is false when
+// it names no model.
+func modelURL(baseURL, version, model, method string) (endpoint string, ok bool) {
+	model = strings.TrimPrefix(model, "models/")
+	if model == "" {
+		return "", false
+	}
+
+	return baseURL + "/" + version + "/models/" + url.PathEscape(model) + ":" + method, true
+}
+
+// NewChatRequest translates the canonical request into a generateContent body
+// and targets generateContent, or streamGenerateContent with SSE framing when
+// req.Stream is set.
+func (p *provider) NewChatRequest(ctx context.Context, req *ais.ChatRequest) (*http.Request, error) {
+	gr, err := toGeminiRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(gr)
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: marshal request: %w", err)
+	}
+
+	method := "generateContent"
+	if req.Stream {
+		method = "streamGenerateContent?alt=sse"
+	}
+
+	endpoint, ok := modelURL(p.baseURL, p.version, req.Model, method)
+	if !ok {
+		return nil, errors.New("aimodel/gemini: model is required")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+
+	return httpReq, nil
+}
+
+// ParseChatResponse decodes a generateContent response. A body-level error
+// object becomes an APIError; a response with no candidates is
+// ErrEmptyResponse, annotated with the block reason when the prompt was
+// blocked.
+func (p *provider) ParseChatResponse(body io.Reader) (*ais.ChatResponse, error) {
+	var result GenerateContentResponse
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("aimodel: decode response: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, geminiAPIError(0, result.Error)
+	}
+
+	if len(result.Candidates) == 0 {
+		if pf := result.PromptFeedback; pf != nil && pf.BlockReason != "" {
+			return nil, fmt.Errorf("aimodel/gemini: prompt blocked (%s): %w", pf.BlockReason, ais.ErrEmptyResponse)
+		}
+
+		return nil, ais.ErrEmptyResponse
+	}
+
+	return fromGeminiResponse(&result), nil
+}
+
+// Compile-time check: the provider lists models.
+var _ ais.ModelLister = (*provider)(nil)
+
+// NewListModelsRequest targets GET /models for the first page of up to 1000
+// models. It makes the provider an ais.ModelLister.
+func (p *provider) NewListModelsRequest(ctx context.Context) (*http.Request, error) {
+	endpoint := p.baseURL + "/" + p.version + "/models?pageSize=1000"
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: create request: %w", err)
+	}
+
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+
+	return httpReq, nil
+}
+
+// ParseListModelsResponse decodes a Gemini model list into model IDs without
+// their "models/" resource prefix. A body-level error object becomes an
+// APIError.
+func (p *provider) ParseListModelsResponse(body io.Reader) ([]string, error) {
+	var result ListModelsResponse
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("aimodel: decode response: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, geminiAPIError(0, result.Error)
+	}
+
+	ids := make([]string, len(result.Models))
+	for i, m := range result.Models {
+		ids[i] = strings.TrimPrefix(m.Name, "models/")
+	}
+
+	return ids, nil
+}
+
+// ParseErrorResponse maps a non-2xx Gemini response body to an APIError,
+// falling back to the raw body when it carries no recognizable error object.
+// The streaming endpoint wraps the error object in a one-element array.
+func (p *provider) ParseErrorResponse(statusCode int, body []byte) error {
+	var errResp ErrorResponse
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []ErrorResponse
+		if json.Unmarshal(trimmed, &list) == nil && len(list) > 0 {
+			errResp = list[0]
+		}
+	} else {
+		_ = json.Unmarshal(trimmed, &errResp)
+	}
+
+	if errResp.Error.Message == "" {
+		return &ais.APIError{
+			StatusCode: statusCode,
+			Message:    string(body),
+		}
+	}
+
+	return geminiAPIError(statusCode, &errResp.Error)
+}
+
+// geminiAPIError converts a Google API error object. The canonical status
+// name (e.g. "RESOURCE_EXHAUSTED") becomes the error Type.
+func geminiAPIError(statusCode int, e *Error) *ais.APIError {
+	return &ais.APIError{
+		StatusCode: statusCode,
+		Type:       e.Status,
+		Message:    e.Message,
+	}
+}
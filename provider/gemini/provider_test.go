@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// newProvider builds a *provider from the given options, failing the test on
+// a factory error.
+func newProvider(t *testing.T, opts any) *provider {
+	t.Helper()
+
+	p, err := New(ais.Config{APIKey: "gm-test", Options: opts})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return p.(*provider)
+}
+
+// TestNewChatRequestEndpoints verifies the unary and streaming URLs, the API
+// key header and the version override.
+func TestNewChatRequestEndpoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   any
+		model  string
+		stream bool
+		want   string
+	}{
+		{"unary", nil, "gemini-2.5-flash", false, "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent"},
+		{"stream", nil, "gemini-2.5-flash", true, "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:streamGenerateContent?alt=sse"},
+		{"resource prefix", nil, "models/gemini-2.5-pro", false, "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-pro:generateContent"},
+		{"version override", Options{Version: "v1"}, "gemini-2.5-pro", false, "https://generativelanguage.googleapis.com/v1/models/gemini-2.5-pro:generateContent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newProvider(t, tt.opts).NewChatRequest(context.Background(), &ais.ChatRequest{Model: tt.model, Stream: tt.stream})
+			if err != nil {
+				t.Fatalf("NewChatRequest: %v", err)
+			}
+
+			if got := req.URL.String(); got != tt.want {
+				t.Errorf("URL = %s, want %s", got, tt.want)
+			}
+			if got := req.Header.Get("x-goog-api-key"); got != "gm-test" {
+				t.Errorf("x-goog-api-key = %q", got)
+			}
+		})
+	}
+}
+
+// TestNewRejectsForeignOptions verifies the factory only accepts Options.
+func TestNewRejectsForeignOptions(t *testing.T) {
+	if _, err := New(ais.Config{APIKey: "k", Options: struct{}{}}); err == nil {
+		t.Fatal("New accepted a foreign options value")
+	}
+}
+
+// TestNewChatRequestRequiresModel verifies an empty model fails before any
+// network I/O.
+func TestNewChatRequestRequiresModel(t *testing.T) {
+	if _, err := newProvider(t, nil).NewChatRequest(context.Background(), &ais.ChatRequest{}); err == nil {
+		t.Fatal("NewChatRequest accepted an empty model")
+	}
+}
+
+// TestParseErrorResponse verifies the Google error object, its array form on
+// the streaming endpoint, and the raw-body fallback.
+func TestParseErrorResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantType string
+		wantMsg  string
+	}{
+		{"object", `{"error":{"code":429,"message":"quota","status":"RESOURCE_EXHAUSTED"}}`, "RESOURCE_EXHAUSTED", "quota"},
+		{"array", `[{"error":{"code":400,"message":"bad","status":"INVALID_ARGUMENT"}}]`, "INVALID_ARGUMENT", "bad"},
+		{"raw", `upstream timeout`, "", "upstream timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newProvider(t, nil).ParseErrorResponse(503, []byte(tt.body))
+
+			var apiErr *ais.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %T, want *ais.APIError", err)
+			}
+			if apiErr.StatusCode != 503 || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMsg {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+		})
+	}
+}
+
+// TestParseChatResponseBlockedPrompt verifies a blocked prompt surfaces as
+// ErrEmptyResponse naming the block reason.
+func TestParseChatResponseBlockedPrompt(t *testing.T) {
+	_, err := newProvider(t, nil).ParseChatResponse(strings.NewReader(`{"promptFeedback":{"blockReason":"SAFETY"}}`))
+	if !errors.Is(err, ais.ErrEmptyResponse) || !strings.Contains(err.Error(), "SAFETY") {
+		t.Errorf("err = %v, want ErrEmptyResponse naming SAFETY", err)
+	}
+}
+
+// requestBody builds the wire body of req through the provider.
+func requestBody(t *testing.T, req *ais.ChatRequest) GenerateContentRequest {
+	t.Helper()
+
+	httpReq, err := newProvider(t, nil).NewChatRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("NewChatRequest: %v", err)
+	}
+
+	data, _ := io.ReadAll(httpReq.Body)
+
+	var body GenerateContentRequest
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+
+	return body
+}
+
+// TestProviderRegistered verifies importing the package registers Name.
+func TestProviderRegistered(t *testing.T) {
+	if _, ok := ais.Lookup(Name); !ok {
+		t.Fatalf("provider %q not registered", Name)
+	}
+}
+
+// TestNewDecodesJSONOptions verifies the factory accepts Options in their JSON
+// encoding, as configuration loaders pass them.
+func TestNewDecodesJSONOptions(t *testing.T) {
+	p, err := New(ais.Config{APIKey: "k", Options: json.RawMessage(`{"version":"v1"}`)})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if got := p.(*provider).version; got != "v1" {
+		t.Errorf("version = %q, want v1", got)
+	}
+}
+
+// TestListModels verifies the model list request and that the IDs lose their
+// resource prefix.
+func TestListModels(t *testing.T) {
+	p := newProvider(t, nil)
+
+	req, err := p.NewListModelsRequest(context.Background())
+	if err != nil {
+		t.Fatalf("NewListModelsRequest: %v", err)
+	}
+
+	if req.Method != "GET" || req.URL.String() != "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1000" ||
+		req.Header.Get("x-goog-api-key") != "gm-test" {
+		t.Errorf("request = %s %s", req.Method, req.URL)
+	}
+
+	ids, err := p.ParseListModelsResponse(strings.NewReader(
+		`{"models":[{"name":"models/gemini-2.5-flash"},{"name":"models/gemini-2.5-pro"}]}`))
+	if err != nil || len(ids) != 2 || ids[0] != "gemini-2.5-flash" || ids[1] != "gemini-2.5-pro" {
+		t.Errorf("ids = %v, %v", ids, err)
+	}
+}
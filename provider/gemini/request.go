@@ -0,0 +1,432 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// toGeminiRequest converts a canonical request into a generateContent body.
+// Gemini-only parameters arrive through RequestExtension / MessageExtension;
+// a mis-typed extension value or a part Gemini cannot represent fails here,
+// before any network I/O.
+func toGeminiRequest(req *ais.ChatRequest) (*GenerateContentRequest, error) {
+	reqExt, err := extensionOf[RequestExtension](req.Extensions, "ChatRequest")
+	if err != nil {
+		return nil, err
+	}
+	if reqExt == nil {
+		reqExt = &RequestExtension{}
+	}
+
+	gr := &GenerateContentRequest{
+		SafetySettings: reqExt.SafetySettings,
+		CachedContent:  reqExt.CachedContent,
+	}
+	if err := setGeminiContents(gr, req.Messages); err != nil {
+		return nil, err
+	}
+
+	tools, err := toGeminiTools(req.Tools)
+	if err != nil {
+		return nil, err
+	}
+	gr.Tools = append(tools, reqExt.Tools...)
+	gr.ToolConfig = toGeminiToolConfig(req.ToolChoice)
+	gr.GenerationConfig = toGeminiGenerationConfig(req, reqExt)
+
+	return gr, nil
+}
+
+// setGeminiContents splits the canonical messages into the system
+// instruction and the contents array. Every system message joins the single
+// systemInstruction; a run of consecutive tool results becomes one user turn
+// of functionResponse parts, answering the preceding model turn's calls.
+func setGeminiContents(gr *GenerateContentRequest, messages []ais.Message) error {
+	var system []Part
+
+	// toolNames resolves a canonical tool call ID to its function name:
+	// functionResponse is matched by name, which a tool message lacks.
+	toolNames := map[string]string{}
+
+	for i := 0; i < len(messages); i++ {
+		m := messages[i]
+
+		switch m.Role {
+		case ais.RoleSystem:
+			system = append(system, Part{Text: m.Content.Text()})
+		case ais.RoleTool:
+			runStart := i
+			for i+1 < len(messages) && messages[i+1].Role == ais.RoleTool {
+				i++
+			}
+
+			content, err := toGeminiFunctionResponses(messages[runStart:i+1], toolNames)
+			if err != nil {
+				return err
+			}
+			gr.Contents = append(gr.Contents, content)
+		default:
+			for _, tc := range m.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+			}
+
+			content, err := toGeminiContent(m)
+			if err != nil {
+				return err
+			}
+			gr.Contents = append(gr.Contents, content)
+		}
+	}
+
+	if len(system) > 0 {
+		gr.SystemInstruction = &Content{Parts: system}
+	}
+
+	return nil
+}
+
+// toGeminiContent translates a user or assistant message. An assistant
+// message carrying MessageExtension.Parts is replayed verbatim, keeping the
+// thought signatures Gemini requires during function calling; otherwise the
+// turn is rebuilt from Content and ToolCalls. Canonical Thinking text is not
+// sent back: Gemini does not accept unsigned thoughts as input.
+func toGeminiContent(m ais.Message) (Content, error) {
+	content := Content{Role: "user"}
+	if m.Role == ais.RoleAssistant {
+		content.Role = "model"
+	}
+
+	ext, err := extensionOf[MessageExtension](m.Extensions, "Message")
+	if err != nil {
+		return Content{}, err
+	}
+	if ext != nil && len(ext.Parts) > 0 {
+		content.Parts = ext.Parts
+
+		return content, nil
+	}
+
+	if parts := m.Content.Parts(); len(parts) > 0 {
+		for _, p := range parts {
+			part, ok, err := toGeminiPart(p)
+			if err != nil {
+				return Content{}, err
+			}
+			if ok {
+				content.Parts = append(content.Parts, part)
+			}
+		}
+	} else if text := m.Content.Text(); text != "" {
+		content.Parts = append(content.Parts, Part{Text: text})
+	}
+
+	for _, tc := range m.ToolCalls {
+		var args map[string]any
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				return Content{}, fmt.Errorf("aimodel/gemini: tool call %q arguments are not a JSON object: %w", tc.Function.Name, err)
+			}
+		}
+
+		content.Parts = append(content.Parts, Part{FunctionCall: &FunctionCall{
+			ID:   nativeCallID(tc.ID, tc.Function.Name),
+			Name: tc.Function.Name,
+			Args: args,
+		}})
+	}
+
+	return content, nil
+}
+
+// toGeminiPart maps one canonical content part. A nil image_url payload is
+// skipped (ok=false); a part Gemini cannot carry fails with a
+// *ais.ContentPartError.
+func toGeminiPart(p ais.ContentPart) (Part, bool, error) {
+	fail := func(reason string) (Part, bool, error) {
+		return Part{}, false, &ais.ContentPartError{Provider: Name, Type: p.Type, Reason: reason}
+	}
+
+	switch p.Type {
+	case "text":
+		return Part{Text: p.Text}, true, nil
+	case "image_url":
+		if p.ImageURL == nil {
+			return Part{}, false, nil
+		}
+
+		return uriPart(p.ImageURL.URL), true, nil
+	case "input_audio":
+		if p.InputAudio == nil {
+			return fail("missing input_audio payload")
+		}
+
+		return Part{InlineData: &Blob{MIMEType: "audio/" + p.InputAudio.Format, Data: p.InputAudio.Data}}, true, nil
+	case "file":
+		switch f := p.File; {
+		case f == nil:
+			return fail("missing file payload")
+		case f.FileData != "":
+			mediaType, data, ok := parseDataURI(f.FileData)
+			if !ok {
+				return fail("file_data must be a base64 data URI")
+			}
+
+			return Part{InlineData: &Blob{MIMEType: mediaType, Data: data}}, true, nil
+		case f.FileURL != "":
+			return uriPart(f.FileURL), true, nil
+		case f.FileID != "":
+			// Gemini identifies uploaded files by their URI.
+			return Part{FileData: &FileData{FileURI: f.FileID}}, true, nil
+		default:
+			return fail("missing file payload")
+		}
+	default:
+		return fail("unknown content part type")
+	}
+}
+
+// uriPart maps a URL to inline data when it is a base64 data URI and to a
+// file reference otherwise.
+func uriPart(uri string) Part {
+	if mediaType, data, ok := parseDataURI(uri); ok {
+		return Part{InlineData: &Blob{MIMEType: mediaType, Data: data}}
+	}
+
+	return Part{FileData: &FileData{FileURI: uri}}
+}
+
+// toGeminiFunctionResponses serializes a run of consecutive tool-result
+// messages into one user turn of functionResponse parts. Gemini wants the
+// response as an object: a result that is a JSON object is sent as is, any
+// other text is wrapped as {"result": text}.
+func toGeminiFunctionResponses(msgs []ais.Message, toolNames map[string]string) (Content, error) {
+	content := Content{Role: "user"}
+
+	for _, m := range msgs {
+		if m.ToolCallID == "" {
+			return Content{}, fmt.Errorf("aimodel: tool result message missing tool_call_id")
+		}
+
+		name, ok := toolNames[m.ToolCallID]
+		if !ok {
+			name = m.ToolCallID
+		}
+
+		text := m.Content.Text()
+
+		var response map[string]any
+		if err := json.Unmarshal([]byte(text), &response); err != nil || response == nil {
+			response = map[string]any{"result": text}
+		}
+
+		content.Parts = append(content.Parts, Part{FunctionResponse: &FunctionResponse{
+			ID:       nativeCallID(m.ToolCallID, name),
+			Name:     name,
+			Response: response,
+		}})
+	}
+
+	return content, nil
+}
+
+// nativeCallID returns the function call ID to send to Gemini. Calls Gemini
+// returned without an ID are given their function name as canonical ID (see
+// fromGeminiParts); that synthesized ID is not sent back.
+func nativeCallID(id, name string) string {
+	if id == name {
+		return ""
+	}
+
+	return id
+}
+
+// toGeminiTools declares every canonical function tool in one Tool entry. The
+// JSON Schema parameters go to parametersJsonSchema unchanged. Gemini's
+// built-in tools have no canonical form: add them through
+// RequestExtension.Tools.
+func toGeminiTools(tools []ais.Tool) ([]Tool, error) {
+	if len(tools) == 0 {
+		return nil, nil
+	}
+
+	decls := make([]FunctionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		if t.Type != "" && t.Type != "function" {
+			return nil, fmt.Errorf("aimodel/gemini: tool type %q is not supported; add built-in tools through RequestExtension.Tools", t.Type)
+		}
+
+		decls = append(decls, FunctionDeclaration{
+			Name:                 t.Function.Name,
+			Description:          t.Function.Description,
+			ParametersJSONSchema: t.Function.Parameters,
+		})
+	}
+
+	return []Tool{{FunctionDeclarations: decls}}, nil
+}
+
+// toGeminiToolConfig maps the canonical tool_choice: "auto" → AUTO,
+// "required" → ANY, "none" → NONE, and a named function → ANY restricted to
+// that name. Anything else leaves the server default.
+func toGeminiToolConfig(tc any) *ToolConfig {
+	var fc *FunctionCallingConfig
+
+	switch v := tc.(type) {
+	case string:
+		switch v {
+		case "auto":
+			fc = &FunctionCallingConfig{Mode: "AUTO"}
+		case "required":
+			fc = &FunctionCallingConfig{Mode: "ANY"}
+		case "none":
+			fc = &FunctionCallingConfig{Mode: "NONE"}
+		}
+	case map[string]any:
+		if fn, ok := v["function"].(map[string]any); ok {
+			if name, ok := fn["name"].(string); ok {
+				fc = &FunctionCallingConfig{Mode: "ANY", AllowedFunctionNames: []string{name}}
+			}
+		}
+	}
+
+	if fc == nil {
+		return nil
+	}
+
+	return &ToolConfig{FunctionCallingConfig: fc}
+}
+
+// toGeminiGenerationConfig gathers the sampling, length, output-format and
+// thinking controls; it returns nil when none is set.
+func toGeminiGenerationConfig(req *ais.ChatRequest, ext *RequestExtension) *GenerationConfig {
+	gc := &GenerationConfig{
+		Temperature:    req.Temperature,
+		TopP:           req.TopP,
+		TopK:           req.TopK,
+		StopSequences:  req.Stop,
+		ThinkingConfig: toGeminiThinkingConfig(req, ext),
+	}
+
+	switch {
+	case req.MaxCompletionTokens != nil:
+		gc.MaxOutputTokens = req.MaxCompletionTokens
+	case req.MaxTokens != nil: //nolint:staticcheck // deprecated field read on purpose
+		gc.MaxOutputTokens = req.MaxTokens //nolint:staticcheck // deprecated field read on purpose
+	}
+
+	gc.ResponseMIMEType, gc.ResponseJSONSchema = toGeminiResponseFormat(req.ResponseFormat)
+
+	if reflect.ValueOf(*gc).IsZero() {
+		return nil
+	}
+
+	return gc
+}
+
+// toGeminiResponseFormat maps the canonical ResponseFormat: both JSON-schema
+// shapes (OpenAI's nested json_schema.schema and the flat schema) become
+// application/json with responseJsonSchema, and {type:"json_object"} becomes
+// plain application/json. Anything else yields no format.
+func toGeminiResponseFormat(rf any) (string, any) {
+	m, ok := rf.(map[string]any)
+	if !ok {
+		return "", nil
+	}
+
+	switch t, _ := m["type"].(string); t {
+	case "json_object":
+		return "application/json", nil
+	case "json_schema":
+		schema := m["schema"]
+		if nested, ok := m["json_schema"].(map[string]any); ok {
+			schema = nested["schema"]
+		}
+		if schema == nil {
+			return "", nil
+		}
+
+		return "application/json", schema
+	default:
+		return "", nil
+	}
+}
+
+// toGeminiThinkingConfig maps the canonical thinking controls. Thinking.Type
+// "enabled" sets thinkingBudget from BudgetTokens, "adaptive" the dynamic
+// budget -1 and "disabled" 0; thought summaries are included unless thinking
+// is disabled or Display is "omitted". ReasoningEffort maps to thinkingLevel
+// and supersedes the budget ("none" disables thinking, "xhigh" becomes
+// "high").
+func toGeminiThinkingConfig(req *ais.ChatRequest, ext *RequestExtension) *ThinkingConfig {
+	tc := &ThinkingConfig{IncludeThoughts: ext.IncludeThoughts}
+
+	if t := req.Thinking; t != nil {
+		switch t.Type {
+		case "enabled":
+			if t.BudgetTokens > 0 { //nolint:staticcheck // deprecated field read on purpose
+				tc.ThinkingBudget = new(t.BudgetTokens) //nolint:staticcheck // deprecated field read on purpose
+			}
+		case "adaptive":
+			tc.ThinkingBudget = new(-1)
+		case "disabled":
+			tc.ThinkingBudget = new(0)
+		}
+
+		if t.Type != "disabled" && t.Display != "omitted" {
+			tc.IncludeThoughts = true
+		}
+	}
+
+	switch effort := strings.ToLower(req.ReasoningEffort); effort {
+	case "":
+	case ais.ReasoningEffortNone:
+		tc.ThinkingBudget = new(0)
+		tc.IncludeThoughts = false
+	case ais.ReasoningEffortXHigh:
+		tc.ThinkingBudget, tc.ThinkingLevel = nil, ais.ReasoningEffortHigh
+	default:
+		tc.ThinkingBudget, tc.ThinkingLevel = nil, effort
+	}
+
+	if *tc == (ThinkingConfig{}) {
+		return nil
+	}
+
+	return tc
+}
+
+// parseDataURI splits a "data:<mediaType>;base64,<data>" URI.
+func parseDataURI(uri string) (mediaType, data string, ok bool) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return "", "", false
+	}
+
+	mediaType, data, ok = strings.Cut(rest, ";base64,")
+	if !ok {
+		return "", "", false
+	}
+
+	return mediaType, data, true
+}
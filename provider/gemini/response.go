@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// fromGeminiResponse converts a generateContent response into the canonical
+// ChatResponse, one Choice per candidate. Gemini-only data — verbatim parts
+// with thought signatures, safety ratings, the raw finish reason, prompt
+// feedback — goes into this provider's extension namespaces.
+func fromGeminiResponse(gr *GenerateContentResponse) *ais.ChatResponse {
+	cr := &ais.ChatResponse{
+		ID:     gr.ResponseID,
+		Object: "chat.completion",
+		Model:  gr.ModelVersion,
+	}
+
+	for _, c := range gr.Candidates {
+		msg, _ := fromGeminiParts(c.Content.Parts, 0)
+
+		choice := ais.Choice{
+			Index:        c.Index,
+			Message:      msg,
+			FinishReason: mapGeminiFinishReason(c.FinishReason, len(msg.ToolCalls) > 0),
+		}
+		if ext := candidateExtension(&c); ext != nil {
+			choice.Extensions.Set(Name, ext)
+		}
+
+		cr.Choices = append(cr.Choices, choice)
+	}
+
+	if gr.UsageMetadata != nil {
+		cr.Usage = geminiCanonicalUsage(gr.UsageMetadata)
+	}
+
+	if gr.PromptFeedback != nil {
+		cr.Extensions.Set(Name, &ResponseExtension{PromptFeedback: gr.PromptFeedback})
+	}
+
+	return cr
+}
+
+// fromGeminiParts folds native parts into a canonical assistant message:
+// thought parts into Thinking, other text into Content, function calls into
+// ToolCalls numbered from firstToolIndex. A call without an ID gets its
+// function name as canonical ID so tool results can be matched back. The
+// parts are also kept verbatim on MessageExtension.Parts — thought signatures
+// and parts with no canonical form (inline media, code execution) survive
+// only there. It returns the number of tool calls it appended.
+func fromGeminiParts(parts []Part, firstToolIndex int) (ais.Message, int) {
+	msg := ais.Message{Role: ais.RoleAssistant}
+
+	var text, thinking strings.Builder
+
+	for _, p := range parts {
+		switch {
+		case p.FunctionCall != nil:
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = p.FunctionCall.Name
+			}
+
+			args := "{}"
+			if len(p.FunctionCall.Args) > 0 {
+				if data, err := json.Marshal(p.FunctionCall.Args); err == nil {
+					args = string(data)
+				}
+			}
+
+			msg.ToolCalls = append(msg.ToolCalls, ais.ToolCall{
+				Index: firstToolIndex + len(msg.ToolCalls),
+				ID:    id,
+				Type:  "function",
+				Function: ais.FunctionCall{
+					Name:      p.FunctionCall.Name,
+					Arguments: args,
+				},
+			})
+		case p.Thought:
+			thinking.WriteString(p.Text)
+		default:
+			text.WriteString(p.Text)
+		}
+	}
+
+	if text.Len() > 0 {
+		msg.Content = ais.NewTextContent(text.String())
+	}
+
+	msg.Thinking = thinking.String()
+
+	if len(parts) > 0 {
+		msg.Extensions.Set(Name, &MessageExtension{Parts: parts})
+	}
+
+	return msg, len(msg.ToolCalls)
+}
+
+// candidateExtension collects a candidate's Gemini-only metadata, or nil when
+// it carries none.
+func candidateExtension(c *Candidate) *ChoiceExtension {
+	if c.FinishReason == "" && len(c.SafetyRatings) == 0 && c.CitationMetadata == nil && c.GroundingMetadata == nil {
+		return nil
+	}
+
+	return &ChoiceExtension{
+		FinishReason:      c.FinishReason,
+		FinishMessage:     c.FinishMessage,
+		SafetyRatings:     c.SafetyRatings,
+		CitationMetadata:  c.CitationMetadata,
+		GroundingMetadata: c.GroundingMetadata,
+	}
+}
+
+// geminiCanonicalUsage maps usageMetadata onto the canonical counts. Gemini's
+// candidatesTokenCount excludes thinking, so CompletionTokens adds the
+// thoughts back to keep ReasoningTokens a subset of it, as on the other
+// providers. The tool-use prompt count goes into the UsageExtension.
+func geminiCanonicalUsage(um *UsageMetadata) ais.Usage {
+	u := ais.Usage{
+		PromptTokens:     um.PromptTokenCount,
+		CompletionTokens: um.CandidatesTokenCount + um.ThoughtsTokenCount,
+		TotalTokens:      um.TotalTokenCount,
+		CacheReadTokens:  um.CachedContentTokenCount,
+		ReasoningTokens:  um.ThoughtsTokenCount,
+	}
+
+	if um.ToolUsePromptTokenCount > 0 {
+		u.Extensions.Set(Name, &UsageExtension{ToolUsePromptTokens: um.ToolUsePromptTokenCount})
+	}
+
+	return u
+}
+
+// mapGeminiFinishReason folds Gemini's finish reasons onto the canonical set.
+// STOP becomes tool_calls when the candidate called a function; the safety
+// family becomes content_filter; everything else passes through verbatim
+// (the raw value is always on ChoiceExtension.FinishReason).
+func mapGeminiFinishReason(reason string, hasToolCalls bool) ais.FinishReason {
+	switch reason {
+	case "STOP":
+		if hasToolCalls {
+			return ais.FinishReasonToolCalls
+		}
+
+		return ais.FinishReasonStop
+	case "MAX_TOKENS":
+		return ais.FinishReasonLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return ais.FinishReasonContentFilter
+	default:
+		return ais.FinishReason(reason)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// NewStreamDecoder returns a decoder for streamGenerateContent?alt=sse. Each
+// SSE data line is a complete GenerateContentResponse carrying the next
+// slice of every candidate; there is no terminal sentinel.
+func (p *provider) NewStreamDecoder(body io.Reader) ais.StreamDecoder {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64*1024), ais.MaxStreamLineSize)
+
+	return &streamDecoder{sc: sc, nextToolIdx: make(map[int]int)}
+}
+
+type streamDecoder struct {
+	sc *bufio.Scanner
+
+	// nextToolIdx numbers tool calls per candidate across chunks, since
+	// Gemini delivers each function call whole and unindexed.
+	nextToolIdx map[int]int
+}
+
+func (d *streamDecoder) Next() (*ais.StreamChunk, error) {
+	sc := d.sc
+
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			// Blank separators, comments and other SSE fields.
+			continue
+		}
+
+		var gr GenerateContentResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &gr); err != nil {
+			return nil, fmt.Errorf("aimodel: decode stream chunk: %w", err)
+		}
+
+		if gr.Error != nil {
+			return nil, geminiAPIError(0, gr.Error)
+		}
+
+		return d.chunk(&gr), nil
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// chunk converts one streamed response into a canonical chunk. The usage
+// metadata Gemini repeats on chunks is cumulative, so each copy replaces the
+// last.
+func (d *streamDecoder) chunk(gr *GenerateContentResponse) *ais.StreamChunk {
+	chunk := &ais.StreamChunk{
+		ID:     gr.ResponseID,
+		Object: "chat.completion.chunk",
+		Model:  gr.ModelVersion,
+	}
+
+	for _, c := range gr.Candidates {
+		delta, calls := fromGeminiParts(c.Content.Parts, d.nextToolIdx[c.Index])
+		d.nextToolIdx[c.Index] += calls
+
+		choice := ais.StreamChunkChoice{Index: c.Index, Delta: delta}
+		if c.FinishReason != "" {
+			reason := string(mapGeminiFinishReason(c.FinishReason, d.nextToolIdx[c.Index] > 0))
+			choice.FinishReason = &reason
+		}
+		if ext := candidateExtension(&c); ext != nil {
+			choice.Extensions.Set(Name, ext)
+		}
+
+		chunk.Choices = append(chunk.Choices, choice)
+	}
+
+	if gr.UsageMetadata != nil {
+		usage := geminiCanonicalUsage(gr.UsageMetadata)
+		chunk.Usage = &usage
+	}
+
+	if gr.PromptFeedback != nil {
+		chunk.Extensions.Set(Name, &ResponseExtension{PromptFeedback: gr.PromptFeedback})
+	}
+
+	return chunk
+}
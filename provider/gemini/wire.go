@@ -0,0 +1,219 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gemini
+
+import "encoding/json"
+
+// --- Gemini request types ---
+
+// GenerateContentRequest is the native body of models/{model}:generateContent
+// and models/{model}:streamGenerateContent. The model travels in the URL.
+type GenerateContentRequest struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
+	SafetySettings    []SafetySetting   `json:"safetySettings,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+	// CachedContent names a context cache ("cachedContents/…") whose content
+	// prefixes this request.
+	CachedContent string `json:"cachedContent,omitempty"`
+}
+
+// Content is one conversation turn. Role is "user" or "model"; it is empty on
+// a system instruction.
+type Content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is one element of a turn. Exactly one data field is set; Thought and
+// ThoughtSignature qualify it.
+type Part struct {
+	Text string `json:"text,omitempty"`
+	// Thought marks a text part as a thought summary rather than answer text.
+	Thought bool `json:"thought,omitempty"`
+	// ThoughtSignature is the opaque signature of the model's internal
+	// reasoning; Gemini requires it back on the same part when the turn is
+	// replayed during function calling.
+	ThoughtSignature    string            `json:"thoughtSignature,omitempty"`
+	InlineData          *Blob             `json:"inlineData,omitempty"`
+	FileData            *FileData         `json:"fileData,omitempty"`
+	FunctionCall        *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse    *FunctionResponse `json:"functionResponse,omitempty"`
+	ExecutableCode      json.RawMessage   `json:"executableCode,omitempty"`
+	CodeExecutionResult json.RawMessage   `json:"codeExecutionResult,omitempty"`
+}
+
+// Blob is inline media: base64 Data of the given MIME type.
+type Blob struct {
+	MIMEType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// FileData references media by URI (an uploaded file or a public URL).
+type FileData struct {
+	MIMEType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type FunctionCall struct {
+	ID   string         `json:"id,omitempty"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type FunctionResponse struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+// Tool groups function declarations; the other fields enable Gemini's
+// built-in tools and are sent as empty objects, e.g. GoogleSearch: &struct{}{}.
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"`
+	GoogleSearch         *struct{}             `json:"googleSearch,omitempty"`
+	CodeExecution        *struct{}             `json:"codeExecution,omitempty"`
+	URLContext           *struct{}             `json:"urlContext,omitempty"`
+}
+
+// FunctionDeclaration declares a callable function. ParametersJSONSchema takes
+// a JSON Schema verbatim; Parameters takes Gemini's OpenAPI subset.
+type FunctionDeclaration struct {
+	Name                 string `json:"name"`
+	Description          string `json:"description,omitempty"`
+	Parameters           any    `json:"parameters,omitempty"`
+	ParametersJSONSchema any    `json:"parametersJsonSchema,omitempty"`
+}
+
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// FunctionCallingConfig selects the function-calling mode: "AUTO", "ANY"
+// (must call; optionally one of AllowedFunctionNames) or "NONE".
+type FunctionCallingConfig struct {
+	Mode                 string   `json:"mode,omitempty"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// SafetySetting sets the blocking threshold of one harm category, e.g.
+// {Category: "HARM_CATEGORY_HATE_SPEECH", Threshold: "BLOCK_ONLY_HIGH"}.
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+type GenerationConfig struct {
+	Temperature        *float64        `json:"temperature,omitempty"`
+	TopP               *float64        `json:"topP,omitempty"`
+	TopK               *int            `json:"topK,omitempty"`
+	MaxOutputTokens    *int            `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string        `json:"stopSequences,omitempty"`
+	CandidateCount     *int            `json:"candidateCount,omitempty"`
+	ResponseMIMEType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema any             `json:"responseJsonSchema,omitempty"`
+	ResponseModalities []string        `json:"responseModalities,omitempty"`
+	ThinkingConfig     *ThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// ThinkingConfig controls the model's thinking. ThinkingBudget caps thinking
+// tokens (0 disables thinking, -1 lets the model decide); ThinkingLevel is the
+// newer qualitative control ("low", "high", …). Set at most one of the two.
+type ThinkingConfig struct {
+	IncludeThoughts bool   `json:"includeThoughts,omitempty"`
+	ThinkingBudget  *int   `json:"thinkingBudget,omitempty"`
+	ThinkingLevel   string `json:"thinkingLevel,omitempty"`
+}
+
+// --- Gemini response types ---
+
+type GenerateContentResponse struct {
+	Candidates     []Candidate     `json:"candidates,omitempty"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	ResponseID     string          `json:"responseId,omitempty"`
+	// Error is set on an error object delivered inside a stream.
+	Error *Error `json:"error,omitempty"`
+}
+
+type Candidate struct {
+	Index             int             `json:"index"`
+	Content           Content         `json:"content"`
+	FinishReason      string          `json:"finishReason,omitempty"`
+	FinishMessage     string          `json:"finishMessage,omitempty"`
+	SafetyRatings     []SafetyRating  `json:"safetyRatings,omitempty"`
+	CitationMetadata  json.RawMessage `json:"citationMetadata,omitempty"`
+	GroundingMetadata json.RawMessage `json:"groundingMetadata,omitempty"`
+}
+
+// SafetyRating is the model's harm assessment of a prompt or candidate in one
+// category. Blocked reports that the content was blocked because of it.
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// PromptFeedback reports whether the prompt itself was blocked; when
+// BlockReason is set the response carries no candidates.
+type PromptFeedback struct {
+	BlockReason   string         `json:"blockReason,omitempty"`
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+}
+
+// UsageMetadata is Gemini's token accounting. CandidatesTokenCount excludes
+// ThoughtsTokenCount; TotalTokenCount covers every count.
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+	ToolUsePromptTokenCount int `json:"toolUsePromptTokenCount,omitempty"`
+}
+
+// ListModelsResponse is the body of GET /models.
+type ListModelsResponse struct {
+	Models        []Model `json:"models"`
+	NextPageToken string  `json:"nextPageToken,omitempty"`
+	Error         *Error  `json:"error,omitempty"`
+}
+
+// Model is one entry of a model list; Name is the resource name, e.g.
+// "models/gemini-2.5-flash".
+type Model struct {
+	Name                       string   `json:"name"`
+	DisplayName                string   `json:"displayName,omitempty"`
+	SupportedGenerationMethods []string `json:"supportedGenerationMethods,omitempty"`
+}
+
+// ErrorResponse is the body of a non-2xx Gemini response.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error is a Google API error: the HTTP Code, a Message and the canonical
+// Status name (e.g. "INVALID_ARGUMENT", "RESOURCE_EXHAUSTED").
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
@@ -132,6 +132,38 @@ func (c *Client) ChatCompletions(ctx context.Context, request *ChatCompletionReq
 	return &result, nil
 }
 
+func (c *Client) Embeddings(ctx context.Context, request *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if request == nil {
+		return nil, fmt.Errorf("openai: nil embeddings request")
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("openai: marshal embeddings request: %w", err)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("openai: create embeddings request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Authorization", "Bearer "+c.apiKey)
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("openai: send embeddings request: %w", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, parseNativeError(response)
+	}
+	var result EmbeddingResponse
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("openai: decode embeddings response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, &HTTPError{StatusCode: response.StatusCode, Code: result.Error.Code, Type: result.Error.Type, Message: result.Error.Message}
+	}
+	return &result, nil
+}
+
 type ChatCompletionStream struct {
 	body io.ReadCloser
 	scan *bufio.Scanner
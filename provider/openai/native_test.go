@@ -114,6 +114,26 @@ func TestNativeClientNonStreamAndStream(t *testing.T) {
 	}
 }
 
+func TestNativeClientEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("request = %s auth=%q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// "AACAPwAAAMA=" is the little-endian float32 pair [1, -2].
+		_, _ = io.WriteString(w, `{"object":"list","model":"m","data":[{"object":"embedding","index":0,"embedding":"AACAPwAAAMA="}],"usage":{"prompt_tokens":1,"total_tokens":1}}`)
+	}))
+	defer server.Close()
+	client := NewClient("key", WithBaseURL(server.URL+"/v1"), WithHTTPClient(server.Client()))
+	response, err := client.Embeddings(context.Background(), &EmbeddingRequest{Model: "m", Input: []string{"hi"}, EncodingFormat: "base64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.Data[0].Embedding; len(got) != 2 || got[0] != 1 || got[1] != -2 {
+		t.Fatalf("embedding=%v", got)
+	}
+}
+
 func TestNativeClientErrors(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
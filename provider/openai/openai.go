@@ -63,9 +63,21 @@ type provider struct {
 	baseURL string
 }
 
+// Compile-time checks: the provider also serves the embeddings capability
+// and lists models.
+var (
+	_ ais.EmbeddingProvider = (*provider)(nil)
+	_ ais.ModelLister       = (*provider)(nil)
+)
+
 // NewChatRequest translates shared fields into the OpenAI wire body.
 func (p *provider) NewChatRequest(ctx context.Context, req *ais.ChatRequest) (*http.Request, error) {
-	body, err := json.Marshal(toOpenAIRequest(req))
+	wire, err := toOpenAIRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(wire)
 	if err != nil {
 		return nil, fmt.Errorf("aimodel: marshal request: %w", err)
 	}
@@ -104,6 +116,86 @@ func (p *provider) ParseChatResponse(body io.Reader) (*ais.ChatResponse, error)
 	return fromOpenAIResponse(&result), nil
 }
 
+// NewEmbeddingRequest translates an embeddings request into the OpenAI
+// /embeddings body. It makes the provider an ais.EmbeddingProvider.
+func (p *provider) NewEmbeddingRequest(ctx context.Context, req *ais.EmbeddingRequest) (*http.Request, error) {
+	body, err := json.Marshal(toOpenAIEmbeddingRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	return httpReq, nil
+}
+
+// ParseEmbeddingResponse decodes an OpenAI embeddings response, float or
+// base64 encoded. A body-level error object becomes an APIError; a response
+// with no data is ErrEmptyResponse.
+func (p *provider) ParseEmbeddingResponse(body io.Reader) (*ais.EmbeddingResponse, error) {
+	var result EmbeddingResponse
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("aimodel: decode response: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, &ais.APIError{
+			Code:    result.Error.Code,
+			Message: result.Error.Message,
+			Type:    result.Error.Type,
+		}
+	}
+
+	if len(result.Data) == 0 {
+		return nil, ais.ErrEmptyResponse
+	}
+
+	return fromOpenAIEmbeddingResponse(&result), nil
+}
+
+// NewListModelsRequest targets GET /models. It makes the provider an
+// ais.ModelLister.
+func (p *provider) NewListModelsRequest(ctx context.Context) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	return httpReq, nil
+}
+
+// ParseListModelsResponse decodes an OpenAI model list into model IDs. A
+// body-level error object becomes an APIError.
+func (p *provider) ParseListModelsResponse(body io.Reader) ([]string, error) {
+	var result ModelList
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("aimodel: decode response: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, &ais.APIError{
+			Code:    result.Error.Code,
+			Message: result.Error.Message,
+			Type:    result.Error.Type,
+		}
+	}
+
+	ids := make([]string, len(result.Data))
+	for i, m := range result.Data {
+		ids[i] = m.ID
+	}
+
+	return ids, nil
+}
+
 // ParseErrorResponse maps a non-2xx OpenAI response body to an APIError,
 // falling back to the raw body when it carries no recognizable error object.
 func (p *provider) ParseErrorResponse(statusCode int, body []byte) error {
@@ -121,6 +121,118 @@ func TestNewChatRequestNonStreamOmitsStreamOptions(t *testing.T) {
 	}
 }
 
+func TestNewChatRequestAudioAndFileParts(t *testing.T) {
+	p := newProvider(t)
+
+	req, err := p.NewChatRequest(context.Background(), &ais.ChatRequest{
+		Model: "gpt-4o-audio-preview",
+		Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewPartsContent(
+			ais.ContentPart{Type: "input_audio", InputAudio: &ais.InputAudio{Data: "UklGRg==", Format: "wav"}},
+			ais.ContentPart{Type: "file", File: &ais.File{FileData: "data:application/pdf;base64,JVBERi0x", Filename: "a.pdf"}},
+			ais.ContentPart{Type: "file", File: &ais.File{FileID: "file-abc"}},
+		)}},
+	})
+	if err != nil {
+		t.Fatalf("NewChatRequest: %v", err)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	var decoded struct {
+		Messages []struct {
+			Content []ChatCompletionContentPart `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	parts := decoded.Messages[0].Content
+	if len(parts) != 3 {
+		t.Fatalf("parts len = %d, want 3", len(parts))
+	}
+	if a := parts[0].InputAudio; a == nil || a.Data != "UklGRg==" || a.Format != "wav" {
+		t.Errorf("input_audio = %+v", a)
+	}
+	if f := parts[1].File; f == nil || f.FileData != "data:application/pdf;base64,JVBERi0x" || f.Filename != "a.pdf" {
+		t.Errorf("file = %+v", f)
+	}
+	if f := parts[2].File; f == nil || f.FileID != "file-abc" {
+		t.Errorf("file = %+v", f)
+	}
+
+	// The native parts map back onto the canonical ones.
+	back := fromOpenAIMessage(ChatCompletionMessage{Role: "user", Content: NewPartsContent(parts...)})
+	got := back.Content.Parts()
+	if len(got) != 3 || got[0].InputAudio == nil || got[1].File == nil || got[2].File.FileID != "file-abc" {
+		t.Errorf("round trip parts = %+v", got)
+	}
+}
+
+func TestNewChatRequestFileURLUnsupported(t *testing.T) {
+	p := newProvider(t)
+
+	_, err := p.NewChatRequest(context.Background(), &ais.ChatRequest{
+		Model: "gpt-4o",
+		Messages: []ais.Message{{Role: ais.RoleUser, Content: ais.NewPartsContent(
+			ais.ContentPart{Type: "file", File: &ais.File{FileURL: "https://example.com/a.pdf"}},
+		)}},
+	})
+
+	var cpe *ais.ContentPartError
+	if !errors.As(err, &cpe) {
+		t.Fatalf("err = %v, want *ais.ContentPartError", err)
+	}
+	if cpe.Provider != Name || cpe.Type != "file" {
+		t.Errorf("error = %+v", cpe)
+	}
+}
+
+func TestNewEmbeddingRequestBody(t *testing.T) {
+	p := newProvider(t)
+
+	req, err := p.NewEmbeddingRequest(context.Background(), &ais.EmbeddingRequest{Model: "text-embedding-3-small", Input: []string{"a"}})
+	if err != nil {
+		t.Fatalf("NewEmbeddingRequest: %v", err)
+	}
+
+	if req.URL.Path != "/v1/embeddings" {
+		t.Errorf("path = %q", req.URL.Path)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if got, want := string(body), `{"model":"text-embedding-3-small","input":["a"]}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestParseEmbeddingResponseFloat(t *testing.T) {
+	p := newProvider(t)
+
+	resp, err := p.ParseEmbeddingResponse(strings.NewReader(`{"object":"list","model":"m","data":[{"object":"embedding","index":0,"embedding":[0.5,-0.25]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`))
+	if err != nil {
+		t.Fatalf("ParseEmbeddingResponse: %v", err)
+	}
+
+	if len(resp.Data) != 1 || len(resp.Data[0].Embedding) != 2 || resp.Data[0].Embedding[1] != -0.25 {
+		t.Errorf("data = %+v", resp.Data)
+	}
+	if resp.Model != "m" || resp.Usage.PromptTokens != 3 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestParseEmbeddingResponseRejectsEmptyAndMalformed(t *testing.T) {
+	p := newProvider(t)
+
+	if _, err := p.ParseEmbeddingResponse(strings.NewReader(`{"object":"list","data":[]}`)); !errors.Is(err, ais.ErrEmptyResponse) {
+		t.Errorf("empty data err = %v, want ErrEmptyResponse", err)
+	}
+
+	if _, err := p.ParseEmbeddingResponse(strings.NewReader(`{"data":[{"index":0,"embedding":"AAA"}]}`)); err == nil {
+		t.Error("truncated base64 vector should fail")
+	}
+}
+
 func TestParseChatResponseEmptyChoices(t *testing.T) {
 	p := newProvider(t)
 
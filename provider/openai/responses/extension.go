@@ -0,0 +1,215 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// This file is the public Responses extension surface of the unified
+// provider extension channel (ais.Extensions). Request-side values configure
+// Responses-only parameters; response-side values carry the output items and
+// response identifiers written by this provider's translators. Every value
+// lives under the Name namespace of the node's Extensions map.
+//
+// Extension values are read-only once attached: the same value may be shared
+// by a request and its pipeline clones, so callers must not mutate a value
+// after setting it, and accessors return the stored value without copying.
+
+// RequestExtension carries the Responses-only request parameters. Attach it
+// with ExtendRequest; the translator reads it before building the wire body.
+type RequestExtension struct {
+	// PreviousResponseID chains this request onto a stored response, so the
+	// server supplies the earlier turns. Send only the new messages when
+	// setting it.
+	PreviousResponseID string
+
+	// Store controls whether the response is stored server-side; nil leaves
+	// the API default (stored).
+	Store *bool
+
+	// Include requests additional output data, e.g.
+	// "reasoning.encrypted_content" to replay reasoning items with Store
+	// false.
+	Include []string
+
+	// Instructions is the top-level system/developer instruction. Canonical
+	// system messages are sent in place as input items either way.
+	Instructions string
+
+	// Tools are native tool objects appended verbatim after the canonical
+	// function tools — the built-in tools such as {"type":"web_search"}.
+	Tools []json.RawMessage
+
+	// ReasoningSummary overrides reasoning.summary ("auto", "concise",
+	// "detailed"); empty derives it from the canonical Thinking.
+	ReasoningSummary string
+
+	// Verbosity sets text.verbosity ("low", "medium", "high").
+	Verbosity string
+
+	// Truncation sets the context truncation strategy ("auto", "disabled").
+	Truncation string
+
+	// Metadata, PromptCacheKey, SafetyIdentifier and ServiceTier map to the
+	// same-named request fields; zero values are omitted.
+	Metadata         map[string]string
+	PromptCacheKey   string
+	SafetyIdentifier string
+	ServiceTier      string
+}
+
+// MessageExtension carries the Responses-only per-message extension. On the
+// response side, this provider stores the output items of the assistant turn
+// in Items — reasoning items with their encrypted content, messages,
+// function calls and built-in tool calls — and the ID of the response that
+// produced them. When the message is sent back, the translator replays Items
+// verbatim instead of rebuilding the turn from Content and ToolCalls; drop
+// the extension to send an edited turn.
+type MessageExtension struct {
+	// ResponseID is the ID of the response this turn came from; pass it as
+	// RequestExtension.PreviousResponseID to chain the next request.
+	ResponseID string
+	// Items are the turn's output items in output order.
+	Items []OutputItem
+}
+
+// MergeExtension implements ais.ExtensionMerger so streaming deltas
+// accumulate: Items concatenate in arrival order and a non-empty ResponseID
+// wins. It returns a fresh value — neither the receiver nor the delta is
+// mutated.
+func (e *MessageExtension) MergeExtension(delta any) any {
+	d, ok := delta.(*MessageExtension)
+	if !ok || d == nil {
+		return e
+	}
+
+	merged := &MessageExtension{ResponseID: e.ResponseID}
+	if d.ResponseID != "" {
+		merged.ResponseID = d.ResponseID
+	}
+
+	if len(e.Items)+len(d.Items) > 0 {
+		merged.Items = make([]OutputItem, 0, len(e.Items)+len(d.Items))
+		merged.Items = append(merged.Items, e.Items...)
+		merged.Items = append(merged.Items, d.Items...)
+	}
+
+	return merged
+}
+
+// ResponseExtension carries the Responses-only response-level metadata,
+// written by this provider on ais.ChatResponse (unary) and on the terminal
+// ais.StreamChunk (streaming).
+type ResponseExtension struct {
+	// Status is the response status: "completed", "incomplete", ….
+	Status string
+	// IncompleteReason explains an "incomplete" status, e.g.
+	// "max_output_tokens" or "content_filter".
+	IncompleteReason string
+	// PreviousResponseID echoes the response this one was chained onto.
+	PreviousResponseID string
+}
+
+// --- setters (request side) ---
+
+// ExtendRequest attaches the Responses request extension to a canonical
+// request. Passing nil removes a previously attached extension.
+func ExtendRequest(r *ais.ChatRequest, ext *RequestExtension) {
+	setExtension(&r.Extensions, ext)
+}
+
+// ExtendMessage attaches the Responses message extension to a canonical
+// message. Passing nil removes a previously attached extension.
+func ExtendMessage(m *ais.Message, ext *MessageExtension) {
+	setExtension(&m.Extensions, ext)
+}
+
+// setExtension stores ext under this provider's namespace; a typed nil
+// deletes the entry so the translator sees a genuinely absent extension.
+func setExtension[T any](exts *ais.Extensions, ext *T) {
+	if ext == nil {
+		delete(*exts, Name)
+
+		return
+	}
+
+	exts.Set(Name, ext)
+}
+
+// --- accessors ---
+
+// RequestExtensionOf returns the Responses request extension attached to r,
+// or nil when absent. A value of any other type also yields nil — the
+// translator rejects such a value with a *ais.ExtensionTypeError before any
+// network I/O.
+func RequestExtensionOf(r *ais.ChatRequest) *RequestExtension {
+	ext, _ := extensionOf[RequestExtension](r.Extensions, "")
+
+	return ext
+}
+
+// MessageExtensionOf returns the Responses message extension attached to m,
+// or nil when absent (same type contract as RequestExtensionOf).
+func MessageExtensionOf(m *ais.Message) *MessageExtension {
+	ext, _ := extensionOf[MessageExtension](m.Extensions, "")
+
+	return ext
+}
+
+// ResponseExtensionOf returns the Responses response-level metadata of a
+// unary response, or nil when the response carries none.
+func ResponseExtensionOf(r *ais.ChatResponse) *ResponseExtension {
+	ext, _ := extensionOf[ResponseExtension](r.Extensions, "")
+
+	return ext
+}
+
+// ChunkExtensionOf returns the Responses response-level metadata of a stream
+// chunk, or nil.
+func ChunkExtensionOf(c *ais.StreamChunk) *ResponseExtension {
+	ext, _ := extensionOf[ResponseExtension](c.Extensions, "")
+
+	return ext
+}
+
+// extensionOf reads this provider's namespace from an extension map. A
+// missing or nil entry is equivalent to a zero value (nil, no error). A value
+// of any other type yields a *ais.ExtensionTypeError naming the canonical
+// node.
+func extensionOf[T any](exts ais.Extensions, node string) (*T, error) {
+	v, ok := exts[Name]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	ext, ok := v.(*T)
+	if !ok {
+		return nil, &ais.ExtensionTypeError{
+			Provider: Name,
+			Node:     node,
+			Want:     fmt.Sprintf("*%T", *new(T)),
+			Value:    v,
+		}
+	}
+
+	return ext, nil
+}
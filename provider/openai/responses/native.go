@@ -0,0 +1,196 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const maxNativeBodySize = 1 << 20
+
+// Client calls the Responses API without canonical translation.
+type Client struct {
+	apiKey, baseURL string
+	httpClient      *http.Client
+}
+
+// ClientOption configures a native Client.
+type ClientOption func(*Client)
+
+// WithBaseURL overrides the API base URL (default https://api.openai.com/v1).
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) { c.baseURL = strings.TrimRight(url, "/") }
+}
+
+// WithHTTPClient supplies the HTTP transport used by the client.
+func WithHTTPClient(client *http.Client) ClientOption {
+	if client == nil {
+		panic("aimodel/openai-responses: nil HTTP client")
+	}
+	return func(c *Client) { c.httpClient = client }
+}
+
+// NewClient constructs a native Responses client.
+func NewClient(apiKey string, options ...ClientOption) *Client {
+	client := &Client{apiKey: apiKey, baseURL: responsesDefaultBaseURL, httpClient: http.DefaultClient}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// HTTPError reports a non-2xx Responses API response and retains its bounded
+// body.
+type HTTPError struct {
+	StatusCode          int
+	Code, Type, Message string
+	Body                json.RawMessage
+	Err                 error
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("openai-responses: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// request posts input with Stream forced to stream on a copy, leaving the
+// caller's request untouched.
+func (c *Client) request(ctx context.Context, input *Request, stream bool) (*http.Response, error) {
+	if input == nil {
+		return nil, fmt.Errorf("openai-responses: nil responses request")
+	}
+	wire := *input
+	wire.Stream = stream
+	body, err := json.Marshal(&wire)
+	if err != nil {
+		return nil, fmt.Errorf("openai-responses: marshal responses request: %w", err)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/responses", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("openai-responses: create responses request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Authorization", "Bearer "+c.apiKey)
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("openai-responses: send responses request: %w", err)
+	}
+	return response, nil
+}
+
+func parseNativeError(response *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxNativeBodySize))
+	result := &HTTPError{
+		StatusCode: response.StatusCode,
+		Body:       append(json.RawMessage(nil), body...),
+		Message:    string(body),
+		Err:        err,
+	}
+	if err != nil {
+		result.Message = "failed to read error response"
+		return result
+	}
+	var wire struct {
+		Error *Error `json:"error"`
+	}
+	if json.Unmarshal(body, &wire) == nil && wire.Error != nil {
+		result.Code, result.Type, result.Message = wire.Error.Code, wire.Error.Type, wire.Error.Message
+	}
+	return result
+}
+
+// CreateResponse performs a non-streaming native POST /responses call.
+func (c *Client) CreateResponse(ctx context.Context, request *Request) (*Response, error) {
+	response, err := c.request(ctx, request, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, parseNativeError(response)
+	}
+	var result Response
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("openai-responses: decode response: %w", err)
+	}
+	return &result, nil
+}
+
+// EventStream reads native Responses SSE events without canonical
+// aggregation.
+type EventStream struct {
+	body io.ReadCloser
+	scan *bufio.Scanner
+	once sync.Once
+}
+
+// CreateResponseStream starts a streaming native POST /responses call.
+func (c *Client) CreateResponseStream(ctx context.Context, request *Request) (*EventStream, error) {
+	response, err := c.request(ctx, request, true)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		defer func() { _ = response.Body.Close() }()
+		return nil, parseNativeError(response)
+	}
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxNativeBodySize)
+	return &EventStream{body: response.Body, scan: scanner}, nil
+}
+
+// Recv returns the next event in wire order, or io.EOF. Error events are
+// returned as events, not errors; Raw keeps every event's full payload.
+func (s *EventStream) Recv() (*StreamEvent, error) {
+	for s.scan.Scan() {
+		data, ok := strings.CutPrefix(s.scan.Text(), "data:")
+		if !ok {
+			continue
+		}
+		raw := []byte(strings.TrimSpace(data))
+		var event StreamEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("openai-responses: decode stream event: %w", err)
+		}
+		event.Raw = raw
+		return &event, nil
+	}
+	if err := s.scan.Err(); err != nil {
+		_ = s.Close()
+		return nil, fmt.Errorf("openai-responses: read stream: %w", err)
+	}
+	_ = s.Close()
+	return nil, io.EOF
+}
+
+// Close releases the response body and is safe to call repeatedly.
+func (s *EventStream) Close() error {
+	var err error
+	s.once.Do(func() { err = s.body.Close() })
+	return err
+}
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNativeCreateResponseAndImmutability(t *testing.T) {
+	var got Request
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("request = %s headers=%v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, `{"id":"resp_1","object":"response","status":"completed","model":"gpt-5","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"hi"}]}]}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	req := &Request{Model: "gpt-5", Input: []InputItem{{Type: "message", Role: "user", Content: NewTextContent("hello")}}, Stream: true}
+	resp, err := NewClient("key", WithBaseURL(s.URL), WithHTTPClient(s.Client())).CreateResponse(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stream || !req.Stream {
+		t.Fatalf("stream got=%v caller=%v", got.Stream, req.Stream)
+	}
+	if got.Input[0].Content.Text() != "hello" || resp.Output[0].Content[0].Text != "hi" || len(resp.Output[0].Raw) == 0 {
+		t.Fatalf("got=%+v resp=%+v", got, resp)
+	}
+}
+
+func TestNativeCreateResponseStream(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if _, err := io.WriteString(w, "event: response.future\ndata: {\"type\":\"response.future\",\"future\":true}\n\n"); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	stream, err := NewClient("key", WithBaseURL(s.URL), WithHTTPClient(s.Client())).CreateResponseStream(context.Background(), &Request{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != "response.future" || len(e.Raw) == 0 {
+		t.Fatalf("event=%+v", e)
+	}
+	if _, err = stream.Recv(); err != io.EOF {
+		t.Fatalf("EOF=%v", err)
+	}
+	if err = stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNativeHTTPError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		if _, err := io.WriteString(w, `{"error":{"message":"bad key","type":"invalid_request_error","code":"invalid_api_key"}}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	_, err := NewClient("key", WithBaseURL(s.URL), WithHTTPClient(s.Client())).CreateResponse(context.Background(), &Request{Model: "m"})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 401 || httpErr.Code != "invalid_api_key" || httpErr.Message != "bad key" {
+		t.Fatalf("err=%v", err)
+	}
+}
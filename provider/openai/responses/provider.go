@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package responses implements the OpenAI Responses API chat provider over
+// POST /responses, alongside the Chat Completions provider in the parent
+// openai package. It owns the full native wire layer (request/response
+// types, translation to and from the canonical schema, typed SSE events) and
+// a native client. Importing this package registers the provider under
+// Name.
+//
+// OpenAI reference: https://platform.openai.com/docs/api-reference/responses
+package responses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Name is the registered provider name. Select it via the root package's
+// WithProvider(responses.Name) after importing this package.
+const Name = "openai-responses"
+
+const responsesDefaultBaseURL = "https://api.openai.com/v1"
+
+func init() {
+	ais.Register(Name, New)
+}
+
+// New constructs a Responses provider. The base URL is optional (it defaults
+// to the OpenAI API) and the provider accepts no vendor options.
+func New(cfg ais.Config) (ais.ChatProvider, error) {
+	if cfg.Options != nil {
+		return nil, fmt.Errorf("aimodel/openai-responses: unexpected provider options of type %T", cfg.Options)
+	}
+
+	p := &provider{apiKey: cfg.APIKey, baseURL: cfg.BaseURL}
+	if p.baseURL == "" {
+		p.baseURL = responsesDefaultBaseURL
+	}
+
+	return p, nil
+}
+
+type provider struct {
+	apiKey  string
+	baseURL string
+}
+
+// NewChatRequest translates the canonical request into a /responses body.
+func (p *provider) NewChatRequest(ctx context.Context, req *ais.ChatRequest) (*http.Request, error) {
+	rr, err := toResponsesRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(rr)
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/responses", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("aimodel: create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	return httpReq, nil
+}
+
+// ParseChatResponse decodes a response object. A failed response or a
+// body-level error object becomes an APIError; a response with no output
+// items is ErrEmptyResponse.
+func (p *provider) ParseChatResponse(body io.Reader) (*ais.ChatResponse, error) {
+	var result Response
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("aimodel: decode response: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, responsesAPIError(0, result.Error)
+	}
+
+	if len(result.Output) == 0 {
+		return nil, ais.ErrEmptyResponse
+	}
+
+	return fromResponsesResponse(&result), nil
+}
+
+// ParseErrorResponse maps a non-2xx response body to an APIError, falling
+// back to the raw body when it carries no recognizable error object.
+func (p *provider) ParseErrorResponse(statusCode int, body []byte) error {
+	var errResp struct {
+		Error *Error `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == nil {
+		return &ais.APIError{
+			StatusCode: statusCode,
+			Message:    string(body),
+		}
+	}
+
+	return responsesAPIError(statusCode, errResp.Error)
+}
+
+// responsesAPIError converts a Responses error object.
+func responsesAPIError(statusCode int, e *Error) *ais.APIError {
+	return &ais.APIError{
+		StatusCode: statusCode,
+		Code:       e.Code,
+		Message:    e.Message,
+		Type:       e.Type,
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// newProvider builds a *provider with a default configuration, failing the
+// test on a factory error.
+func newProvider(t *testing.T) *provider {
+	t.Helper()
+
+	p, err := New(ais.Config{APIKey: "sk-test"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return p.(*provider)
+}
+
+// requestBody builds the wire body of req through the provider and decodes it
+// generically, so tests assert the exact JSON field names.
+func requestBody(t *testing.T, req *ais.ChatRequest) map[string]any {
+	t.Helper()
+
+	httpReq, err := newProvider(t).NewChatRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("NewChatRequest: %v", err)
+	}
+
+	data, _ := io.ReadAll(httpReq.Body)
+
+	var body map[string]any
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+
+	return body
+}
+
+// TestNewChatRequestEndpoint verifies the default URL and bearer auth.
+func TestNewChatRequestEndpoint(t *testing.T) {
+	httpReq, err := newProvider(t).NewChatRequest(context.Background(), &ais.ChatRequest{Model: "gpt-5"})
+	if err != nil {
+		t.Fatalf("NewChatRequest: %v", err)
+	}
+
+	if got := httpReq.URL.String(); got != "https://api.openai.com/v1/responses" {
+		t.Errorf("url = %s", got)
+	}
+	if got := httpReq.Header.Get("Authorization"); got != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+// TestNewRejectsOptions verifies the factory rejects vendor options.
+func TestNewRejectsOptions(t *testing.T) {
+	if _, err := New(ais.Config{APIKey: "k", Options: struct{}{}}); err == nil {
+		t.Fatal("expected error for unexpected options")
+	}
+}
+
+// TestParseErrorResponse verifies error bodies map to APIError, with the raw
+// body as fallback.
+func TestParseErrorResponse(t *testing.T) {
+	p := newProvider(t)
+
+	err := p.ParseErrorResponse(400, []byte(`{"error":{"message":"bad input","type":"invalid_request_error","code":"invalid_value"}}`))
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.Code != "invalid_value" || apiErr.Message != "bad input" {
+		t.Errorf("err = %v", err)
+	}
+
+	err = p.ParseErrorResponse(502, []byte("bad gateway"))
+	if !errors.As(err, &apiErr) || apiErr.Message != "bad gateway" {
+		t.Errorf("fallback err = %v", err)
+	}
+}
+
+// TestParseChatResponseFailed verifies a body-level error and an empty
+// output are reported as errors.
+func TestParseChatResponseFailed(t *testing.T) {
+	p := newProvider(t)
+
+	_, err := p.ParseChatResponse(strings.NewReader(`{"id":"r","status":"failed","output":[],"error":{"code":"server_error","message":"boom"}}`))
+
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "server_error" {
+		t.Errorf("err = %v, want *ais.APIError", err)
+	}
+
+	if _, err := p.ParseChatResponse(strings.NewReader(`{"id":"r","status":"completed","output":[]}`)); !errors.Is(err, ais.ErrEmptyResponse) {
+		t.Errorf("err = %v, want ErrEmptyResponse", err)
+	}
+}
+
+// TestProviderRegistered verifies importing the package registers Name.
+func TestProviderRegistered(t *testing.T) {
+	if _, ok := ais.Lookup(Name); !ok {
+		t.Fatalf("provider %q not registered", Name)
+	}
+}
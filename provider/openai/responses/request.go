@@ -0,0 +1,316 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// toResponsesRequest converts a canonical request into a /responses body.
+// Responses-only parameters arrive through RequestExtension /
+// MessageExtension; a mis-typed extension value or a part the API cannot
+// represent fails here, before any network I/O. Stop and TopK have no
+// Responses counterpart and are not sent.
+func toResponsesRequest(req *ais.ChatRequest) (*Request, error) {
+	reqExt, err := extensionOf[RequestExtension](req.Extensions, "ChatRequest")
+	if err != nil {
+		return nil, err
+	}
+	if reqExt == nil {
+		reqExt = &RequestExtension{}
+	}
+
+	rr := &Request{
+		Model:              req.Model,
+		Instructions:       reqExt.Instructions,
+		Include:            reqExt.Include,
+		Metadata:           reqExt.Metadata,
+		ParallelToolCalls:  req.ParallelToolCalls,
+		PreviousResponseID: reqExt.PreviousResponseID,
+		PromptCacheKey:     reqExt.PromptCacheKey,
+		Reasoning:          toResponsesReasoning(req, reqExt),
+		SafetyIdentifier:   reqExt.SafetyIdentifier,
+		ServiceTier:        reqExt.ServiceTier,
+		Store:              reqExt.Store,
+		Stream:             req.Stream,
+		Temperature:        req.Temperature,
+		Text:               toResponsesText(req.ResponseFormat, reqExt.Verbosity),
+		ToolChoice:         toResponsesToolChoice(req.ToolChoice),
+		TopP:               req.TopP,
+		Truncation:         reqExt.Truncation,
+	}
+
+	switch {
+	case req.MaxCompletionTokens != nil:
+		rr.MaxOutputTokens = req.MaxCompletionTokens
+	case req.MaxTokens != nil: //nolint:staticcheck // deprecated field read on purpose
+		rr.MaxOutputTokens = req.MaxTokens //nolint:staticcheck // deprecated field read on purpose
+	}
+
+	for _, m := range req.Messages {
+		items, err := toResponsesItems(m)
+		if err != nil {
+			return nil, err
+		}
+		rr.Input = append(rr.Input, items...)
+	}
+
+	tools, err := toResponsesTools(req.Tools)
+	if err != nil {
+		return nil, err
+	}
+	rr.Tools = append(tools, reqExt.Tools...)
+
+	return rr, nil
+}
+
+// toResponsesItems translates one canonical message into input items. System
+// and user messages become message items in place; a tool result becomes a
+// function_call_output. An assistant message carrying MessageExtension.Items
+// is replayed verbatim, keeping the reasoning items reasoning models need
+// across tool calls; otherwise it is rebuilt as a message item followed by
+// one function_call item per tool call. Canonical Thinking text is not sent
+// back: reasoning is only accepted as a reasoning item.
+func toResponsesItems(m ais.Message) ([]InputItem, error) {
+	switch m.Role {
+	case ais.RoleTool:
+		return []InputItem{{Type: "function_call_output", CallID: m.ToolCallID, Output: m.Content.Text()}}, nil
+	case ais.RoleAssistant:
+		ext, err := extensionOf[MessageExtension](m.Extensions, "Message")
+		if err != nil {
+			return nil, err
+		}
+		if ext != nil && len(ext.Items) > 0 {
+			return replayItems(ext.Items), nil
+		}
+
+		var items []InputItem
+		if text := m.Content.Text(); text != "" {
+			items = append(items, InputItem{Type: "message", Role: "assistant", Content: NewTextContent(text)})
+		}
+		for _, tc := range m.ToolCalls {
+			items = append(items, InputItem{
+				Type:      "function_call",
+				CallID:    tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
+
+		return items, nil
+	}
+
+	item := InputItem{Type: "message", Role: string(m.Role)}
+
+	parts := m.Content.Parts()
+	if parts == nil {
+		item.Content = NewTextContent(m.Content.Text())
+
+		return []InputItem{item}, nil
+	}
+
+	converted := make([]ContentPart, 0, len(parts))
+	for _, p := range parts {
+		part, err := toResponsesContentPart(p)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, part)
+	}
+	item.Content = NewPartsContent(converted...)
+
+	return []InputItem{item}, nil
+}
+
+// replayItems sends output items back as input. Items decoded from a response
+// keep their full JSON and are replayed verbatim; items built by hand are sent
+// through their typed fields.
+func replayItems(items []OutputItem) []InputItem {
+	input := make([]InputItem, 0, len(items))
+	for _, o := range items {
+		if len(o.Raw) > 0 {
+			input = append(input, InputItem{Raw: o.Raw})
+
+			continue
+		}
+
+		input = append(input, InputItem{
+			Type:             o.Type,
+			ID:               o.ID,
+			Role:             o.Role,
+			Content:          outputContent(o),
+			CallID:           o.CallID,
+			Name:             o.Name,
+			Arguments:        o.Arguments,
+			Summary:          o.Summary,
+			EncryptedContent: o.EncryptedContent,
+		})
+	}
+
+	return input
+}
+
+// outputContent returns the content of a hand-built message item; other item
+// types carry none.
+func outputContent(o OutputItem) *MessageContent {
+	if o.Type != "message" {
+		return nil
+	}
+
+	return NewPartsContent(o.Content...)
+}
+
+// toResponsesContentPart maps one canonical part onto its input counterpart.
+func toResponsesContentPart(p ais.ContentPart) (ContentPart, error) {
+	switch p.Type {
+	case "text":
+		return ContentPart{Type: "input_text", Text: p.Text}, nil
+	case "image_url":
+		if p.ImageURL == nil {
+			return ContentPart{}, &ais.ContentPartError{Provider: Name, Type: p.Type, Reason: "missing image_url"}
+		}
+
+		return ContentPart{Type: "input_image", ImageURL: p.ImageURL.URL, Detail: p.ImageURL.Detail}, nil
+	case "input_audio":
+		if p.InputAudio == nil {
+			return ContentPart{}, &ais.ContentPartError{Provider: Name, Type: p.Type, Reason: "missing input_audio"}
+		}
+
+		return ContentPart{Type: "input_audio", InputAudio: &InputAudio{Data: p.InputAudio.Data, Format: p.InputAudio.Format}}, nil
+	case "file":
+		if p.File == nil {
+			return ContentPart{}, &ais.ContentPartError{Provider: Name, Type: p.Type, Reason: "missing file"}
+		}
+
+		return ContentPart{
+			Type:     "input_file",
+			FileData: p.File.FileData,
+			FileID:   p.File.FileID,
+			FileURL:  p.File.FileURL,
+			Filename: p.File.Filename,
+		}, nil
+	default:
+		return ContentPart{}, &ais.ContentPartError{Provider: Name, Type: p.Type, Reason: "unsupported content part type"}
+	}
+}
+
+// toResponsesTools maps canonical function tools onto the flat Responses
+// function tool shape. Built-in tools have no canonical form: add them
+// through RequestExtension.Tools.
+func toResponsesTools(tools []ais.Tool) ([]json.RawMessage, error) {
+	var out []json.RawMessage
+	for _, t := range tools {
+		if t.Type != "" && t.Type != "function" {
+			return nil, fmt.Errorf("aimodel/openai-responses: tool type %q is not supported; add built-in tools through RequestExtension.Tools", t.Type)
+		}
+
+		raw, err := json.Marshal(FunctionTool{
+			Type:        "function",
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+			Strict:      t.Strict,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("aimodel/openai-responses: marshal tool %q: %w", t.Function.Name, err)
+		}
+		out = append(out, raw)
+	}
+
+	return out, nil
+}
+
+// toResponsesToolChoice flattens the Chat Completions named-function choice
+// {type:"function", function:{name}} into {type:"function", name}. The
+// string modes and any other value pass through unchanged.
+func toResponsesToolChoice(tc any) any {
+	m, ok := tc.(map[string]any)
+	if !ok {
+		return tc
+	}
+
+	if fn, ok := m["function"].(map[string]any); ok {
+		if name, ok := fn["name"].(string); ok {
+			return map[string]any{"type": "function", "name": name}
+		}
+	}
+
+	return tc
+}
+
+// toResponsesText maps the canonical ResponseFormat onto text.format: the
+// nested Chat Completions json_schema object is flattened, json_object and
+// text pass through. It returns nil when neither a format nor a verbosity is
+// set.
+func toResponsesText(rf any, verbosity string) *TextConfig {
+	var format *TextFormat
+
+	if m, ok := rf.(map[string]any); ok {
+		switch t, _ := m["type"].(string); t {
+		case "json_object", "text":
+			format = &TextFormat{Type: t}
+		case "json_schema":
+			spec := m
+			if nested, ok := m["json_schema"].(map[string]any); ok {
+				spec = nested
+			}
+
+			format = &TextFormat{Type: t, Schema: spec["schema"]}
+			format.Name, _ = spec["name"].(string)
+			format.Description, _ = spec["description"].(string)
+			if strict, ok := spec["strict"].(bool); ok {
+				format.Strict = &strict
+			}
+		}
+	}
+
+	if format == nil && verbosity == "" {
+		return nil
+	}
+
+	return &TextConfig{Format: format, Verbosity: verbosity}
+}
+
+// toResponsesReasoning maps the canonical reasoning controls: ReasoningEffort
+// becomes reasoning.effort, and a Thinking that is not disabled and not
+// "omitted" asks for an automatic summary, which the response translators
+// surface as Thinking. Thinking "disabled" without an effort sends effort
+// "none".
+func toResponsesReasoning(req *ais.ChatRequest, ext *RequestExtension) *Reasoning {
+	r := &Reasoning{Effort: req.ReasoningEffort, Summary: ext.ReasoningSummary}
+
+	if t := req.Thinking; t != nil {
+		switch {
+		case t.Type == "disabled":
+			if r.Effort == "" {
+				r.Effort = ais.ReasoningEffortNone
+			}
+		case r.Summary == "" && t.Display != "omitted":
+			r.Summary = "auto"
+		}
+	}
+
+	if *r == (Reasoning{}) {
+		return nil
+	}
+
+	return r
+}
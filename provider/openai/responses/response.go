@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// fromResponsesResponse converts a response object into a canonical
+// response. The output items fold into one assistant message: output_text
+// into Content, reasoning summaries into Thinking and function_call items
+// into ToolCalls; the items themselves and the response ID are kept on
+// MessageExtension for replay and chaining.
+func fromResponsesResponse(r *Response) *ais.ChatResponse {
+	msg, calls := fromOutputItems(r.Output, 0)
+	msg.Role = ais.RoleAssistant
+	msg.Extensions.Set(Name, &MessageExtension{ResponseID: r.ID, Items: r.Output})
+
+	resp := &ais.ChatResponse{
+		ID:      r.ID,
+		Object:  "chat.completion",
+		Created: r.CreatedAt,
+		Model:   r.Model,
+		Choices: []ais.Choice{{
+			Message:      msg,
+			FinishReason: finishReason(r, calls > 0),
+		}},
+	}
+
+	if r.Usage != nil {
+		resp.Usage = canonicalUsage(r.Usage, r.ServiceTier)
+	}
+
+	resp.Extensions.Set(Name, responseExtension(r))
+
+	return resp
+}
+
+// fromOutputItems folds output items into a canonical message. Tool calls
+// are numbered from firstToolIndex; it returns the message and the number of
+// calls found. Item types without a canonical form (built-in tool calls)
+// contribute nothing here — they stay on MessageExtension.Items.
+func fromOutputItems(items []OutputItem, firstToolIndex int) (ais.Message, int) {
+	var (
+		msg      ais.Message
+		text     strings.Builder
+		thinking strings.Builder
+	)
+
+	for _, item := range items {
+		switch item.Type {
+		case "message":
+			for _, part := range item.Content {
+				if part.Type == "output_text" {
+					text.WriteString(part.Text)
+				}
+			}
+		case "reasoning":
+			for _, s := range item.Summary {
+				thinking.WriteString(s.Text)
+			}
+			if len(item.Summary) == 0 {
+				for _, part := range item.Content {
+					thinking.WriteString(part.Text)
+				}
+			}
+		case "function_call":
+			msg.ToolCalls = append(msg.ToolCalls, ais.ToolCall{
+				Index:    firstToolIndex + len(msg.ToolCalls),
+				ID:       item.CallID,
+				Type:     "function",
+				Function: ais.FunctionCall{Name: item.Name, Arguments: item.Arguments},
+			})
+		}
+	}
+
+	msg.Content = ais.NewTextContent(text.String())
+	msg.Thinking = thinking.String()
+
+	return msg, len(msg.ToolCalls)
+}
+
+// finishReason derives the canonical finish reason from the response status:
+// a completed response stops, or reports tool_calls when it called
+// functions; an incomplete one reports length or content_filter from its
+// reason. Any other status passes through verbatim.
+func finishReason(r *Response, hasToolCalls bool) ais.FinishReason {
+	switch r.Status {
+	case "completed":
+		if hasToolCalls {
+			return ais.FinishReasonToolCalls
+		}
+
+		return ais.FinishReasonStop
+	case "incomplete":
+		if r.IncompleteDetails != nil {
+			switch r.IncompleteDetails.Reason {
+			case "max_output_tokens":
+				return ais.FinishReasonLength
+			case "content_filter":
+				return ais.FinishReasonContentFilter
+			}
+
+			return ais.FinishReason(r.IncompleteDetails.Reason)
+		}
+
+		return ais.FinishReasonLength
+	default:
+		return ais.FinishReason(r.Status)
+	}
+}
+
+// responseExtension captures the response-level metadata.
+func responseExtension(r *Response) *ResponseExtension {
+	ext := &ResponseExtension{Status: r.Status, PreviousResponseID: r.PreviousResponseID}
+	if r.IncompleteDetails != nil {
+		ext.IncompleteReason = r.IncompleteDetails.Reason
+	}
+
+	return ext
+}
+
+// canonicalUsage maps the response usage. Cached input tokens become
+// CacheReadTokens and reasoning tokens ReasoningTokens; both stay included in
+// PromptTokens / CompletionTokens, as with Chat Completions.
+func canonicalUsage(u *Usage, serviceTier string) ais.Usage {
+	usage := ais.Usage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      u.TotalTokens,
+		ServiceTier:      serviceTier,
+	}
+	if u.InputTokensDetails != nil {
+		usage.CacheReadTokens = u.InputTokensDetails.CachedTokens
+	}
+	if u.OutputTokensDetails != nil {
+		usage.ReasoningTokens = u.OutputTokensDetails.ReasoningTokens
+	}
+
+	return usage
+}
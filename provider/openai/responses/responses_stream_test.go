@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// sse frames typed events the way the API does: an event line, a data line
+// and a blank separator.
+func sse(events ...string) string {
+	var b strings.Builder
+	for _, e := range events {
+		typ := e[strings.Index(e, `"type":"`)+8:]
+		typ = typ[:strings.IndexByte(typ, '"')]
+		b.WriteString("event: " + typ + "\ndata: " + e + "\n\n")
+	}
+
+	return b.String()
+}
+
+// collectStream drains a decoder over body, folding the deltas into one
+// message, and returns every chunk.
+func collectStream(t *testing.T, body string) ([]*ais.StreamChunk, ais.Message, error) {
+	t.Helper()
+
+	dec := newProvider(t).NewStreamDecoder(strings.NewReader(body))
+
+	var (
+		chunks []*ais.StreamChunk
+		msg    ais.Message
+	)
+
+	for {
+		chunk, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks, msg, nil
+		}
+		if err != nil {
+			return chunks, msg, err
+		}
+		chunks = append(chunks, chunk)
+		for i := range chunk.Choices {
+			msg.AppendDelta(&chunk.Choices[i].Delta)
+		}
+	}
+}
+
+// TestResponsesStreamText verifies text and reasoning summary deltas, the
+// response identity on every chunk and the terminal usage.
+func TestResponsesStreamText(t *testing.T) {
+	body := sse(
+		`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1","created_at":1760000000,"status":"in_progress","model":"gpt-5","output":[]}}`,
+		`{"type":"response.in_progress","sequence_number":1,"response":{"id":"resp_1","status":"in_progress","output":[]}}`,
+		`{"type":"response.reasoning_summary_text.delta","sequence_number":2,"item_id":"rs_1","output_index":0,"summary_index":0,"delta":"thinking"}`,
+		`{"type":"response.output_item.done","sequence_number":3,"output_index":0,"item":{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"thinking"}]}}`,
+		`{"type":"response.output_text.delta","sequence_number":4,"item_id":"msg_1","output_index":1,"content_index":0,"delta":"Hi"}`,
+		`{"type":"response.output_text.delta","sequence_number":5,"item_id":"msg_1","output_index":1,"content_index":0,"delta":" there"}`,
+		`{"type":"response.output_text.done","sequence_number":6,"item_id":"msg_1","output_index":1,"content_index":0,"text":"Hi there"}`,
+		`{"type":"response.completed","sequence_number":7,"response":{"id":"resp_1","status":"completed","model":"gpt-5","output":[],"usage":{"input_tokens":5,"output_tokens":7,"output_tokens_details":{"reasoning_tokens":3},"total_tokens":12}}}`,
+	)
+
+	chunks, msg, err := collectStream(t, body)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	for _, c := range chunks {
+		if c.ID != "resp_1" || c.Model != "gpt-5" {
+			t.Errorf("chunk identity = %q %q", c.ID, c.Model)
+		}
+	}
+
+	if chunks[0].Choices[0].Delta.Role != ais.RoleAssistant || msg.Content.Text() != "Hi there" || msg.Thinking != "thinking" {
+		t.Errorf("message = %+v", msg)
+	}
+	if ext := MessageExtensionOf(&msg); ext == nil || ext.ResponseID != "resp_1" || len(ext.Items) != 1 || ext.Items[0].Type != "reasoning" {
+		t.Errorf("message extension = %+v", ext)
+	}
+
+	last := chunks[len(chunks)-1]
+	if fr := last.Choices[0].FinishReason; fr == nil || *fr != string(ais.FinishReasonStop) {
+		t.Errorf("finish_reason = %v", fr)
+	}
+	if u := last.Usage; u == nil || u.TotalTokens != 12 || u.ReasoningTokens != 3 {
+		t.Errorf("usage = %+v", u)
+	}
+	if ext := ChunkExtensionOf(last); ext == nil || ext.Status != "completed" {
+		t.Errorf("chunk extension = %+v", ext)
+	}
+}
+
+// TestResponsesStreamToolCalls verifies function_call items open indexed
+// tool calls whose argument deltas accumulate, and the turn finishes with
+// tool_calls.
+func TestResponsesStreamToolCalls(t *testing.T) {
+	body := sse(
+		`{"type":"response.created","response":{"id":"resp_2","model":"gpt-5","status":"in_progress","output":[]}}`,
+		`{"type":"response.output_item.added","output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"call_a","name":"a","arguments":""}}`,
+		`{"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"{\"x\":"}`,
+		`{"type":"response.output_item.added","output_index":1,"item":{"type":"function_call","id":"fc_2","call_id":"call_b","name":"b","arguments":""}}`,
+		`{"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"1}"}`,
+		`{"type":"response.function_call_arguments.delta","item_id":"fc_2","output_index":1,"delta":"{}"}`,
+		`{"type":"response.completed","response":{"id":"resp_2","status":"completed","output":[]}}`,
+	)
+
+	chunks, msg, err := collectStream(t, body)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("tool calls = %+v", msg.ToolCalls)
+	}
+	if tc := msg.ToolCalls[0]; tc.ID != "call_a" || tc.Function.Name != "a" || tc.Function.Arguments != `{"x":1}` {
+		t.Errorf("first call = %+v", tc)
+	}
+	if tc := msg.ToolCalls[1]; tc.ID != "call_b" || tc.Index != 1 || tc.Function.Arguments != "{}" {
+		t.Errorf("second call = %+v", tc)
+	}
+
+	if fr := chunks[len(chunks)-1].Choices[0].FinishReason; fr == nil || *fr != string(ais.FinishReasonToolCalls) {
+		t.Errorf("finish_reason = %v, want tool_calls", fr)
+	}
+}
+
+// TestResponsesStreamErrors verifies error events and failed responses end
+// the stream with an *ais.APIError.
+func TestResponsesStreamErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"error event", sse(`{"type":"error","code":"rate_limit_exceeded","message":"slow down"}`), "rate_limit_exceeded"},
+		{"failed response", sse(`{"type":"response.failed","response":{"id":"r","status":"failed","output":[],"error":{"code":"server_error","message":"boom"}}}`), "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := collectStream(t, tt.body)
+
+			var apiErr *ais.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+				t.Fatalf("err = %v, want *ais.APIError with code %q", err, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestToResponsesRequest verifies messages become input items in place, and
+// the tools, tool choice, text format, reasoning and request extension
+// mapping.
+func TestToResponsesRequest(t *testing.T) {
+	req := &ais.ChatRequest{
+		Model: "gpt-5",
+		Messages: []ais.Message{
+			{Role: ais.RoleSystem, Content: ais.NewTextContent("be brief")},
+			{Role: ais.RoleUser, Content: ais.NewPartsContent(
+				ais.ContentPart{Type: "text", Text: "what is this?"},
+				ais.ContentPart{Type: "image_url", ImageURL: &ais.ImageURL{URL: "https://x/a.png", Detail: "low"}},
+				ais.ContentPart{Type: "file", File: &ais.File{FileURL: "https://x/a.pdf"}},
+			)},
+			{Role: ais.RoleAssistant, ToolCalls: []ais.ToolCall{
+				{ID: "call_1", Type: "function", Function: ais.FunctionCall{Name: "lookup", Arguments: `{"q":"go"}`}},
+			}},
+			{Role: ais.RoleTool, ToolCallID: "call_1", Content: ais.NewTextContent("found")},
+		},
+		MaxCompletionTokens: new(100),
+		Stop:                []string{"END"},
+		Tools: []ais.Tool{{Type: "function", Function: ais.FunctionDefinition{
+			Name: "lookup", Parameters: map[string]any{"type": "object"},
+		}}},
+		ToolChoice:      map[string]any{"type": "function", "function": map[string]any{"name": "lookup"}},
+		ResponseFormat:  map[string]any{"type": "json_schema", "json_schema": map[string]any{"name": "out", "schema": map[string]any{"type": "object"}, "strict": true}},
+		ReasoningEffort: ais.ReasoningEffortHigh,
+		Thinking:        &ais.Thinking{Type: "adaptive"},
+	}
+	ExtendRequest(req, &RequestExtension{
+		PreviousResponseID: "resp_0",
+		Store:              new(false),
+		Include:            []string{"reasoning.encrypted_content"},
+		Tools:              []json.RawMessage{json.RawMessage(`{"type":"web_search"}`)},
+	})
+
+	body := requestBody(t, req)
+
+	if body["max_output_tokens"] != float64(100) || body["previous_response_id"] != "resp_0" || body["store"] != false {
+		t.Errorf("scalars = %v", body)
+	}
+	if _, ok := body["stop"]; ok {
+		t.Error("stop must not be sent")
+	}
+
+	input := body["input"].([]any)
+	if len(input) != 4 {
+		t.Fatalf("input len = %d, want 4", len(input))
+	}
+
+	system := input[0].(map[string]any)
+	if system["type"] != "message" || system["role"] != "system" || system["content"] != "be brief" {
+		t.Errorf("system item = %v", system)
+	}
+
+	parts := input[1].(map[string]any)["content"].([]any)
+	if p := parts[0].(map[string]any); p["type"] != "input_text" {
+		t.Errorf("text part = %v", p)
+	}
+	if p := parts[1].(map[string]any); p["type"] != "input_image" || p["image_url"] != "https://x/a.png" || p["detail"] != "low" {
+		t.Errorf("image part = %v", p)
+	}
+	if p := parts[2].(map[string]any); p["type"] != "input_file" || p["file_url"] != "https://x/a.pdf" {
+		t.Errorf("file part = %v", p)
+	}
+
+	call := input[2].(map[string]any)
+	if call["type"] != "function_call" || call["call_id"] != "call_1" || call["name"] != "lookup" || call["arguments"] != `{"q":"go"}` {
+		t.Errorf("function_call item = %v", call)
+	}
+
+	output := input[3].(map[string]any)
+	if output["type"] != "function_call_output" || output["call_id"] != "call_1" || output["output"] != "found" {
+		t.Errorf("function_call_output item = %v", output)
+	}
+
+	tools := body["tools"].([]any)
+	if fn := tools[0].(map[string]any); fn["type"] != "function" || fn["name"] != "lookup" || fn["parameters"] == nil {
+		t.Errorf("function tool = %v", fn)
+	}
+	if ws := tools[1].(map[string]any); ws["type"] != "web_search" {
+		t.Errorf("built-in tool = %v", ws)
+	}
+
+	if tc := body["tool_choice"].(map[string]any); tc["type"] != "function" || tc["name"] != "lookup" {
+		t.Errorf("tool_choice = %v", tc)
+	}
+
+	format := body["text"].(map[string]any)["format"].(map[string]any)
+	if format["type"] != "json_schema" || format["name"] != "out" || format["strict"] != true || format["schema"] == nil {
+		t.Errorf("text.format = %v", format)
+	}
+
+	if r := body["reasoning"].(map[string]any); r["effort"] != "high" || r["summary"] != "auto" {
+		t.Errorf("reasoning = %v", r)
+	}
+}
+
+// TestToResponsesReasoning verifies the canonical thinking controls map onto
+// reasoning.effort / reasoning.summary.
+func TestToResponsesReasoning(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ais.ChatRequest
+		ext     RequestExtension
+		effort  string
+		summary string
+		wantNil bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "effort only", req: ais.ChatRequest{ReasoningEffort: ais.ReasoningEffortLow}, effort: "low"},
+		{name: "thinking enabled", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "enabled"}}, summary: "auto"},
+		{name: "display omitted", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "adaptive", Display: "omitted"}}, wantNil: true},
+		{name: "disabled", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "disabled"}}, effort: "none"},
+		{name: "summary override", req: ais.ChatRequest{Thinking: &ais.Thinking{Type: "enabled"}}, ext: RequestExtension{ReasoningSummary: "detailed"}, summary: "detailed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := toResponsesReasoning(&tt.req, &tt.ext)
+			if tt.wantNil {
+				if r != nil {
+					t.Errorf("reasoning = %+v, want nil", r)
+				}
+
+				return
+			}
+			if r == nil || r.Effort != tt.effort || r.Summary != tt.summary {
+				t.Errorf("reasoning = %+v, want effort %q summary %q", r, tt.effort, tt.summary)
+			}
+		})
+	}
+}
+
+// TestToResponsesRequestRejects verifies unrepresentable input fails before
+// any network I/O with a typed error.
+func TestToResponsesRequestRejects(t *testing.T) {
+	var cpe *ais.ContentPartError
+	_, err := toResponsesRequest(&ais.ChatRequest{Model: "m", Messages: []ais.Message{{
+		Role: ais.RoleUser, Content: ais.NewPartsContent(ais.ContentPart{Type: "video_url"}),
+	}}})
+	if !errors.As(err, &cpe) || cpe.Provider != Name {
+		t.Errorf("unknown part err = %v, want *ais.ContentPartError", err)
+	}
+
+	if _, err := toResponsesRequest(&ais.ChatRequest{Model: "m", Tools: []ais.Tool{{Type: "web_search"}}}); err == nil {
+		t.Error("non-function canonical tool accepted")
+	}
+
+	req := &ais.ChatRequest{Model: "m"}
+	req.Extensions.Set(Name, "wrong")
+
+	var ete *ais.ExtensionTypeError
+	if _, err := toResponsesRequest(req); !errors.As(err, &ete) {
+		t.Errorf("mis-typed extension err = %v, want *ais.ExtensionTypeError", err)
+	}
+}
+
+const toolTurnResponse = `{
+	"id": "resp_1",
+	"object": "response",
+	"created_at": 1760000000,
+	"status": "completed",
+	"model": "gpt-5",
+	"output": [
+		{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "need a lookup"}], "encrypted_content": "enc-1"},
+		{"type": "message", "id": "msg_1", "status": "completed", "role": "assistant", "content": [{"type": "output_text", "text": "Checking.", "annotations": []}]},
+		{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "lookup", "arguments": "{\"q\":\"go\"}", "status": "completed"},
+		{"type": "web_search_call", "id": "ws_1", "status": "completed", "action": {"type": "search", "query": "go"}}
+	],
+	"usage": {"input_tokens": 20, "input_tokens_details": {"cached_tokens": 8}, "output_tokens": 30, "output_tokens_details": {"reasoning_tokens": 12}, "total_tokens": 50},
+	"service_tier": "default"
+}`
+
+// TestFromResponsesResponse verifies output items fold into one assistant
+// message and the items, response ID and status land on the extensions.
+func TestFromResponsesResponse(t *testing.T) {
+	cr, err := newProvider(t).ParseChatResponse(strings.NewReader(toolTurnResponse))
+	if err != nil {
+		t.Fatalf("ParseChatResponse: %v", err)
+	}
+
+	if cr.ID != "resp_1" || cr.Model != "gpt-5" || cr.Created != 1760000000 {
+		t.Errorf("response = %+v", cr)
+	}
+
+	choice := cr.Choices[0]
+	msg := choice.Message
+	if msg.Role != ais.RoleAssistant || msg.Content.Text() != "Checking." || msg.Thinking != "need a lookup" {
+		t.Errorf("message = %+v", msg)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "call_1" || msg.ToolCalls[0].Function.Name != "lookup" {
+		t.Errorf("tool calls = %+v", msg.ToolCalls)
+	}
+	if choice.FinishReason != ais.FinishReasonToolCalls {
+		t.Errorf("finish_reason = %q", choice.FinishReason)
+	}
+
+	ext := MessageExtensionOf(&msg)
+	if ext == nil || ext.ResponseID != "resp_1" || len(ext.Items) != 4 || ext.Items[0].EncryptedContent != "enc-1" {
+		t.Fatalf("message extension = %+v", ext)
+	}
+	if rext := ResponseExtensionOf(cr); rext == nil || rext.Status != "completed" {
+		t.Errorf("response extension = %+v", rext)
+	}
+
+	u := cr.Usage
+	if u.PromptTokens != 20 || u.CompletionTokens != 30 || u.CacheReadTokens != 8 || u.ReasoningTokens != 12 || u.ServiceTier != "default" {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+// TestReplayOutputItems verifies an assistant turn from a response is sent
+// back as its output items verbatim — reasoning and built-in tool calls
+// included — ahead of the tool result.
+func TestReplayOutputItems(t *testing.T) {
+	cr, err := newProvider(t).ParseChatResponse(strings.NewReader(toolTurnResponse))
+	if err != nil {
+		t.Fatalf("ParseChatResponse: %v", err)
+	}
+
+	body := requestBody(t, &ais.ChatRequest{Model: "gpt-5", Messages: []ais.Message{
+		{Role: ais.RoleUser, Content: ais.NewTextContent("search go")},
+		cr.Choices[0].Message,
+		{Role: ais.RoleTool, ToolCallID: "call_1", Content: ais.NewTextContent("found")},
+	}})
+
+	input := body["input"].([]any)
+	if len(input) != 6 {
+		t.Fatalf("input len = %d, want 6", len(input))
+	}
+
+	reasoning := input[1].(map[string]any)
+	if reasoning["type"] != "reasoning" || reasoning["id"] != "rs_1" || reasoning["encrypted_content"] != "enc-1" {
+		t.Errorf("reasoning item = %v", reasoning)
+	}
+	if ws := input[4].(map[string]any); ws["type"] != "web_search_call" || ws["action"] == nil {
+		t.Errorf("built-in call item = %v", ws)
+	}
+	if out := input[5].(map[string]any); out["type"] != "function_call_output" {
+		t.Errorf("tool result item = %v", out)
+	}
+}
+
+// TestFinishReason verifies the status / incomplete reason folding.
+func TestFinishReason(t *testing.T) {
+	tests := []struct {
+		resp  Response
+		tools bool
+		want  ais.FinishReason
+	}{
+		{Response{Status: "completed"}, false, ais.FinishReasonStop},
+		{Response{Status: "completed"}, true, ais.FinishReasonToolCalls},
+		{Response{Status: "incomplete", IncompleteDetails: &IncompleteDetails{Reason: "max_output_tokens"}}, false, ais.FinishReasonLength},
+		{Response{Status: "incomplete", IncompleteDetails: &IncompleteDetails{Reason: "content_filter"}}, false, ais.FinishReasonContentFilter},
+		{Response{Status: "cancelled"}, false, "cancelled"},
+	}
+
+	for _, tt := range tests {
+		if got := finishReason(&tt.resp, tt.tools); got != tt.want {
+			t.Errorf("finishReason(%+v, %v) = %q, want %q", tt.resp, tt.tools, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// NewStreamDecoder returns a decoder for the typed Responses SSE events. Each
+// data line is one event whose "type" names it; the stream ends after the
+// terminal response.completed / response.incomplete event, with no sentinel.
+func (p *provider) NewStreamDecoder(body io.Reader) ais.StreamDecoder {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64*1024), ais.MaxStreamLineSize)
+
+	return &streamDecoder{sc: sc, toolIdx: make(map[int]int)}
+}
+
+type streamDecoder struct {
+	sc *bufio.Scanner
+
+	// id, model and created come from response.created and are stamped on
+	// every chunk.
+	id      string
+	model   string
+	created int64
+
+	// toolIdx maps a function_call item's output_index to its canonical tool
+	// call index, so argument deltas land on the right call.
+	toolIdx map[int]int
+}
+
+func (d *streamDecoder) Next() (*ais.StreamChunk, error) {
+	sc := d.sc
+
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			// Blank separators, event names, comments and other SSE fields.
+			continue
+		}
+
+		var ev StreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+			return nil, fmt.Errorf("aimodel: decode stream chunk: %w", err)
+		}
+
+		chunk, err := d.chunk(&ev)
+		if err != nil {
+			return nil, err
+		}
+		if chunk != nil {
+			return chunk, nil
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// chunk converts one event into a canonical chunk, or nil for events with no
+// canonical content (in_progress, content_part.added, the *.done text events,
+// …).
+func (d *streamDecoder) chunk(ev *StreamEvent) (*ais.StreamChunk, error) {
+	var delta ais.Message
+
+	switch ev.Type {
+	case "response.created":
+		if r := ev.Response; r != nil {
+			d.id, d.model, d.created = r.ID, r.Model, r.CreatedAt
+		}
+		delta.Role = ais.RoleAssistant
+	case "response.output_text.delta":
+		delta.Content = ais.NewTextContent(ev.Delta)
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		delta.Thinking = ev.Delta
+	case "response.output_item.added":
+		if ev.Item == nil || ev.Item.Type != "function_call" {
+			return nil, nil
+		}
+
+		idx := len(d.toolIdx)
+		d.toolIdx[ev.OutputIndex] = idx
+		delta.ToolCalls = []ais.ToolCall{{
+			Index:    idx,
+			ID:       ev.Item.CallID,
+			Type:     "function",
+			Function: ais.FunctionCall{Name: ev.Item.Name, Arguments: ev.Item.Arguments},
+		}}
+	case "response.function_call_arguments.delta":
+		idx, ok := d.toolIdx[ev.OutputIndex]
+		if !ok {
+			return nil, nil
+		}
+
+		delta.ToolCalls = []ais.ToolCall{{Index: idx, Function: ais.FunctionCall{Arguments: ev.Delta}}}
+	case "response.output_item.done":
+		if ev.Item == nil {
+			return nil, nil
+		}
+
+		delta.Extensions.Set(Name, &MessageExtension{ResponseID: d.id, Items: []OutputItem{*ev.Item}})
+	case "response.completed", "response.incomplete":
+		return d.final(ev.Response), nil
+	case "response.failed":
+		if r := ev.Response; r != nil && r.Error != nil {
+			return nil, responsesAPIError(0, r.Error)
+		}
+
+		return nil, &ais.APIError{Message: "response failed"}
+	case "error":
+		return nil, &ais.APIError{Code: ev.Code, Message: ev.Message}
+	default:
+		return nil, nil
+	}
+
+	return d.newChunk(ais.StreamChunkChoice{Delta: delta}), nil
+}
+
+// final builds the terminal chunk: the finish reason, the usage and the
+// response-level extension.
+func (d *streamDecoder) final(r *Response) *ais.StreamChunk {
+	if r == nil {
+		r = &Response{Status: "completed"}
+	}
+
+	reason := string(finishReason(r, len(d.toolIdx) > 0))
+	chunk := d.newChunk(ais.StreamChunkChoice{FinishReason: &reason})
+
+	if r.Usage != nil {
+		usage := canonicalUsage(r.Usage, r.ServiceTier)
+		chunk.Usage = &usage
+	}
+
+	chunk.Extensions.Set(Name, responseExtension(r))
+
+	return chunk
+}
+
+func (d *streamDecoder) newChunk(choice ais.StreamChunkChoice) *ais.StreamChunk {
+	return &ais.StreamChunk{
+		ID:      d.id,
+		Object:  "chat.completion.chunk",
+		Created: d.created,
+		Model:   d.model,
+		Choices: []ais.StreamChunkChoice{choice},
+	}
+}
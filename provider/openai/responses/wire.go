@@ -0,0 +1,311 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package responses
+
+import "encoding/json"
+
+// Request is the native POST /responses body.
+type Request struct {
+	Model              string            `json:"model"`
+	Input              []InputItem       `json:"input"`
+	Instructions       string            `json:"instructions,omitempty"`
+	Include            []string          `json:"include,omitempty"`
+	MaxOutputTokens    *int              `json:"max_output_tokens,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	ParallelToolCalls  *bool             `json:"parallel_tool_calls,omitempty"`
+	PreviousResponseID string            `json:"previous_response_id,omitempty"`
+	PromptCacheKey     string            `json:"prompt_cache_key,omitempty"`
+	Reasoning          *Reasoning        `json:"reasoning,omitempty"`
+	SafetyIdentifier   string            `json:"safety_identifier,omitempty"`
+	ServiceTier        string            `json:"service_tier,omitempty"`
+	Store              *bool             `json:"store,omitempty"`
+	Stream             bool              `json:"stream,omitempty"`
+	Temperature        *float64          `json:"temperature,omitempty"`
+	Text               *TextConfig       `json:"text,omitempty"`
+	ToolChoice         any               `json:"tool_choice,omitempty"`
+	Tools              []json.RawMessage `json:"tools,omitempty"`
+	TopP               *float64          `json:"top_p,omitempty"`
+	Truncation         string            `json:"truncation,omitempty"`
+}
+
+// Reasoning configures reasoning models: the effort level and whether
+// reasoning summaries are returned ("auto", "concise" or "detailed").
+type Reasoning struct {
+	Effort  string `json:"effort,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// TextConfig configures the text output: its format and verbosity.
+type TextConfig struct {
+	Format    *TextFormat `json:"format,omitempty"`
+	Verbosity string      `json:"verbosity,omitempty"`
+}
+
+// TextFormat is the output format: {type:"text"}, {type:"json_object"} or
+// {type:"json_schema", name, schema, strict}.
+type TextFormat struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema,omitempty"`
+	Strict      *bool  `json:"strict,omitempty"`
+}
+
+// FunctionTool is a function tool definition. Tools travel as raw JSON on
+// the wire so built-in tools (web_search, file_search, …) pass through with
+// all their fields; marshal a FunctionTool to add a function.
+type FunctionTool struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+	Strict      *bool  `json:"strict,omitempty"`
+}
+
+// InputItem is one input item. Type selects the shape:
+//   - "message": Role and Content (a string or content parts);
+//   - "function_call": CallID, Name and Arguments, replaying a model call;
+//   - "function_call_output": CallID and Output, the tool result;
+//   - "reasoning": ID, Summary and EncryptedContent, replaying a reasoning
+//     item;
+//   - "item_reference": ID of a stored item.
+//
+// When Raw is set it is sent verbatim instead of the typed fields, which is
+// how output items — built-in tool calls included — are replayed losslessly.
+type InputItem struct {
+	Type             string          `json:"type"`
+	ID               string          `json:"id,omitempty"`
+	Role             string          `json:"role,omitempty"`
+	Content          *MessageContent `json:"content,omitempty"`
+	CallID           string          `json:"call_id,omitempty"`
+	Name             string          `json:"name,omitempty"`
+	Arguments        string          `json:"arguments,omitempty"`
+	Output           string          `json:"output,omitempty"`
+	Summary          []SummaryPart   `json:"summary,omitempty"`
+	EncryptedContent string          `json:"encrypted_content,omitempty"`
+	Raw              json.RawMessage `json:"-"`
+}
+
+// MarshalJSON encodes Raw when set, otherwise the typed fields.
+func (i InputItem) MarshalJSON() ([]byte, error) {
+	if len(i.Raw) > 0 {
+		return i.Raw, nil
+	}
+
+	type plain InputItem
+
+	return json.Marshal(plain(i))
+}
+
+// MessageContent is a message item's content: either a plain string or a
+// list of content parts.
+type MessageContent struct {
+	text  *string
+	parts []ContentPart
+}
+
+// NewTextContent returns string message content.
+func NewTextContent(text string) *MessageContent { return &MessageContent{text: &text} }
+
+// NewPartsContent returns multi-part message content.
+func NewPartsContent(parts ...ContentPart) *MessageContent {
+	return &MessageContent{parts: append([]ContentPart{}, parts...)}
+}
+
+// Parts returns the content parts, or nil for string content.
+func (c *MessageContent) Parts() []ContentPart { return c.parts }
+
+// Text returns the string content, or the concatenated text of the parts.
+func (c *MessageContent) Text() string {
+	if c.text != nil {
+		return *c.text
+	}
+
+	var text string
+	for _, part := range c.parts {
+		text += part.Text
+	}
+
+	return text
+}
+
+// MarshalJSON encodes string content as a JSON string and parts as an array.
+func (c MessageContent) MarshalJSON() ([]byte, error) {
+	if c.text != nil {
+		return json.Marshal(*c.text)
+	}
+
+	return json.Marshal(c.parts)
+}
+
+// UnmarshalJSON accepts either a JSON string or an array of parts.
+func (c *MessageContent) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+
+		*c = MessageContent{text: &text}
+
+		return nil
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+
+	*c = MessageContent{parts: parts}
+
+	return nil
+}
+
+// ContentPart is one content part. Input parts are "input_text",
+// "input_image", "input_file" and "input_audio"; output parts are
+// "output_text" and "refusal".
+type ContentPart struct {
+	Type        string          `json:"type"`
+	Text        string          `json:"text,omitempty"`
+	ImageURL    string          `json:"image_url,omitempty"`
+	Detail      string          `json:"detail,omitempty"`
+	FileID      string          `json:"file_id,omitempty"`
+	FileData    string          `json:"file_data,omitempty"`
+	FileURL     string          `json:"file_url,omitempty"`
+	Filename    string          `json:"filename,omitempty"`
+	InputAudio  *InputAudio     `json:"input_audio,omitempty"`
+	Refusal     string          `json:"refusal,omitempty"`
+	Annotations json.RawMessage `json:"annotations,omitempty"`
+}
+
+// InputAudio is base64 audio input.
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// SummaryPart is one reasoning summary entry, {type:"summary_text", text}.
+type SummaryPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Response is the native response object, returned by POST /responses and
+// carried by the response.* lifecycle stream events.
+type Response struct {
+	ID                 string             `json:"id"`
+	Object             string             `json:"object"`
+	CreatedAt          int64              `json:"created_at"`
+	Status             string             `json:"status"`
+	Model              string             `json:"model"`
+	Output             []OutputItem       `json:"output"`
+	Usage              *Usage             `json:"usage,omitempty"`
+	Error              *Error             `json:"error,omitempty"`
+	IncompleteDetails  *IncompleteDetails `json:"incomplete_details,omitempty"`
+	PreviousResponseID string             `json:"previous_response_id,omitempty"`
+	ServiceTier        string             `json:"service_tier,omitempty"`
+}
+
+// IncompleteDetails explains a response with status "incomplete".
+type IncompleteDetails struct {
+	Reason string `json:"reason"`
+}
+
+// OutputItem is one output item. Type selects the populated fields:
+// "message" (Role, Content), "function_call" (CallID, Name, Arguments) and
+// "reasoning" (Summary, Content, EncryptedContent). Other item types — the
+// built-in tool calls such as "web_search_call" — keep their full JSON in
+// Raw.
+type OutputItem struct {
+	Type             string          `json:"type"`
+	ID               string          `json:"id,omitempty"`
+	Status           string          `json:"status,omitempty"`
+	Role             string          `json:"role,omitempty"`
+	Content          []ContentPart   `json:"content,omitempty"`
+	CallID           string          `json:"call_id,omitempty"`
+	Name             string          `json:"name,omitempty"`
+	Arguments        string          `json:"arguments,omitempty"`
+	Summary          []SummaryPart   `json:"summary,omitempty"`
+	EncryptedContent string          `json:"encrypted_content,omitempty"`
+	Raw              json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the typed fields and keeps the full item in Raw.
+func (o *OutputItem) UnmarshalJSON(data []byte) error {
+	type plain OutputItem
+
+	var item plain
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+
+	item.Raw = append(json.RawMessage(nil), data...)
+	*o = OutputItem(item)
+
+	return nil
+}
+
+// Usage is the response token accounting.
+type Usage struct {
+	InputTokens         int                  `json:"input_tokens"`
+	InputTokensDetails  *InputTokensDetails  `json:"input_tokens_details,omitempty"`
+	OutputTokens        int                  `json:"output_tokens"`
+	OutputTokensDetails *OutputTokensDetails `json:"output_tokens_details,omitempty"`
+	TotalTokens         int                  `json:"total_tokens"`
+}
+
+// InputTokensDetails breaks down input tokens.
+type InputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// OutputTokensDetails breaks down output tokens.
+type OutputTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// Error is the error object of a failed response, an error event, or a
+// non-2xx body ({"error": Error}).
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+// StreamEvent is one typed SSE event. Type names the event
+// ("response.output_text.delta", "response.completed", …); the other fields
+// are populated per type. Raw keeps the full payload, including fields of
+// event types this package does not model.
+type StreamEvent struct {
+	Type           string          `json:"type"`
+	SequenceNumber int             `json:"sequence_number"`
+	Response       *Response       `json:"response,omitempty"`
+	OutputIndex    int             `json:"output_index"`
+	ContentIndex   int             `json:"content_index"`
+	SummaryIndex   int             `json:"summary_index"`
+	ItemID         string          `json:"item_id,omitempty"`
+	Item           *OutputItem     `json:"item,omitempty"`
+	Delta          string          `json:"delta,omitempty"`
+	Text           string          `json:"text,omitempty"`
+	Arguments      string          `json:"arguments,omitempty"`
+	Code           string          `json:"code,omitempty"`
+	Message        string          `json:"message,omitempty"`
+	Param          string          `json:"param,omitempty"`
+	Raw            json.RawMessage `json:"-"`
+}
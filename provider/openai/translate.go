@@ -18,7 +18,7 @@ package openai
 
 import "github.com/vogo/aimodel/ais"
 
-func toOpenAIRequest(input *ais.ChatRequest) *ChatCompletionRequest {
+func toOpenAIRequest(input *ais.ChatRequest) (*ChatCompletionRequest, error) {
 	request := &ChatCompletionRequest{
 		// MaxTokens remains mapped for backward compatibility with older models.
 		Model: input.Model, Temperature: input.Temperature, MaxTokens: input.MaxTokens, //nolint:staticcheck
@@ -39,9 +39,9 @@ func toOpenAIRequest(input *ais.ChatRequest) *ChatCompletionRequest {
 		if parts := message.Content.Parts(); parts != nil {
 			converted := make([]ChatCompletionContentPart, 0, len(parts))
 			for _, part := range parts {
-				item := ChatCompletionContentPart{Type: part.Type, Text: part.Text}
-				if part.ImageURL != nil {
-					item.ImageURL = &ImageURL{URL: part.ImageURL.URL, Detail: part.ImageURL.Detail}
+				item, err := toOpenAIContentPart(part)
+				if err != nil {
+					return nil, err
 				}
 				converted = append(converted, item)
 			}
@@ -57,7 +57,27 @@ func toOpenAIRequest(input *ais.ChatRequest) *ChatCompletionRequest {
 	for _, tool := range input.Tools {
 		request.Tools = append(request.Tools, ChatCompletionTool{Type: tool.Type, Function: ChatCompletionFunction{Name: tool.Function.Name, Description: tool.Function.Description, Parameters: tool.Function.Parameters, Strict: tool.Strict}})
 	}
-	return request
+	return request, nil
+}
+
+// toOpenAIContentPart maps one canonical part onto its native counterpart.
+// Chat Completions has no URL source for files, so a FileURL part fails
+// instead of being sent without its content.
+func toOpenAIContentPart(part ais.ContentPart) (ChatCompletionContentPart, error) {
+	item := ChatCompletionContentPart{Type: part.Type, Text: part.Text}
+	if part.ImageURL != nil {
+		item.ImageURL = &ImageURL{URL: part.ImageURL.URL, Detail: part.ImageURL.Detail}
+	}
+	if part.InputAudio != nil {
+		item.InputAudio = &InputAudio{Data: part.InputAudio.Data, Format: part.InputAudio.Format}
+	}
+	if part.File != nil {
+		if part.File.FileURL != "" {
+			return ChatCompletionContentPart{}, &ais.ContentPartError{Provider: Name, Type: part.Type, Reason: "file URLs are not supported; use file_data or file_id"}
+		}
+		item.File = &InputFile{FileData: part.File.FileData, FileID: part.File.FileID, Filename: part.File.Filename}
+	}
+	return item, nil
 }
 
 func fromOpenAIMessage(input ChatCompletionMessage) ais.Message {
@@ -69,6 +89,12 @@ func fromOpenAIMessage(input ChatCompletionMessage) ais.Message {
 			if part.ImageURL != nil {
 				item.ImageURL = &ais.ImageURL{URL: part.ImageURL.URL, Detail: part.ImageURL.Detail}
 			}
+			if part.InputAudio != nil {
+				item.InputAudio = &ais.InputAudio{Data: part.InputAudio.Data, Format: part.InputAudio.Format}
+			}
+			if part.File != nil {
+				item.File = &ais.File{FileData: part.File.FileData, FileID: part.File.FileID, Filename: part.File.Filename}
+			}
 			converted = append(converted, item)
 		}
 		message.Content = ais.NewPartsContent(converted...)
@@ -105,7 +131,13 @@ func fromOpenAIResponse(input *ChatCompletionResponse) *ais.ChatResponse {
 		if choice.FinishReason != nil {
 			finish = ais.FinishReason(*choice.FinishReason)
 		}
-		result.Choices = append(result.Choices, ais.Choice{Index: choice.Index, Message: fromOpenAIMessage(choice.Message), FinishReason: finish})
+		message := fromOpenAIMessage(choice.Message)
+		// A unary response carries no tool-call index; number the calls by
+		// position, as an accumulated stream does.
+		for i := range message.ToolCalls {
+			message.ToolCalls[i].Index = i
+		}
+		result.Choices = append(result.Choices, ais.Choice{Index: choice.Index, Message: message, FinishReason: finish})
 	}
 	return result
 }
@@ -117,3 +149,20 @@ func fromOpenAIChunk(input *ChatCompletionChunk) *ais.StreamChunk {
 	}
 	return result
 }
+
+func toOpenAIEmbeddingRequest(input *ais.EmbeddingRequest) *EmbeddingRequest {
+	request := &EmbeddingRequest{Model: input.Model, Input: input.Input, EncodingFormat: input.EncodingFormat}
+	if input.Dimensions > 0 {
+		request.Dimensions = &input.Dimensions
+	}
+	return request
+}
+
+func fromOpenAIEmbeddingResponse(input *EmbeddingResponse) *ais.EmbeddingResponse {
+	result := &ais.EmbeddingResponse{Model: input.Model, Usage: ais.Usage{PromptTokens: input.Usage.PromptTokens, TotalTokens: input.Usage.TotalTokens}}
+	result.Data = make([]ais.Embedding, 0, len(input.Data))
+	for _, item := range input.Data {
+		result.Data = append(result.Data, ais.Embedding{Index: item.Index, Embedding: item.Embedding})
+	}
+	return result
+}
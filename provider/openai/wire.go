@@ -18,7 +18,11 @@
 package openai
 
 import (
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
+	"math"
 	"strings"
 )
 
@@ -280,3 +284,70 @@ type Error struct {
 	Param   any    `json:"param,omitempty"`
 	Type    string `json:"type"`
 }
+
+// EmbeddingRequest is the native OpenAI POST /embeddings body.
+type EmbeddingRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	Dimensions     *int     `json:"dimensions,omitempty"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+	User           string   `json:"user,omitempty"`
+}
+
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+	Error  *Error          `json:"error,omitempty"`
+}
+type EmbeddingData struct {
+	Object    string          `json:"object"`
+	Index     int             `json:"index"`
+	Embedding EmbeddingVector `json:"embedding"`
+}
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ModelList is the native OpenAI GET /models response.
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+	Error  *Error  `json:"error,omitempty"`
+}
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// EmbeddingVector decodes both wire encodings of an embedding: a JSON number
+// array (encoding_format "float") or a base64 string of little-endian
+// float32 values (encoding_format "base64"). It always marshals as an array.
+type EmbeddingVector []float32
+
+func (v *EmbeddingVector) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return json.Unmarshal(data, (*[]float32)(v))
+	}
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decode base64 embedding: %w", err)
+	}
+	if len(raw)%4 != 0 {
+		return fmt.Errorf("decode base64 embedding: %d bytes is not a whole number of float32 values", len(raw))
+	}
+	vector := make(EmbeddingVector, len(raw)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	*v = vector
+	return nil
+}
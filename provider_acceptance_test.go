@@ -188,6 +188,7 @@ func TestFakeProvider_ErrorHook(t *testing.T) {
 	calls := &fakeCalls{}
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = w.Write([]byte(`boom`))
 	}))
@@ -216,6 +217,10 @@ func TestFakeProvider_ErrorHook(t *testing.T) {
 		t.Errorf("unexpected error: %+v", apiErr)
 	}
 
+	if got := apiErr.Header.Get("Retry-After"); got != "7" {
+		t.Errorf("APIError.Header Retry-After = %q, want 7", got)
+	}
+
 	if calls.parseError != 1 {
 		t.Errorf("ParseErrorResponse called %d times, want 1", calls.parseError)
 	}
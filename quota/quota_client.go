@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package quota provides an opt-in token budget wrapper around any
+// aimodel.ChatCompleter. It caps what each tenant may spend over rolling
+// windows, reserving an estimate before each call and settling it with the
+// usage the provider reports.
+package quota
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose calls through a QuotaClient are charged
+// to tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, or "".
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+
+	return tenant
+}
+
+// QuotaClient enforces per-tenant token budgets on an inner
+// aimodel.ChatCompleter. It implements aimodel.ChatCompleter, so it can wrap
+// a *aimodel.Client, a composes.ComposeClient or a retries.RetryClient.
+// Wrap it outside a compose: a rejected call is an error to a compose, not
+// a reason to try the next entry.
+//
+// Before each call it reserves the request's estimated tokens with the
+// Store, and rejects the call with an *ExceededError when that would take
+// the tenant over a limit. After the call the reservation is settled with
+// the response usage; a stream settles when it ends or is closed, with the
+// usage chunk Stream.Usage reports. A call that fails without usage releases
+// its reservation; one that succeeds without usage keeps the estimate.
+type QuotaClient struct {
+	inner    aimodel.ChatCompleter
+	store    Store
+	limits   func(tenant string) []Limit
+	tenant   func(ctx context.Context) string
+	estimate func(*ais.ChatRequest) Tokens
+	onError  func(error)
+	nowFunc  func() time.Time
+}
+
+// Option configures a QuotaClient.
+type Option func(*QuotaClient)
+
+// WithStore sets where spend is kept. The default is a fresh MemoryStore,
+// which enforces budgets within this process only.
+func WithStore(store Store) Option {
+	return func(c *QuotaClient) {
+		c.store = store
+	}
+}
+
+// WithLimits applies the same limits to every tenant.
+func WithLimits(limits ...Limit) Option {
+	return func(c *QuotaClient) {
+		c.limits = func(string) []Limit { return limits }
+	}
+}
+
+// WithTenantLimits looks up each tenant's limits per call. A tenant with no
+// limits is not metered.
+func WithTenantLimits(fn func(tenant string) []Limit) Option {
+	return func(c *QuotaClient) {
+		c.limits = fn
+	}
+}
+
+// WithTenantFunc replaces TenantFromContext as the way a call's tenant is
+// read from its context, e.g. to reuse an API key or an auth claim already
+// there. An empty tenant is metered like any other.
+func WithTenantFunc(fn func(ctx context.Context) string) Option {
+	return func(c *QuotaClient) {
+		c.tenant = fn
+	}
+}
+
+// WithEstimator replaces Estimate as the reservation made before each call.
+func WithEstimator(fn func(*ais.ChatRequest) Tokens) Option {
+	return func(c *QuotaClient) {
+		c.estimate = fn
+	}
+}
+
+// WithOnError registers a callback for store errors raised while settling,
+// which cannot be returned to the caller. A failed settle leaves the
+// estimate in place.
+func WithOnError(fn func(error)) Option {
+	return func(c *QuotaClient) {
+		c.onError = fn
+	}
+}
+
+// NewQuotaClient wraps inner with the budgets configured by opts. Without
+// WithLimits or WithTenantLimits no tenant is metered.
+func NewQuotaClient(inner aimodel.ChatCompleter, opts ...Option) *QuotaClient {
+	c := &QuotaClient{
+		inner:    inner,
+		limits:   func(string) []Limit { return nil },
+		tenant:   TenantFromContext,
+		estimate: Estimate,
+		nowFunc:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.store == nil {
+		c.store = NewMemoryStore()
+	}
+
+	return c
+}
+
+// Compile-time check: *QuotaClient implements aimodel.ChatCompleter.
+var _ aimodel.ChatCompleter = (*QuotaClient)(nil)
+
+// Estimate roughly estimates a request's spend with ais.EstimateTokens: the
+// prompt estimate, and the output cap as completion tokens. A request without
+// an output cap reserves no completion tokens, so its output is only charged
+// once it is known.
+func Estimate(req *ais.ChatRequest) Tokens {
+	prompt, completion := ais.EstimateTokens(req)
+
+	return Tokens{Prompt: int64(prompt), Completion: int64(completion), Total: int64(prompt + completion)}
+}
+
+// ChatCompletion reserves the request's estimate, sends it and settles the
+// reservation with the response usage.
+func (c *QuotaClient) ChatCompletion(ctx context.Context, req *ais.ChatRequest) (*ais.ChatResponse, error) {
+	r, metered, err := c.reserve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.inner.ChatCompletion(ctx, req)
+
+	if metered {
+		var usage *ais.Usage
+		if resp != nil && resp.Usage.PromptTokens+resp.Usage.CompletionTokens+resp.Usage.TotalTokens > 0 {
+			usage = &resp.Usage
+		}
+
+		c.settle(ctx, r, usage, err)
+	}
+
+	return resp, err
+}
+
+// ChatCompletionStream reserves the request's estimate and opens the stream.
+// The reservation is settled when the stream ends or is closed.
+func (c *QuotaClient) ChatCompletionStream(ctx context.Context, req *ais.ChatRequest) (*aimodel.Stream, error) {
+	r, metered, err := c.reserve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := c.inner.ChatCompletionStream(ctx, req)
+	if !metered {
+		return s, err
+	}
+
+	if err != nil {
+		c.settle(ctx, r, nil, err)
+
+		return nil, err
+	}
+
+	// Track the usage chunk here: Stream.Usage cannot be read from inside
+	// onDone, which may run under the stream's lock. Close may race Recv.
+	var usage atomic.Pointer[ais.Usage]
+
+	return aimodel.InterceptStream(s, func(chunk *ais.StreamChunk) {
+		if chunk.Usage != nil {
+			usage.Store(chunk.Usage)
+		}
+	}, func(err error) {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+		c.settle(ctx, r, usage.Load(), err)
+	}), nil
+}
+
+// reserve books the request's estimate for the call's tenant. metered is
+// false when the tenant has no limits.
+func (c *QuotaClient) reserve(ctx context.Context, req *ais.ChatRequest) (Reservation, bool, error) {
+	tenant := c.tenant(ctx)
+
+	limits := c.limits(tenant)
+	if len(limits) == 0 {
+		return Reservation{}, false, nil
+	}
+
+	r, err := c.store.Reserve(ctx, tenant, c.estimate(req), limits, c.nowFunc())
+	if err != nil {
+		return Reservation{}, false, err
+	}
+
+	return r, true, nil
+}
+
+// settle replaces the reservation with the reported usage. Without usage, a
+// failed call releases the reservation and a successful one keeps it.
+func (c *QuotaClient) settle(ctx context.Context, r Reservation, usage *ais.Usage, callErr error) {
+	var actual Tokens
+
+	switch {
+	case usage != nil:
+		actual = UsageTokens(usage)
+	case callErr == nil:
+		return
+	}
+
+	if err := c.store.Settle(context.WithoutCancel(ctx), r, actual); err != nil && c.onError != nil {
+		c.onError(err)
+	}
+}
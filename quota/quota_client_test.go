@@ -0,0 +1,231 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// usageCompleter answers with a fixed usage, or fails with err.
+type usageCompleter struct {
+	usage ais.Usage
+	err   error
+	calls int
+}
+
+func (u *usageCompleter) ChatCompletion(context.Context, *ais.ChatRequest) (*ais.ChatResponse, error) {
+	u.calls++
+	if u.err != nil {
+		return nil, u.err
+	}
+
+	return &ais.ChatResponse{ID: "ok", Usage: u.usage}, nil
+}
+
+func (u *usageCompleter) ChatCompletionStream(context.Context, *ais.ChatRequest) (*aimodel.Stream, error) {
+	u.calls++
+	if u.err != nil {
+		return nil, u.err
+	}
+
+	chunks := []*ais.StreamChunk{{ID: "c1"}, {ID: "c2", Usage: &u.usage}}
+
+	return aimodel.NewStream(func() (*ais.StreamChunk, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		c := chunks[0]
+		chunks = chunks[1:]
+
+		return c, nil
+	}, nil), nil
+}
+
+func testRequest() *ais.ChatRequest {
+	return &ais.ChatRequest{
+		Model:               "m",
+		Messages:            []ais.Message{{Role: ais.RoleUser, Content: ais.NewTextContent("12345678")}},
+		MaxCompletionTokens: new(10),
+	}
+}
+
+func newTestClient(inner aimodel.ChatCompleter, store Store, limits ...Limit) *QuotaClient {
+	c := NewQuotaClient(inner, WithStore(store), WithLimits(limits...))
+	c.nowFunc = func() time.Time { return time.Unix(1000, 0) }
+
+	return c
+}
+
+func TestEstimate(t *testing.T) {
+	got := Estimate(testRequest())
+	if want := (Tokens{Prompt: 2, Completion: 10, Total: 12}); got != want {
+		t.Errorf("Estimate = %+v, want %+v", got, want)
+	}
+}
+
+func TestTenantFromContext(t *testing.T) {
+	if got := TenantFromContext(context.Background()); got != "" {
+		t.Errorf("tenant = %q", got)
+	}
+
+	if got := TenantFromContext(WithTenant(context.Background(), "acme")); got != "acme" {
+		t.Errorf("tenant = %q", got)
+	}
+}
+
+func TestChatCompletionSettlesUsage(t *testing.T) {
+	inner := &usageCompleter{usage: ais.Usage{PromptTokens: 30, CompletionTokens: 20, TotalTokens: 50, CacheReadTokens: 5}}
+	store := NewMemoryStore()
+	c := newTestClient(inner, store, Limit{Window: time.Hour, Max: Tokens{Total: 110}})
+	ctx := WithTenant(context.Background(), "acme")
+
+	for range 2 {
+		if _, err := c.ChatCompletion(ctx, testRequest()); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+
+	used, _ := store.Usage(ctx, "acme", time.Hour, time.Unix(1000, 0))
+	if want := (Tokens{Prompt: 60, Completion: 40, CacheRead: 10, Total: 100}); used != want {
+		t.Errorf("usage = %+v, want %+v", used, want)
+	}
+
+	_, err := c.ChatCompletion(ctx, testRequest())
+
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Tenant != "acme" || exceeded.Kind != "total" {
+		t.Fatalf("err = %v, want the total budget exceeded", err)
+	}
+
+	if inner.calls != 2 {
+		t.Errorf("inner calls = %d, want the rejected call not sent", inner.calls)
+	}
+
+	if _, err := c.ChatCompletion(WithTenant(context.Background(), "other"), testRequest()); err != nil {
+		t.Errorf("other tenant: %v", err)
+	}
+}
+
+func TestChatCompletionFailureReleases(t *testing.T) {
+	inner := &usageCompleter{err: errors.New("boom")}
+	store := NewMemoryStore()
+	c := newTestClient(inner, store, Limit{Window: time.Hour, Max: Tokens{Total: 100}})
+
+	if _, err := c.ChatCompletion(context.Background(), testRequest()); err == nil {
+		t.Fatal("want the inner error")
+	}
+
+	if used, _ := store.Usage(context.Background(), "", time.Hour, time.Unix(1000, 0)); used != (Tokens{}) {
+		t.Errorf("usage = %+v, want the reservation released", used)
+	}
+}
+
+func TestChatCompletionWithoutUsageKeepsEstimate(t *testing.T) {
+	store := NewMemoryStore()
+	c := newTestClient(&usageCompleter{}, store, Limit{Window: time.Hour, Max: Tokens{Total: 100}})
+
+	if _, err := c.ChatCompletion(context.Background(), testRequest()); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	used, _ := store.Usage(context.Background(), "", time.Hour, time.Unix(1000, 0))
+	if used != Estimate(testRequest()) {
+		t.Errorf("usage = %+v, want the estimate kept", used)
+	}
+}
+
+func TestChatCompletionStreamSettlesOnEnd(t *testing.T) {
+	inner := &usageCompleter{usage: ais.Usage{PromptTokens: 7, CompletionTokens: 3}}
+	store := NewMemoryStore()
+	c := newTestClient(inner, store, Limit{Window: time.Hour, Max: Tokens{Total: 100}})
+	ctx := WithTenant(context.Background(), "acme")
+
+	s, err := c.ChatCompletionStream(ctx, testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	used, _ := store.Usage(ctx, "acme", time.Hour, time.Unix(1000, 0))
+	if used != Estimate(testRequest()) {
+		t.Errorf("usage while streaming = %+v, want the estimate reserved", used)
+	}
+
+	if _, err := s.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if s.Usage() == nil || s.Usage().PromptTokens != 7 {
+		t.Errorf("Stream.Usage = %+v", s.Usage())
+	}
+
+	used, _ = store.Usage(ctx, "acme", time.Hour, time.Unix(1000, 0))
+	if want := (Tokens{Prompt: 7, Completion: 3, Total: 10}); used != want {
+		t.Errorf("usage = %+v, want %+v", used, want)
+	}
+}
+
+func TestUnmeteredTenantPassesThrough(t *testing.T) {
+	inner := &usageCompleter{}
+	store := NewMemoryStore()
+	c := NewQuotaClient(inner, WithStore(store), WithTenantLimits(func(tenant string) []Limit {
+		if tenant == "free" {
+			return []Limit{{Window: time.Hour, Max: Tokens{Total: 1}}}
+		}
+
+		return nil
+	}))
+
+	if _, err := c.ChatCompletion(WithTenant(context.Background(), "paid"), testRequest()); err != nil {
+		t.Fatalf("paid tenant: %v", err)
+	}
+
+	if _, err := c.ChatCompletion(WithTenant(context.Background(), "free"), testRequest()); err == nil {
+		t.Fatal("free tenant: want the budget exceeded")
+	}
+
+	if len(store.tenants) != 0 {
+		t.Errorf("store tenants = %v, want nothing recorded", store.tenants)
+	}
+}
+
+func TestWithTenantFunc(t *testing.T) {
+	type apiKey struct{}
+
+	c := NewQuotaClient(&usageCompleter{},
+		WithLimits(Limit{Window: time.Hour, Max: Tokens{Prompt: 1}}),
+		WithTenantFunc(func(ctx context.Context) string {
+			key, _ := ctx.Value(apiKey{}).(string)
+
+			return key
+		}))
+
+	_, err := c.ChatCompletion(context.WithValue(context.Background(), apiKey{}, "sk-1"), testRequest())
+
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Tenant != "sk-1" {
+		t.Fatalf("err = %v, want tenant sk-1 rejected", err)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Tokens counts tokens per kind. In a Limit, a zero field means that kind is
+// not capped.
+type Tokens struct {
+	Prompt     int64 `json:"prompt,omitempty"`
+	Completion int64 `json:"completion,omitempty"`
+	CacheRead  int64 `json:"cache_read,omitempty"`
+	Total      int64 `json:"total,omitempty"`
+}
+
+// UsageTokens converts a usage report into Tokens. Total falls back to
+// prompt plus completion when the provider does not report it; a nil usage
+// counts nothing.
+func UsageTokens(u *ais.Usage) Tokens {
+	if u == nil {
+		return Tokens{}
+	}
+
+	t := Tokens{
+		Prompt:     int64(u.PromptTokens),
+		Completion: int64(u.CompletionTokens),
+		CacheRead:  int64(u.CacheReadTokens),
+		Total:      int64(u.TotalTokens),
+	}
+
+	if t.Total == 0 {
+		t.Total = t.Prompt + t.Completion
+	}
+
+	return t
+}
+
+// Add returns the field-wise sum of t and o.
+func (t Tokens) Add(o Tokens) Tokens {
+	return Tokens{
+		Prompt:     t.Prompt + o.Prompt,
+		Completion: t.Completion + o.Completion,
+		CacheRead:  t.CacheRead + o.CacheRead,
+		Total:      t.Total + o.Total,
+	}
+}
+
+// Limit caps the tokens a tenant may spend within a rolling window. A tenant
+// may carry several limits, e.g. a per-minute burst cap and a daily budget.
+type Limit struct {
+	Window time.Duration `json:"window"`
+	Max    Tokens        `json:"max"`
+}
+
+// Check returns an *ExceededError, without Tenant or RetryAfter, for the
+// first token kind where used plus n exceeds the limit, or nil when n fits.
+// Store implementations use it to apply limits the same way.
+func (l Limit) Check(used, n Tokens) *ExceededError {
+	for _, k := range []struct {
+		kind           string
+		used, n, limit int64
+	}{
+		{"prompt", used.Prompt, n.Prompt, l.Max.Prompt},
+		{"completion", used.Completion, n.Completion, l.Max.Completion},
+		{"cache_read", used.CacheRead, n.CacheRead, l.Max.CacheRead},
+		{"total", used.Total, n.Total, l.Max.Total},
+	} {
+		if k.limit > 0 && k.used+k.n > k.limit {
+			return &ExceededError{Limit: l, Kind: k.kind, Used: k.used, Requested: k.n, Budget: k.limit}
+		}
+	}
+
+	return nil
+}
+
+// ExceededError reports a request rejected because it would take a tenant
+// over one of its limits. No call reaches the inner client.
+type ExceededError struct {
+	Tenant string
+	Limit  Limit
+	// Kind is the capped token kind: "prompt", "completion", "cache_read"
+	// or "total".
+	Kind string
+	// Used is what the tenant already spent of Kind within the window,
+	// reservations of in-flight calls included.
+	Used int64
+	// Requested is the estimate reserved for the rejected request.
+	Requested int64
+	// Budget is the limit's cap on Kind.
+	Budget int64
+	// RetryAfter, when known, is how long until the oldest spend in the
+	// window expires. It is a lower bound: that may not free enough. It is
+	// zero for an oversized request, which no wait makes fit.
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	msg := fmt.Sprintf("aimodel/quota: tenant %q exceeded its %s token budget (%d used + %d requested > %d per %s)",
+		e.Tenant, e.Kind, e.Used, e.Requested, e.Budget, e.Limit.Window)
+
+	switch {
+	case e.Oversized():
+		msg += "; the request alone exceeds the budget"
+	case e.RetryAfter > 0:
+		msg += "; retry after " + e.RetryAfter.String()
+	}
+
+	return msg
+}
+
+// Oversized reports whether the request alone exceeds the budget, so
+// retrying it can never succeed.
+func (e *ExceededError) Oversized() bool {
+	return e.Requested > e.Budget
+}
+
+// Reservation is the estimate a Store recorded for an in-flight call, to be
+// replaced by the actual spend through Settle.
+type Reservation struct {
+	Tenant string
+	// ID identifies the reservation within the store.
+	ID     string
+	At     time.Time
+	Tokens Tokens
+}
+
+// Store keeps per-tenant spend over rolling windows. Implementations must be
+// safe for concurrent use; a shared store (e.g. Redis) lets several
+// processes enforce one budget.
+type Store interface {
+	// Reserve checks the tenant's spend within each limit's window plus n
+	// against the limit and, when all hold, records n at now. It must check
+	// and record atomically, so concurrent calls cannot both slip under a
+	// limit. When a limit would be exceeded it records nothing and returns a
+	// *ExceededError, whose RetryAfter stays zero when the request is
+	// Oversized.
+	Reserve(ctx context.Context, tenant string, n Tokens, limits []Limit, now time.Time) (Reservation, error)
+	// Settle replaces a reservation's estimate with the actual spend. The
+	// spend stays dated at the reservation time.
+	Settle(ctx context.Context, r Reservation, actual Tokens) error
+	// Usage returns the tenant's spend within window before now.
+	Usage(ctx context.Context, tenant string, window time.Duration, now time.Time) (Tokens, error)
+}
+
+// MemoryStore is an in-process Store. Spend older than the longest window it
+// has been asked to enforce is dropped on the tenant's next reservation.
+type MemoryStore struct {
+	mu      sync.Mutex
+	nextID  uint64
+	horizon time.Duration
+	tenants map[string][]spend
+}
+
+type spend struct {
+	id     string
+	at     time.Time
+	tokens Tokens
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tenants: make(map[string][]spend)}
+}
+
+// Compile-time check: *MemoryStore implements Store.
+var _ Store = (*MemoryStore)(nil)
+
+// Reserve implements Store.
+func (m *MemoryStore) Reserve(_ context.Context, tenant string, n Tokens, limits []Limit, now time.Time) (Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, l := range limits {
+		m.horizon = max(m.horizon, l.Window)
+	}
+
+	m.prune(tenant, now)
+
+	for _, l := range limits {
+		used, oldest := m.sum(tenant, l.Window, now)
+
+		err := l.Check(used, n)
+		if err == nil {
+			continue
+		}
+
+		err.Tenant = tenant
+		if !oldest.IsZero() && !err.Oversized() {
+			err.RetryAfter = oldest.Add(l.Window).Sub(now)
+		}
+
+		return Reservation{}, err
+	}
+
+	m.nextID++
+	r := Reservation{Tenant: tenant, ID: strconv.FormatUint(m.nextID, 10), At: now, Tokens: n}
+	m.tenants[tenant] = append(m.tenants[tenant], spend{id: r.ID, at: now, tokens: n})
+
+	return r, nil
+}
+
+// Settle implements Store. A reservation already dropped as expired is
+// ignored.
+func (m *MemoryStore) Settle(_ context.Context, r Reservation, actual Tokens) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	spends := m.tenants[r.Tenant]
+	for i := range spends {
+		if spends[i].id == r.ID {
+			spends[i].tokens = actual
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// Usage implements Store.
+func (m *MemoryStore) Usage(_ context.Context, tenant string, window time.Duration, now time.Time) (Tokens, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	used, _ := m.sum(tenant, window, now)
+
+	return used, nil
+}
+
+// sum adds the tenant's spend within window before now and returns the time
+// of the oldest spend counted.
+func (m *MemoryStore) sum(tenant string, window time.Duration, now time.Time) (used Tokens, oldest time.Time) {
+	for _, s := range m.tenants[tenant] {
+		if now.Sub(s.at) >= window {
+			continue
+		}
+
+		used = used.Add(s.tokens)
+		if oldest.IsZero() || s.at.Before(oldest) {
+			oldest = s.at
+		}
+	}
+
+	return used, oldest
+}
+
+// prune drops the tenant's spend older than the horizon.
+func (m *MemoryStore) prune(tenant string, now time.Time) {
+	spends := m.tenants[tenant]
+
+	kept := spends[:0]
+	for _, s := range spends {
+		if now.Sub(s.at) < m.horizon {
+			kept = append(kept, s)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(m.tenants, tenant)
+
+		return
+	}
+
+	m.tenants[tenant] = kept
+}
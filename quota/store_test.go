@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+func TestUsageTokens(t *testing.T) {
+	got := UsageTokens(&ais.Usage{PromptTokens: 10, CompletionTokens: 5, CacheReadTokens: 4})
+	want := Tokens{Prompt: 10, Completion: 5, CacheRead: 4, Total: 15}
+
+	if got != want {
+		t.Errorf("UsageTokens = %+v, want %+v", got, want)
+	}
+
+	if got := UsageTokens(nil); got != (Tokens{}) {
+		t.Errorf("UsageTokens(nil) = %+v", got)
+	}
+}
+
+func TestMemoryStoreRollingWindow(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	limits := []Limit{{Window: time.Minute, Max: Tokens{Total: 100}}}
+
+	if _, err := m.Reserve(ctx, "a", Tokens{Total: 60}, limits, now); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+
+	_, err := m.Reserve(ctx, "a", Tokens{Total: 50}, limits, now.Add(20*time.Second))
+
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("err = %v, want *ExceededError", err)
+	}
+
+	if exceeded.Tenant != "a" || exceeded.Kind != "total" || exceeded.Used != 60 ||
+		exceeded.Requested != 50 || exceeded.Budget != 100 || exceeded.RetryAfter != 40*time.Second {
+		t.Errorf("exceeded = %+v", exceeded)
+	}
+
+	if _, err := m.Reserve(ctx, "b", Tokens{Total: 50}, limits, now.Add(20*time.Second)); err != nil {
+		t.Errorf("other tenant: %v", err)
+	}
+
+	if _, err := m.Reserve(ctx, "a", Tokens{Total: 50}, limits, now.Add(time.Minute)); err != nil {
+		t.Errorf("after the window: %v", err)
+	}
+
+	if len(m.tenants["a"]) != 1 {
+		t.Errorf("spends kept = %d, want the expired one pruned", len(m.tenants["a"]))
+	}
+}
+
+func TestMemoryStoreOversized(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	limits := []Limit{{Window: time.Minute, Max: Tokens{Total: 100}}}
+
+	if _, err := m.Reserve(ctx, "a", Tokens{Total: 60}, limits, now); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+
+	_, err := m.Reserve(ctx, "a", Tokens{Total: 150}, limits, now.Add(20*time.Second))
+
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("err = %v, want *ExceededError", err)
+	}
+
+	if !exceeded.Oversized() || exceeded.RetryAfter != 0 {
+		t.Errorf("exceeded = %+v, want oversized without RetryAfter", exceeded)
+	}
+}
+
+func TestMemoryStoreSettle(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	limits := []Limit{{Window: time.Hour, Max: Tokens{Prompt: 100}}}
+
+	r, err := m.Reserve(ctx, "a", Tokens{Prompt: 80}, limits, now)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+
+	if err := m.Settle(ctx, r, Tokens{Prompt: 30, CacheRead: 10}); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+
+	used, _ := m.Usage(ctx, "a", time.Hour, now.Add(time.Minute))
+	if used != (Tokens{Prompt: 30, CacheRead: 10}) {
+		t.Errorf("usage = %+v", used)
+	}
+
+	if _, err := m.Reserve(ctx, "a", Tokens{Prompt: 70}, limits, now.Add(time.Minute)); err != nil {
+		t.Errorf("reserve after settling down: %v", err)
+	}
+}
+
+func TestMemoryStoreSeveralLimits(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	limits := []Limit{
+		{Window: time.Minute, Max: Tokens{Completion: 50}},
+		{Window: 24 * time.Hour, Max: Tokens{Completion: 80}},
+	}
+
+	for i, at := range []time.Duration{0, 2 * time.Minute} {
+		if _, err := m.Reserve(ctx, "a", Tokens{Completion: 40}, limits, now.Add(at)); err != nil {
+			t.Fatalf("reserve %d: %v", i, err)
+		}
+	}
+
+	_, err := m.Reserve(ctx, "a", Tokens{Completion: 1}, limits, now.Add(4*time.Minute))
+
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit.Window != 24*time.Hour {
+		t.Fatalf("err = %v, want the daily limit exceeded", err)
+	}
+}
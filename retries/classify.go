@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package retries
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// Retryable reports whether err is worth another attempt. It is the default
+// classifier of RetryClient:
+//
+//   - context cancellation and deadline expiry are terminal;
+//   - an *ais.APIError reporting an exceeded context window is terminal
+//     whatever its status, since resending the same prompt cannot succeed;
+//   - an *ais.APIError with status 408, 409, 429 or 5xx (including
+//     Anthropic's 529) is retryable, any other status is terminal;
+//   - an *ais.APIError without a status, as raised by an SSE error event, is
+//     retryable when its type or code names an overload, rate limit or server
+//     error;
+//   - a network error from the transport is retryable;
+//   - anything else (request translation errors, decoding errors) is terminal.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *ais.APIError
+	if errors.As(err, &apiErr) {
+		return retryableAPIError(apiErr)
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// retryableAPIError classifies an API error by context-length markers first,
+// then by status, then by error type or code for status-less stream errors.
+func retryableAPIError(e *ais.APIError) bool {
+	if e.ContextLengthExceeded() {
+		return false
+	}
+
+	switch {
+	case e.StatusCode == http.StatusRequestTimeout,
+		e.StatusCode == http.StatusConflict,
+		e.StatusCode == http.StatusTooManyRequests,
+		e.StatusCode >= http.StatusInternalServerError:
+		return true
+	case e.StatusCode != 0:
+		return false
+	}
+
+	for _, s := range []string{e.Type, e.Code} {
+		s = strings.ToLower(s)
+		if strings.Contains(s, "overloaded") || strings.Contains(s, "rate_limit") ||
+			strings.Contains(s, "server_error") || strings.Contains(s, "api_error") ||
+			s == "unavailable" || s == "resource_exhausted" || s == "internal" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryAfter extracts the server's retry hint from the headers of an
+// *ais.APIError: retry-after-ms (fractional milliseconds) takes precedence over
+// Retry-After (delta seconds or an HTTP date). It reports false when err
+// carries no usable hint.
+func retryAfter(err error, now time.Time) (time.Duration, bool) {
+	var apiErr *ais.APIError
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0, false
+	}
+
+	if v := apiErr.Header.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	v := apiErr.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package retries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel/ais"
+)
+
+// TestRetryable covers the default classification of API, transport and
+// context errors.
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"429", &ais.APIError{StatusCode: 429}, true},
+		{"500", &ais.APIError{StatusCode: 500}, true},
+		{"503", &ais.APIError{StatusCode: 503}, true},
+		{"anthropic 529 overloaded", &ais.APIError{StatusCode: 529, Type: "overloaded_error"}, true},
+		{"408", &ais.APIError{StatusCode: 408}, true},
+		{"400", &ais.APIError{StatusCode: 400}, false},
+		{"401", &ais.APIError{StatusCode: 401}, false},
+		{"404", &ais.APIError{StatusCode: 404}, false},
+		{"openai context length", &ais.APIError{StatusCode: 400, Code: "context_length_exceeded"}, false},
+		{"context length on 5xx", &ais.APIError{StatusCode: 500, Message: "This model's maximum context length is 8192 tokens"}, false},
+		{"anthropic prompt too long", &ais.APIError{StatusCode: 413, Message: "prompt is too long: 210000 tokens"}, false},
+		{"stream overloaded", &ais.APIError{Type: "overloaded_error"}, true},
+		{"stream server_error", &ais.APIError{Type: "server_error"}, true},
+		{"stream invalid request", &ais.APIError{Type: "invalid_request_error"}, false},
+		{"wrapped 429", fmt.Errorf("model x: %w", &ais.APIError{StatusCode: 429}), true},
+		{"transport", fmt.Errorf("aimodel: send request: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), true},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("send: %w", context.DeadlineExceeded), false},
+		{"content part", &ais.ContentPartError{Provider: "anthropic", Type: "input_audio"}, false},
+		{"plain", errors.New("decode"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryAfter covers the header forms and their precedence.
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	withHeader := func(kv ...string) error {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+
+		return &ais.APIError{StatusCode: 429, Header: h}
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{"seconds", withHeader("Retry-After", "3"), 3 * time.Second, true},
+		{"milliseconds win", withHeader("Retry-After", "3", "retry-after-ms", "1500.5"), 1500500 * time.Microsecond, true},
+		{"http date", withHeader("Retry-After", now.Add(10*time.Second).Format(http.TimeFormat)), 10 * time.Second, true},
+		{"past date", withHeader("Retry-After", now.Add(-time.Minute).Format(http.TimeFormat)), 0, true},
+		{"garbage", withHeader("Retry-After", "soon"), 0, false},
+		{"no header", &ais.APIError{StatusCode: 429}, 0, false},
+		{"not an API error", errors.New("x"), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.err, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
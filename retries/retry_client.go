@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package retries provides an opt-in retry wrapper around any
+// aimodel.ChatCompleter. The core client stays one-call-one-request (ADR
+// 0001); callers who want resilience wrap it here and choose the policy.
+package retries
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMultiplier     = 2.0
+	defaultJitter         = 0.5
+)
+
+// RetryClient retries failed chat calls of an inner aimodel.ChatCompleter.
+// It implements aimodel.ChatCompleter, so it can wrap a *aimodel.Client, a
+// composes.ComposeClient, or be a compose entry itself.
+//
+// A streaming call is retried only until its first chunk arrives:
+// ChatCompletionStream reads that chunk before returning, and the returned
+// Stream replays it. An error after the first chunk is the caller's.
+type RetryClient struct {
+	inner          aimodel.ChatCompleter
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	multiplier     float64
+	jitter         float64
+	budget         time.Duration
+	classify       func(error) bool
+	onRetry        func(attempt int, err error, wait time.Duration)
+	nowFunc        func() time.Time
+	sleepFunc      func(ctx context.Context, d time.Duration) error
+	rng            *rand.Rand
+	mu             sync.Mutex // protects rng
+}
+
+// Option configures a RetryClient.
+type Option func(*RetryClient)
+
+// WithMaxAttempts sets the total number of attempts, the first included.
+// Values below 1 are treated as 1. The default is 3.
+func WithMaxAttempts(n int) Option {
+	return func(c *RetryClient) {
+		c.maxAttempts = max(n, 1)
+	}
+}
+
+// WithBackoff sets the exponential backoff: the wait before retry n is
+// initial × multiplier^(n-1), capped at maxBackoff. A server retry hint is
+// never shortened: when it exceeds maxBackoff the call fails with the last
+// error instead of retrying early. The defaults are 500ms, 30s and 2.
+func WithBackoff(initial, maxBackoff time.Duration, multiplier float64) Option {
+	return func(c *RetryClient) {
+		c.initialBackoff = initial
+		c.maxBackoff = maxBackoff
+		c.multiplier = multiplier
+	}
+}
+
+// WithJitter sets the fraction of each backoff that is randomized: a wait w
+// becomes a uniform value in [w×(1-jitter), w]. It is clamped to [0, 1]; the
+// default 0.5 is "equal jitter". A server retry hint is never jittered.
+func WithJitter(jitter float64) Option {
+	return func(c *RetryClient) {
+		c.jitter = min(max(jitter, 0), 1)
+	}
+}
+
+// WithBudget caps the total time spent on one call, attempts and waits
+// included. A retry whose wait would end past the budget is not started and
+// the last error is returned instead. Zero, the default, means no budget
+// beyond the attempt count and the context deadline.
+func WithBudget(d time.Duration) Option {
+	return func(c *RetryClient) {
+		c.budget = d
+	}
+}
+
+// WithClassifier replaces Retryable as the rule deciding whether an error is
+// worth another attempt.
+func WithClassifier(fn func(error) bool) Option {
+	return func(c *RetryClient) {
+		c.classify = fn
+	}
+}
+
+// WithOnRetry registers a callback fired before each wait, with the number of
+// the attempt that failed (starting at 1), its error and the wait chosen.
+func WithOnRetry(fn func(attempt int, err error, wait time.Duration)) Option {
+	return func(c *RetryClient) {
+		c.onRetry = fn
+	}
+}
+
+// NewRetryClient wraps inner with the retry policy configured by opts.
+func NewRetryClient(inner aimodel.ChatCompleter, opts ...Option) *RetryClient {
+	c := &RetryClient{
+		inner:          inner,
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		multiplier:     defaultMultiplier,
+		jitter:         defaultJitter,
+		classify:       Retryable,
+		nowFunc:        time.Now,
+		sleepFunc:      sleep,
+		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Compile-time check: *RetryClient implements aimodel.ChatCompleter.
+var _ aimodel.ChatCompleter = (*RetryClient)(nil)
+
+// ChatCompletion sends a non-streaming request, retrying retryable failures.
+func (c *RetryClient) ChatCompletion(ctx context.Context, req *ais.ChatRequest) (*ais.ChatResponse, error) {
+	var resp *ais.ChatResponse
+
+	err := c.retry(ctx, func() (err error) {
+		resp, err = c.inner.ChatCompletion(ctx, req)
+
+		return err
+	})
+
+	return resp, err
+}
+
+// ChatCompletionStream sends a streaming request and waits for its first
+// chunk, retrying while the stream fails to open or fails before that chunk.
+// The returned Stream replays the first chunk on the first Recv.
+func (c *RetryClient) ChatCompletionStream(ctx context.Context, req *ais.ChatRequest) (*aimodel.Stream, error) {
+	var stream *aimodel.Stream
+
+	err := c.retry(ctx, func() error {
+		s, err := c.inner.ChatCompletionStream(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		stream, err = s.Peek()
+
+		return err
+	})
+
+	return stream, err
+}
+
+// retry runs attempt until it succeeds, fails terminally, exhausts the
+// attempt count or budget, or ctx ends. Cancellation returns the context error
+// rather than the last attempt's error.
+func (c *RetryClient) retry(ctx context.Context, attempt func() error) error {
+	start := c.nowFunc()
+
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if n >= c.maxAttempts || !c.classify(err) {
+			return err
+		}
+
+		wait, hinted := c.wait(n, err)
+		if hinted && wait > c.maxBackoff {
+			return err
+		}
+
+		if c.budget > 0 && c.nowFunc().Add(wait).Sub(start) > c.budget {
+			return err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && c.nowFunc().Add(wait).After(deadline) {
+			return err
+		}
+
+		if c.onRetry != nil {
+			c.onRetry(n, err, wait)
+		}
+
+		if err := c.sleepFunc(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// wait picks the delay after failed attempt n: the server's retry hint when
+// present, reported by hinted, otherwise the jittered exponential backoff.
+func (c *RetryClient) wait(n int, err error) (d time.Duration, hinted bool) {
+	if d, ok := retryAfter(err, c.nowFunc()); ok {
+		return d, true
+	}
+
+	backoff := float64(c.initialBackoff)
+	for range n - 1 {
+		backoff *= c.multiplier
+		if backoff >= float64(c.maxBackoff) {
+			break
+		}
+	}
+
+	backoff = min(backoff, float64(c.maxBackoff))
+
+	c.mu.Lock()
+	r := c.rng.Float64()
+	c.mu.Unlock()
+
+	return time.Duration(backoff * (1 - c.jitter*r)), false
+}
+
+// sleep waits for d or until ctx ends.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,348 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package retries
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vogo/aimodel"
+	"github.com/vogo/aimodel/ais"
+)
+
+// scriptedCompleter fails its first len(errs) calls with the scripted errors
+// and then succeeds.
+type scriptedCompleter struct {
+	errs  []error
+	calls int
+	// streamErrs, when set, are returned by the first Recv of each stream
+	// instead of failing the open.
+	streamErrs []error
+	closed     int
+}
+
+func (s *scriptedCompleter) next() error {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return s.errs[s.calls-1]
+	}
+
+	return nil
+}
+
+func (s *scriptedCompleter) ChatCompletion(context.Context, *ais.ChatRequest) (*ais.ChatResponse, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+
+	return &ais.ChatResponse{ID: "ok"}, nil
+}
+
+func (s *scriptedCompleter) ChatCompletionStream(context.Context, *ais.ChatRequest) (*aimodel.Stream, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	if s.calls <= len(s.streamErrs) {
+		firstErr = s.streamErrs[s.calls-1]
+	}
+
+	chunks := []*ais.StreamChunk{{ID: "c1"}, {ID: "c2"}}
+
+	return aimodel.NewStream(func() (*ais.StreamChunk, error) {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		c := chunks[0]
+		chunks = chunks[1:]
+
+		return c, nil
+	}, func() error {
+		s.closed++
+		return nil
+	}), nil
+}
+
+// newTestClient returns a RetryClient with a recorded, instant sleep and a
+// fixed jitter draw.
+func newTestClient(inner aimodel.ChatCompleter, waits *[]time.Duration, opts ...Option) *RetryClient {
+	c := NewRetryClient(inner, opts...)
+	c.sleepFunc = func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	c.rng = newFixedRand()
+
+	return c
+}
+
+// halfSource is a rand.Source whose Float64 draw is always 0.5.
+type halfSource struct{}
+
+func (halfSource) Int63() int64 { return 1 << 62 }
+
+func (halfSource) Seed(int64) {}
+
+func newFixedRand() *rand.Rand { return rand.New(halfSource{}) }
+
+var (
+	errRateLimited = &ais.APIError{StatusCode: http.StatusTooManyRequests}
+	errOverloaded  = &ais.APIError{Type: "overloaded_error", Message: "Overloaded"}
+)
+
+// TestChatCompletion_RetriesThenSucceeds verifies retryable failures are
+// retried with exponential backoff.
+func TestChatCompletion_RetriesThenSucceeds(t *testing.T) {
+	inner := &scriptedCompleter{errs: []error{errRateLimited, errRateLimited}}
+
+	var waits []time.Duration
+
+	c := newTestClient(inner, &waits, WithBackoff(100*time.Millisecond, time.Second, 2), WithJitter(0))
+
+	resp, err := c.ChatCompletion(context.Background(), &ais.ChatRequest{})
+	if err != nil || resp.ID != "ok" {
+		t.Fatalf("ChatCompletion = %v, %v", resp, err)
+	}
+
+	if inner.calls != 3 {
+		t.Errorf("calls = %d, want 3", inner.calls)
+	}
+
+	if len(waits) != 2 || waits[0] != 100*time.Millisecond || waits[1] != 200*time.Millisecond {
+		t.Errorf("waits = %v, want [100ms 200ms]", waits)
+	}
+}
+
+// TestChatCompletion_TerminalNotRetried verifies a terminal error returns
+// after one attempt.
+func TestChatCompletion_TerminalNotRetried(t *testing.T) {
+	terminal := &ais.APIError{StatusCode: http.StatusBadRequest, Code: "context_length_exceeded"}
+	inner := &scriptedCompleter{errs: []error{terminal}}
+
+	var waits []time.Duration
+
+	_, err := newTestClient(inner, &waits).ChatCompletion(context.Background(), &ais.ChatRequest{})
+	if !errors.Is(err, terminal) || inner.calls != 1 || len(waits) != 0 {
+		t.Errorf("err = %v, calls = %d, waits = %v", err, inner.calls, waits)
+	}
+}
+
+// TestChatCompletion_MaxAttempts verifies the last error is returned once the
+// attempt count is exhausted.
+func TestChatCompletion_MaxAttempts(t *testing.T) {
+	inner := &scriptedCompleter{errs: []error{errRateLimited, errRateLimited, errRateLimited, errRateLimited}}
+
+	var (
+		waits   []time.Duration
+		retried []int
+	)
+
+	c := newTestClient(inner, &waits, WithMaxAttempts(2), WithOnRetry(func(attempt int, _ error, _ time.Duration) {
+		retried = append(retried, attempt)
+	}))
+
+	if _, err := c.ChatCompletion(context.Background(), &ais.ChatRequest{}); !errors.Is(err, errRateLimited) {
+		t.Fatalf("err = %v", err)
+	}
+
+	if inner.calls != 2 || len(retried) != 1 || retried[0] != 1 {
+		t.Errorf("calls = %d, retried = %v", inner.calls, retried)
+	}
+}
+
+// TestChatCompletion_HonorsRetryAfter verifies the server hint replaces the
+// backoff, unjittered.
+func TestChatCompletion_HonorsRetryAfter(t *testing.T) {
+	hinted := &ais.APIError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"4"}}}
+	inner := &scriptedCompleter{errs: []error{hinted}}
+
+	var waits []time.Duration
+
+	if _, err := newTestClient(inner, &waits).ChatCompletion(context.Background(), &ais.ChatRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(waits) != 1 || waits[0] != 4*time.Second {
+		t.Errorf("waits = %v, want [4s]", waits)
+	}
+}
+
+// TestChatCompletion_HintBeyondMaxBackoff verifies a server hint longer than
+// the maximum backoff is never shortened: the call fails with the hinted error
+// instead of retrying early.
+func TestChatCompletion_HintBeyondMaxBackoff(t *testing.T) {
+	hinted := &ais.APIError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"3600"}}}
+	inner := &scriptedCompleter{errs: []error{hinted}}
+
+	var waits []time.Duration
+
+	c := newTestClient(inner, &waits, WithBackoff(time.Second, 10*time.Second, 2))
+
+	if _, err := c.ChatCompletion(context.Background(), &ais.ChatRequest{}); !errors.Is(err, hinted) {
+		t.Fatalf("err = %v, want the hinted error", err)
+	}
+
+	if inner.calls != 1 || len(waits) != 0 {
+		t.Errorf("calls = %d, waits = %v; want no second attempt", inner.calls, waits)
+	}
+}
+
+// TestChatCompletion_Budget verifies a retry that would overrun the budget is
+// not started.
+func TestChatCompletion_Budget(t *testing.T) {
+	hinted := &ais.APIError{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"30"}}}
+	inner := &scriptedCompleter{errs: []error{hinted}}
+
+	var waits []time.Duration
+
+	c := newTestClient(inner, &waits, WithBudget(10*time.Second))
+
+	if _, err := c.ChatCompletion(context.Background(), &ais.ChatRequest{}); !errors.Is(err, hinted) {
+		t.Fatalf("err = %v, want the hinted error", err)
+	}
+
+	if inner.calls != 1 || len(waits) != 0 {
+		t.Errorf("calls = %d, waits = %v", inner.calls, waits)
+	}
+}
+
+// TestChatCompletion_CancelDuringWait verifies cancellation while backing off
+// returns the context error.
+func TestChatCompletion_CancelDuringWait(t *testing.T) {
+	inner := &scriptedCompleter{errs: []error{errRateLimited}}
+	c := NewRetryClient(inner, WithBackoff(time.Hour, time.Hour, 2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.onRetry = func(int, error, time.Duration) { cancel() }
+
+	if _, err := c.ChatCompletion(ctx, &ais.ChatRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+// TestBackoffJitterAndCap verifies the jitter range and the backoff cap.
+func TestBackoffJitterAndCap(t *testing.T) {
+	c := NewRetryClient(&scriptedCompleter{}, WithBackoff(time.Second, 5*time.Second, 2), WithJitter(0.5))
+	c.rng = newFixedRand()
+
+	if got, _ := c.wait(1, errRateLimited); got != 750*time.Millisecond {
+		t.Errorf("wait(1) = %v, want 750ms with a 0.5 draw", got)
+	}
+
+	if got, _ := c.wait(10, errRateLimited); got != 3750*time.Millisecond {
+		t.Errorf("wait(10) = %v, want the 5s cap jittered to 3.75s", got)
+	}
+}
+
+// TestChatCompletionStream_RetriesBeforeFirstChunk verifies a stream that
+// fails before its first chunk is reopened, and the returned stream replays
+// the peeked chunk.
+func TestChatCompletionStream_RetriesBeforeFirstChunk(t *testing.T) {
+	inner := &scriptedCompleter{errs: []error{errRateLimited}, streamErrs: []error{nil, errOverloaded}}
+
+	var waits []time.Duration
+
+	s, err := newTestClient(inner, &waits).ChatCompletionStream(context.Background(), &ais.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+
+	var ids []string
+
+	for {
+		chunk, err := s.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Recv: %v", err)
+			}
+
+			break
+		}
+
+		ids = append(ids, chunk.ID)
+	}
+
+	_ = s.Close()
+
+	if inner.calls != 3 || len(waits) != 2 {
+		t.Errorf("calls = %d, waits = %v, want 3 calls and 2 waits", inner.calls, waits)
+	}
+
+	if len(ids) != 2 || ids[0] != "c1" || ids[1] != "c2" {
+		t.Errorf("chunks = %v, want [c1 c2]", ids)
+	}
+
+	if inner.closed != 2 {
+		t.Errorf("closed = %d, want the failed and the returned stream closed", inner.closed)
+	}
+}
+
+// TestChatCompletionStream_ThroughClient verifies headers from a real 429
+// drive the retry through an *aimodel.Client.
+func TestChatCompletionStream_ThroughClient(t *testing.T) {
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("retry-after-ms", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"error":{"message":"slow down","type":"rate_limit_error"}}`)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"id\":\"s1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"a\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client, err := aimodel.NewClient(aimodel.WithAPIKey("k"), aimodel.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var waits []time.Duration
+
+	s, err := newTestClient(client, &waits).ChatCompletionStream(context.Background(), &ais.ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	chunk, err := s.Recv()
+	if err != nil || chunk.ID != "s1" {
+		t.Fatalf("Recv = %v, %v", chunk, err)
+	}
+
+	if len(waits) != 1 || waits[0] != 5*time.Millisecond {
+		t.Errorf("waits = %v, want [5ms]", waits)
+	}
+}
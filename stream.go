@@ -18,6 +18,7 @@
 package aimodel
 
 import (
+	"errors"
 	"io"
 	"sync"
 	"sync/atomic"
@@ -26,14 +27,16 @@ import (
 )
 
 // Stream reads streaming chat completion responses using SSE.
-// Stream is safe for concurrent use between a single Recv caller and Close.
+// Stream is safe for concurrent use between a single Recv caller and Close;
+// Meta and SetMeta may be called from any goroutine.
 type Stream struct {
 	mu      sync.Mutex
-	reader  io.ReadCloser
+	reader  io.Closer
 	recv    func() (*ais.StreamChunk, error)
 	closed  atomic.Bool
 	usage   *ais.Usage // captured from the final chunk that includes usage data
 	onClose func(*ais.Usage)
+	meta    atomic.Pointer[ais.ResponseMeta]
 }
 
 // newStream wraps a streaming response body and its provider-supplied SSE
@@ -46,6 +49,26 @@ func newStream(body io.ReadCloser, decoder ais.StreamDecoder) *Stream {
 	}
 }
 
+// NewStream builds a Stream from a chunk source and a release function, for
+// wrappers that splice or replay streams outside this package. recv follows
+// the Recv contract (io.EOF ends the stream); closeFn is called once by Close
+// and may be nil.
+func NewStream(recv func() (*ais.StreamChunk, error), closeFn func() error) *Stream {
+	if closeFn == nil {
+		closeFn = func() error { return nil }
+	}
+
+	return &Stream{
+		reader: closeFunc(closeFn),
+		recv:   recv,
+	}
+}
+
+// closeFunc adapts a function to io.Closer.
+type closeFunc func() error
+
+func (f closeFunc) Close() error { return f() }
+
 // Recv reads the next chunk from the stream.
 // Returns io.EOF when the stream is done.
 func (s *Stream) Recv() (*ais.StreamChunk, error) {
@@ -78,6 +101,77 @@ func (s *Stream) Usage() *ais.Usage {
 	return s.usage
 }
 
+// Meta returns the HTTP metadata of the response that opened the stream
+// (request ID, rate-limit state, headers). It is nil for a stream built with
+// NewStream unless set through SetMeta.
+func (s *Stream) Meta() *ais.ResponseMeta {
+	return s.meta.Load()
+}
+
+// SetMeta sets the metadata returned by Meta, so wrappers that rebuild a
+// stream with NewStream can carry the original response's metadata over. It
+// is safe to call while Meta or Recv run on other goroutines, e.g. when a
+// failover replaces the serving response mid-stream.
+func (s *Stream) SetMeta(meta *ais.ResponseMeta) {
+	s.meta.Store(meta)
+}
+
+// Peek waits for the first chunk of s, so a wrapper can tell a stream that
+// fails before producing output from one that works. On an error other than
+// io.EOF it closes s and returns the error; otherwise it returns a Stream
+// that replays the first chunk, or the end of an empty stream, continues
+// with s and carries its Meta.
+func (s *Stream) Peek() (*Stream, error) {
+	first, err := s.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = s.Close()
+
+		return nil, err
+	}
+
+	peeked := true
+	firstErr := err
+
+	replay := NewStream(func() (*ais.StreamChunk, error) {
+		if peeked {
+			peeked = false
+
+			return first, firstErr
+		}
+
+		return s.Recv()
+	}, s.Close)
+	replay.SetMeta(s.Meta())
+
+	return replay, nil
+}
+
+// Collect reads the stream to its end, closes it, and returns the complete
+// response accumulated with ais.Accumulator — the value the unary call would
+// have returned, with Meta set from the stream. On a mid-stream error it
+// returns the partial response together with the error.
+func (s *Stream) Collect() (*ais.ChatResponse, error) {
+	defer func() { _ = s.Close() }()
+
+	var acc ais.Accumulator
+
+	for {
+		chunk, err := s.Recv()
+		acc.Add(chunk)
+
+		if err != nil {
+			resp := acc.Response()
+			resp.Meta = s.Meta()
+
+			if errors.Is(err, io.EOF) {
+				return resp, nil
+			}
+
+			return resp, err
+		}
+	}
+}
+
 // Close closes the stream and releases resources.
 // Close is safe to call concurrently with Recv and is idempotent.
 func (s *Stream) Close() error {
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/vogo/aimodel/ais"
@@ -135,3 +136,154 @@ func TestWrapStreamNil(t *testing.T) {
 		t.Errorf("WrapStream(nil, nil) = %v, want nil", result)
 	}
 }
+
+func TestNewStreamFromFunc(t *testing.T) {
+	chunks := []*ais.StreamChunk{{ID: "a"}, {ID: "b", Usage: &ais.Usage{TotalTokens: 3}}}
+	closed := 0
+
+	stream := NewStream(func() (*ais.StreamChunk, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		c := chunks[0]
+		chunks = chunks[1:]
+
+		return c, nil
+	}, func() error {
+		closed++
+		return nil
+	})
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Recv: %v", err)
+			}
+
+			break
+		}
+	}
+
+	if u := stream.Usage(); u == nil || u.TotalTokens != 3 {
+		t.Errorf("usage = %+v, want TotalTokens 3", u)
+	}
+
+	_ = stream.Close()
+	_ = stream.Close()
+
+	if closed != 1 {
+		t.Errorf("close func called %d times, want 1", closed)
+	}
+
+	if err := NewStream(func() (*ais.StreamChunk, error) { return nil, io.EOF }, nil).Close(); err != nil {
+		t.Errorf("Close with nil closeFn: %v", err)
+	}
+}
+
+func TestStreamPeek(t *testing.T) {
+	meta := &ais.ResponseMeta{RequestID: "req_1"}
+	chunks := []*ais.StreamChunk{{ID: "a"}, {ID: "b"}}
+	closed := 0
+
+	s := NewStream(func() (*ais.StreamChunk, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		c := chunks[0]
+		chunks = chunks[1:]
+
+		return c, nil
+	}, func() error {
+		closed++
+		return nil
+	})
+	s.SetMeta(meta)
+
+	peeked, err := s.Peek()
+	if err != nil {
+		t.Fatalf("Peek: %v", err)
+	}
+
+	if len(chunks) != 1 || peeked.Meta() != meta {
+		t.Fatalf("Peek read %d chunks, meta %+v", 2-len(chunks), peeked.Meta())
+	}
+
+	for _, want := range []string{"a", "b"} {
+		if c, err := peeked.Recv(); err != nil || c.ID != want {
+			t.Fatalf("Recv = %+v, %v; want %q", c, err, want)
+		}
+	}
+
+	_ = peeked.Close()
+
+	if closed != 1 {
+		t.Errorf("close func called %d times, want 1", closed)
+	}
+
+	boom := errors.New("boom")
+	failing := NewStream(func() (*ais.StreamChunk, error) { return nil, boom }, func() error {
+		closed++
+		return nil
+	})
+
+	if p, err := failing.Peek(); p != nil || !errors.Is(err, boom) || closed != 2 {
+		t.Errorf("Peek = %v, %v (closed %d); want boom and the stream closed", p, err, closed)
+	}
+
+	empty, err := NewStream(func() (*ais.StreamChunk, error) { return nil, io.EOF }, nil).Peek()
+	if err != nil {
+		t.Fatalf("Peek empty: %v", err)
+	}
+
+	if _, err := empty.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("Recv on empty = %v, want io.EOF", err)
+	}
+}
+
+// TestStreamMetaConcurrent verifies Meta can be read while a wrapper replaces
+// it, as a mid-stream failover does (run with -race).
+func TestStreamMetaConcurrent(t *testing.T) {
+	s := NewStream(func() (*ais.StreamChunk, error) { return nil, io.EOF }, nil)
+	first, second := &ais.ResponseMeta{RequestID: "a"}, &ais.ResponseMeta{RequestID: "b"}
+	s.SetMeta(first)
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		for range 100 {
+			s.SetMeta(second)
+			s.SetMeta(first)
+		}
+	})
+
+	for range 100 {
+		if m := s.Meta(); m != first && m != second {
+			t.Fatalf("Meta = %+v", m)
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestStreamCollectReturnsPartialOnError(t *testing.T) {
+	boom := errors.New("boom")
+	stream := newStream(io.NopCloser(strings.NewReader("")), &sequenceDecoder{
+		chunks: []*ais.StreamChunk{{ID: "p", Choices: []ais.StreamChunkChoice{{Delta: ais.Message{Content: ais.NewTextContent("part")}}}}},
+		err:    boom,
+	})
+
+	resp, err := stream.Collect()
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+
+	if resp == nil || resp.ID != "p" || resp.Choices[0].Message.Content.Text() != "part" {
+		t.Errorf("partial response = %+v", resp)
+	}
+
+	if _, err := stream.Recv(); !errors.Is(err, ais.ErrStreamClosed) {
+		t.Errorf("Recv after Collect = %v, want ErrStreamClosed", err)
+	}
+}